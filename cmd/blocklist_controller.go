@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/httputil"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+)
+
+type blockAuthorRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (e *env) handleGetBlockedAuthors(c *gin.Context) {
+	authors, err := e.blocklistSvc.GetBlocked()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authors)
+}
+
+func (e *env) handleBlockAuthor(c *gin.Context) {
+	var req blockAuthorRequest
+	err := json.NewDecoder(c.Request.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		c.Error(apierror.InvalidBody("must be a JSON object"))
+		return
+	}
+
+	author, err := e.blocklistSvc.Block(c.Param("authorId"), req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, author)
+}
+
+func (e *env) handleUnblockAuthor(c *gin.Context) {
+	err := e.blocklistSvc.Unblock(c.Param("authorId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
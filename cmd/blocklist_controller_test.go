@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleGetBlockedAuthors(t *testing.T) {
+	assert := assert.New(t)
+
+	blocklistRepo := &repository.MockBlocklistRepo{
+		FindAllAuthors: []domain.BlockedAuthor{
+			domain.BlockedAuthor{AuthorID: "a-1", Reason: "spam", CreatedAt: time.Now().UTC()},
+		},
+	}
+
+	conf := getTestConfig()
+	server := newServer(getTestBlocklistEnv(blocklistRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	req := createTestGetRequest(token, "/v1/authors/blocklist")
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, blocklistRepo.FindAllInvocations)
+	var authors []domain.BlockedAuthor
+	err := json.NewDecoder(res.Body).Decode(&authors)
+	assert.NoError(err)
+	assert.Equal(1, len(authors))
+	assert.Equal("a-1", authors[0].AuthorID)
+
+	blocklistRepo.UnsetArgs()
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+	req = createTestGetRequest(userToken, "/v1/authors/blocklist")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusForbidden, res.Code)
+	assert.Equal(0, blocklistRepo.FindAllInvocations)
+}
+
+func TestHandleBlockAuthor(t *testing.T) {
+	assert := assert.New(t)
+
+	blocklistRepo := &repository.MockBlocklistRepo{}
+
+	conf := getTestConfig()
+	server := newServer(getTestBlocklistEnv(blocklistRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	body := blockAuthorRequest{Reason: "pump and dump"}
+	req := createTestRequestWithBody(token, "/v1/authors/blocklist/a-1", http.MethodPut, body)
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, blocklistRepo.SaveInvocations)
+	assert.Equal("a-1", blocklistRepo.SaveArg.AuthorID)
+	assert.Equal("pump and dump", blocklistRepo.SaveArg.Reason)
+
+	blocklistRepo.UnsetArgs()
+	req = createTestPutRequest(token, "/v1/authors/blocklist/a-2")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("a-2", blocklistRepo.SaveArg.AuthorID)
+	assert.Equal("", blocklistRepo.SaveArg.Reason)
+
+	blocklistRepo.UnsetArgs()
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+	req = createTestPutRequest(userToken, "/v1/authors/blocklist/a-3")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusForbidden, res.Code)
+	assert.Equal(0, blocklistRepo.SaveInvocations)
+}
+
+func TestHandleUnblockAuthor(t *testing.T) {
+	assert := assert.New(t)
+
+	blocklistRepo := &repository.MockBlocklistRepo{}
+
+	conf := getTestConfig()
+	server := newServer(getTestBlocklistEnv(blocklistRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	req := createTestRequest(token, "/v1/authors/blocklist/a-1", http.MethodDelete)
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("a-1", blocklistRepo.DeleteArg)
+
+	blocklistRepo.UnsetArgs()
+	blocklistRepo.DeleteErr = repository.ErrNoSuchAuthor
+	req = createTestRequest(token, "/v1/authors/blocklist/missing", http.MethodDelete)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusNotFound, res.Code)
+	assert.Equal("missing", blocklistRepo.DeleteArg)
+}
+
+func getTestBlocklistEnv(blocklistRepo repository.BlocklistRepo) *env {
+	e := getTestEnv(nil, nil)
+	e.blocklistSvc = service.NewBlocklistService(blocklistRepo)
+	return e
+}
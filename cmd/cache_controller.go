@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/stock-search/pkg/cache"
+)
+
+type cacheStatsResponse struct {
+	Enabled bool `json:"enabled"`
+	cache.Stats
+}
+
+func (e *env) handleGetCacheStats(c *gin.Context) {
+	if e.stockCache == nil {
+		c.JSON(http.StatusOK, cacheStatsResponse{Enabled: false})
+		return
+	}
+
+	c.JSON(http.StatusOK, cacheStatsResponse{
+		Enabled: true,
+		Stats:   e.stockCache.CacheStats(),
+	})
+}
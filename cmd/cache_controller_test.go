@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleGetCacheStats(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{domain.Stock{Symbol: "TSLA"}},
+	}
+	conf := getTestConfig()
+	e := getTestEnv(stockRepo, nil)
+	e.stockCache = service.NewCachedStockService(e.stockSvc, service.NewStockCache(service.DefaultCacheOptions()),
+		service.NewVersionTracker(stockRepo, &repository.MockVersionRepo{}, 0))
+	e.stockSvc = e.stockCache
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks/suggestions"))
+	performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks/suggestions"))
+	assert.Equal(1, stockRepo.FindMostCommonInvocations)
+
+	res := performTestRequest(server.Handler, createTestGetRequest(token, "/v1/cache/stats"))
+	assert.Equal(http.StatusOK, res.Code)
+	var stats cacheStatsResponse
+	err := json.NewDecoder(res.Body).Decode(&stats)
+	assert.NoError(err)
+	assert.True(stats.Enabled)
+	assert.Equal(int64(1), stats.Hits)
+	assert.Equal(int64(1), stats.Misses)
+	assert.Equal(1, stats.Entries)
+
+	e.stockCache = nil
+	res = performTestRequest(server.Handler, createTestGetRequest(token, "/v1/cache/stats"))
+	assert.Equal(http.StatusOK, res.Code)
+	stats = cacheStatsResponse{}
+	err = json.NewDecoder(res.Body).Decode(&stats)
+	assert.NoError(err)
+	assert.False(stats.Enabled)
+
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+	res = performTestRequest(server.Handler, createTestGetRequest(userToken, "/v1/cache/stats"))
+	assert.Equal(http.StatusForbidden, res.Code)
+}
@@ -2,9 +2,17 @@ package main
 
 import (
 	"log"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/stock-search/pkg/graphqlapi"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/validation"
 
 	"github.com/mimir-news/pkg/dbutil"
 )
@@ -16,24 +24,216 @@ const (
 )
 
 var (
-	unsecuredRoutes        = []string{"/health"}
-	defaultSearchLimit     = 10
-	defaultSuggestionLimit = 5
+	unsecuredRoutes         = []string{"/health"}
+	defaultGRPCPort         = "9090"
+	defaultAnomalyLimit     = 20
+	defaultDeliveryLimit    = 50
+	webhookTimeout          = 5 * time.Second
+	shutdownTimeout         = 10 * time.Second
+	streamHeartbeatInterval = 15 * time.Second
+	typeaheadDebounce       = 100 * time.Millisecond
+	typeaheadWriteTimeout   = 5 * time.Second
+	typeaheadMaxMessageSize = int64(1024)
 )
 
+// Storage backends selected by DB_BACKEND.
+const (
+	backendPostgres = "postgres"
+	backendSQLite   = "sqlite"
+	backendMemory   = "memory"
+)
+
+const defaultDBFile = "stock-search.db"
+
 type config struct {
-	db             dbutil.Config
-	port           string
-	JWTCredentials auth.JWTCredentials
+	backend          string
+	db               dbutil.Config
+	dbFile           string
+	memorySeedFile   string
+	migrateOnStartup bool
+	streamRelayDSN   string
+	port             string
+	grpcPort         string
+	JWTCredentials   auth.JWTCredentials
+	stockOptions     repository.StockOptions
+	countOptions     repository.CountOptions
+	cacheOptions     service.CacheOptions
+	anomalyOptions   service.AnomalyOptions
+	webhookOptions   service.WebhookOptions
+	graphqlLimits    graphqlapi.Limits
+	rules            requestRules
+	cacheControls    cacheControls
+}
+
+// cacheControls Cache-Control headers of the routes which send validators derived from the
+// data version, an empty value sends no Cache-Control header. All of these routes require
+// a token, so shared caches must not store their responses by default.
+type cacheControls struct {
+	search      string
+	suggestions string
+	anomalies   string
+}
+
+func defaultCacheControls() cacheControls {
+	return cacheControls{
+		search:      "private, max-age=60",
+		suggestions: "private, max-age=300",
+		anomalies:   "private, max-age=60",
+	}
+}
+
+// requestRules validation rules of request parameters. The search rules
+// are shared by all APIs, the remaining limits only exist over HTTP.
+type requestRules struct {
+	validation.Rules
+	anomalyLimit  validation.LimitRule
+	deliveryLimit validation.LimitRule
+}
+
+func defaultRequestRules() requestRules {
+	return requestRules{
+		Rules:         validation.DefaultRules(),
+		anomalyLimit:  validation.LimitRule{Default: defaultAnomalyLimit, Min: 1, Max: 100, Policy: validation.Clamp},
+		deliveryLimit: validation.LimitRule{Default: defaultDeliveryLimit, Min: 1, Max: 200, Policy: validation.Clamp},
+	}
 }
 
 func getConfig() config {
-	jwtCredentials := getJWTCredentials(mustGetenv("JWT_CREDENTIALS_FILE"))
+	cfg := getStorageConfig()
+	cfg.memorySeedFile = os.Getenv("MEMORY_SEED_FILE")
+	cfg.migrateOnStartup = getenv("MIGRATE_ON_STARTUP", strconv.FormatBool(cfg.backend == backendSQLite)) == "true"
+	cfg.streamRelayDSN = getStreamRelayDSN(cfg.backend)
+	cfg.JWTCredentials = getJWTCredentials(mustGetenv("JWT_CREDENTIALS_FILE"))
+	cfg.port = mustGetenv("SERVICE_PORT")
+	cfg.grpcPort = getenv("GRPC_PORT", defaultGRPCPort)
+	cfg.stockOptions = getStockOptions()
+	cfg.countOptions = getCountOptions()
+	cfg.cacheOptions = getCacheOptions()
+	cfg.anomalyOptions = getAnomalyOptions()
+	cfg.webhookOptions = getWebhookOptions()
+	cfg.graphqlLimits = getGraphQLLimits()
+	cfg.rules = getRequestRules()
+	cfg.cacheControls = getCacheControls()
+
+	return cfg
+}
+
+// getStorageConfig reads the configuration of the storage backend,
+// which is all the migrate subcommand needs.
+func getStorageConfig() config {
+	backend := getBackend()
+
+	var db dbutil.Config
+	if backend == backendPostgres {
+		db = dbutil.MustGetConfig("DB")
+	}
 
 	return config{
-		db:             dbutil.MustGetConfig("DB"),
-		JWTCredentials: jwtCredentials,
-		port:           mustGetenv("SERVICE_PORT"),
+		backend: backend,
+		db:      db,
+		dbFile:  getenv("DB_FILE", defaultDBFile),
+	}
+}
+
+// getStreamRelayDSN reads the data source which ranking events are relayed between replicas over,
+// connecting to STREAM_RELAY_DB_HOST with the database and credentials of the DB_ variables.
+// Relaying is disabled if unset, which is only correct when running a single replica.
+func getStreamRelayDSN(backend string) string {
+	host := getenv("STREAM_RELAY_DB_HOST", "")
+	if host == "" || backend != backendPostgres {
+		return ""
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(mustGetenv("DB_USERNAME"), mustGetenv("DB_PASSWORD")),
+		Host:     host + ":" + getenv("STREAM_RELAY_DB_PORT", mustGetenv("DB_PORT")),
+		Path:     "/" + mustGetenv("DB_NAME"),
+		RawQuery: "sslmode=" + getenv("STREAM_RELAY_DB_SSLMODE", "disable"),
+	}
+
+	return dsn.String()
+}
+
+// getBackend reads the storage backend. The sqlite backend stores all data in DB_FILE
+// and the memory backend keeps all data in process, neither needs a database server.
+func getBackend() string {
+	backend := getenv("DB_BACKEND", backendPostgres)
+	switch backend {
+	case backendPostgres, backendSQLite, backendMemory:
+		return backend
+	default:
+		log.Fatalf("Invalid DB_BACKEND: %s\n", backend)
+		return ""
+	}
+}
+
+func getStockOptions() repository.StockOptions {
+	opts := repository.DefaultStockOptions()
+	opts.PhoneticMatching = getenv("PHONETIC_MATCHING_ENABLED", "false") == "true"
+
+	return opts
+}
+
+func getCountOptions() repository.CountOptions {
+	opts := repository.DefaultCountOptions()
+
+	mode, ok := repository.ParseCountMode(getenv("COUNT_MODE", string(opts.Mode)))
+	if !ok {
+		log.Fatalf("Invalid COUNT_MODE: %s\n", os.Getenv("COUNT_MODE"))
+	}
+	opts.Mode = mode
+
+	period := getenv("RANKING_PERIOD", "")
+	if period != "" {
+		d, err := time.ParseDuration(period)
+		if err != nil {
+			log.Fatalf("Invalid RANKING_PERIOD: %s\n", err)
+		}
+		opts.Period = d
+	}
+
+	opts.Spam = repository.SpamFilter{
+		MinFollowers:  getIntEnv("SPAM_MIN_FOLLOWERS", 0),
+		MaxDailyPosts: getIntEnv("SPAM_MAX_DAILY_POSTS", 0),
+		MaxCashtags:   getIntEnv("SPAM_MAX_CASHTAGS", 0),
+	}
+
+	return opts
+}
+
+func getCacheOptions() service.CacheOptions {
+	opts := service.DefaultCacheOptions()
+	opts.Enabled = getenv("CACHE_ENABLED", "true") == "true"
+	opts.Size = int(getIntEnv("CACHE_SIZE", int64(opts.Size)))
+
+	ttl := getenv("CACHE_TTL", "")
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid CACHE_TTL: %s\n", err)
+		}
+		opts.TTL = d
+	}
+
+	interval := getenv("CACHE_VERSION_INTERVAL", "")
+	if interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid CACHE_VERSION_INTERVAL: %s\n", err)
+		}
+		opts.VersionInterval = d
+	}
+
+	return opts
+}
+
+func getCacheControls() cacheControls {
+	defaults := defaultCacheControls()
+	return cacheControls{
+		search:      getenv("CACHE_CONTROL_SEARCH", defaults.search),
+		suggestions: getenv("CACHE_CONTROL_SUGGESTIONS", defaults.suggestions),
+		anomalies:   getenv("CACHE_CONTROL_ANOMALIES", defaults.anomalies),
 	}
 }
 
@@ -51,6 +251,126 @@ func getJWTCredentials(filename string) auth.JWTCredentials {
 	return credentials
 }
 
+func getAnomalyOptions() service.AnomalyOptions {
+	opts := service.DefaultAnomalyOptions()
+	opts.Enabled = getenv("ANOMALY_DETECTION_ENABLED", "false") == "true"
+	opts.WindowDays = int(getIntEnv("ANOMALY_WINDOW_DAYS", int64(opts.WindowDays)))
+	opts.MinMentions = getIntEnv("ANOMALY_MIN_MENTIONS", opts.MinMentions)
+	opts.WebhookURLs = getListEnv("ANOMALY_WEBHOOK_URLS")
+
+	threshold := getenv("ANOMALY_THRESHOLD", "")
+	if threshold != "" {
+		t, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			log.Fatalf("Invalid ANOMALY_THRESHOLD: %s\n", err)
+		}
+		opts.Threshold = t
+	}
+
+	return opts
+}
+
+func getWebhookOptions() service.WebhookOptions {
+	opts := service.DefaultWebhookOptions()
+	opts.TopN = int(getIntEnv("WEBHOOK_TOP_N", int64(opts.TopN)))
+	opts.MaxAttempts = int(getIntEnv("WEBHOOK_MAX_ATTEMPTS", int64(opts.MaxAttempts)))
+	opts.Workers = int(getIntEnv("WEBHOOK_WORKERS", int64(opts.Workers)))
+	opts.QueueSize = int(getIntEnv("WEBHOOK_QUEUE_SIZE", int64(opts.QueueSize)))
+	opts.DeliveryLogSize = int(getIntEnv("WEBHOOK_DELIVERY_LOG_SIZE", int64(defaultDeliveryLimit)))
+
+	backoff := getenv("WEBHOOK_INITIAL_BACKOFF", "")
+	if backoff != "" {
+		d, err := time.ParseDuration(backoff)
+		if err != nil {
+			log.Fatalf("Invalid WEBHOOK_INITIAL_BACKOFF: %s\n", err)
+		}
+		opts.InitialBackoff = d
+	}
+
+	return opts
+}
+
+func getGraphQLLimits() graphqlapi.Limits {
+	limits := graphqlapi.DefaultLimits()
+	limits.MaxDepth = int(getIntEnv("GRAPHQL_MAX_DEPTH", int64(limits.MaxDepth)))
+	limits.MaxComplexity = int(getIntEnv("GRAPHQL_MAX_COMPLEXITY", int64(limits.MaxComplexity)))
+
+	return limits
+}
+
+func getRequestRules() requestRules {
+	rules := defaultRequestRules()
+	rules.SearchLimit = getLimitRule("SEARCH_LIMIT", rules.SearchLimit)
+	rules.SuggestionLimit = getLimitRule("SUGGESTION_LIMIT", rules.SuggestionLimit)
+	rules.anomalyLimit = getLimitRule("ANOMALY_LIMIT", rules.anomalyLimit)
+	rules.deliveryLimit = getLimitRule("DELIVERY_LIMIT", rules.deliveryLimit)
+
+	rules.Query.MaxLength = int(getIntEnv("QUERY_MAX_LENGTH", int64(rules.Query.MaxLength)))
+	rules.Query.Policy = getPolicyEnv("QUERY_POLICY", rules.Query.Policy)
+	rules.Symbols.MaxCount = int(getIntEnv("SYMBOLS_MAX_COUNT", int64(rules.Symbols.MaxCount)))
+	rules.Symbols.Policy = getPolicyEnv("SYMBOLS_POLICY", rules.Symbols.Policy)
+
+	return rules
+}
+
+// getLimitRule reads the <PREFIX>_DEFAULT, _MIN, _MAX and _POLICY
+// variables of a limit parameter.
+func getLimitRule(prefix string, rule validation.LimitRule) validation.LimitRule {
+	rule.Default = int(getIntEnv(prefix+"_DEFAULT", int64(rule.Default)))
+	rule.Min = int(getIntEnv(prefix+"_MIN", int64(rule.Min)))
+	rule.Max = int(getIntEnv(prefix+"_MAX", int64(rule.Max)))
+	rule.Policy = getPolicyEnv(prefix+"_POLICY", rule.Policy)
+	if rule.Min < 1 || rule.Min > rule.Max || rule.Default < rule.Min || rule.Default > rule.Max {
+		log.Fatalf("Invalid %s: default %d must be within %d and %d\n", prefix, rule.Default, rule.Min, rule.Max)
+	}
+
+	return rule
+}
+
+func getPolicyEnv(key string, defaultValue validation.Policy) validation.Policy {
+	policy, ok := validation.ParsePolicy(getenv(key, string(defaultValue)))
+	if !ok {
+		log.Fatalf("Invalid %s: %s\n", key, os.Getenv(key))
+	}
+
+	return policy
+}
+
+func getListEnv(key string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func getenv(key, defaultValue string) string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue
+	}
+
+	return val
+}
+
+func getIntEnv(key string, defaultValue int64) int64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue
+	}
+
+	intVal, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid value for key: %s. %s\n", key, err)
+	}
+
+	return intVal
+}
+
 func mustGetenv(key string) string {
 	val := os.Getenv(key)
 	if val == "" {
@@ -3,42 +3,70 @@ package main
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mimir-news/pkg/httputil"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/validation"
 )
 
 func (e *env) handleStockSearch(c *gin.Context) {
-	query, err := httputil.ParseQueryValue(c, "query")
+	result, limit, err := e.search(c, false)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	searchLimit, err := getIntParam(c, "limit", defaultSearchLimit)
+	c.JSON(http.StatusOK, result.Stocks(limit))
+}
+
+func (e *env) handleGroupedStockSearch(c *gin.Context) {
+	highlight, err := getBoolParam(c, "highlight", false)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	results, err := e.stockSvc.Search(query, searchLimit)
+	result, _, err := e.search(c, highlight)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	c.JSON(http.StatusOK, result)
+}
+
+func (e *env) search(c *gin.Context, highlight bool) (domain.SearchResult, int, error) {
+	query, err := e.rules.Query.Apply("query", c.Query("query"))
+	if err != nil {
+		return domain.SearchResult{}, 0, err
+	}
+
+	limit, err := getLimitParam(c, e.rules.SearchLimit)
+	if err != nil {
+		return domain.SearchResult{}, 0, err
+	}
+
+	result, err := e.stockSvc.Search(query, getLanguageParam(c), limit, highlight)
+	return result, limit, err
 }
 
 func (e *env) handleSuggestStocks(c *gin.Context) {
-	excluded := getSymbolsFromQuery(c, "exclude")
-	limit, err := getIntParam(c, "limit", defaultSuggestionLimit)
+	excluded, err := e.rules.Symbols.Apply("exclude", getSymbolsFromQuery(c, "exclude"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	limit, err := getLimitParam(c, e.rules.SuggestionLimit)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	results, err := e.stockSvc.GetSuggestions(excluded, limit)
+	language := getLanguageParam(c)
+	results, err := e.stockSvc.GetSuggestions(excluded, language, limit)
 	if err != nil {
 		c.Error(err)
 		return
@@ -47,25 +75,47 @@ func (e *env) handleSuggestStocks(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
+func (e *env) handleGetAnomalies(c *gin.Context) {
+	limit, err := getLimitParam(c, e.rules.anomalyLimit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	anomalies, err := e.anomalySvc.GetAnomalies(limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, anomalies)
+}
+
 func (e *env) handleStocksRanking(c *gin.Context) {
-	err := e.stockSvc.RankStocks()
+	report, err := e.stockSvc.RankStocks()
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	httputil.SendOK(c)
+	c.JSON(http.StatusOK, report)
 }
 
 func (e *env) handleStockRanking(c *gin.Context) {
 	symbol := c.Param("symbol")
-	err := e.stockSvc.RankStock(symbol)
+	err := e.rules.Symbols.Check("symbol", symbol)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	httputil.SendOK(c)
+	report, err := e.stockSvc.RankStock(symbol)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
 }
 
 func getIntParam(c *gin.Context, name string, defaultValue int) (int, error) {
@@ -76,17 +126,63 @@ func getIntParam(c *gin.Context, name string, defaultValue int) (int, error) {
 
 	intValue, err := strconv.Atoi(value)
 	if err != nil {
-		return 0, httputil.NewError("Invalid "+name, http.StatusBadRequest)
+		return 0, apierror.InvalidParameter(name, "must be an integer")
 	}
 
 	return intValue, nil
 }
 
+func getBoolParam(c *gin.Context, name string, defaultValue bool) (bool, error) {
+	value, ok := c.GetQuery(name)
+	if !ok {
+		return defaultValue, nil
+	}
+
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, apierror.InvalidParameter(name, "must be a boolean")
+	}
+
+	return boolValue, nil
+}
+
+// getLimitParam reads the limit parameter, clamping or rejecting
+// values outside of the range allowed by the rule.
+func getLimitParam(c *gin.Context, rule validation.LimitRule) (int, error) {
+	value, ok := c.GetQuery("limit")
+	if !ok {
+		return rule.Default, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, apierror.InvalidLimit("limit", "must be an integer")
+	}
+
+	return rule.Apply("limit", limit)
+}
+
+func getLanguageParam(c *gin.Context) string {
+	return strings.ToLower(strings.TrimSpace(c.Query("lang")))
+}
+
+// getSymbolsFromQuery reads symbols given either as repeated
+// query parameters or as a comma separated list.
 func getSymbolsFromQuery(c *gin.Context, name string) []string {
-	symbols, ok := c.GetQueryArray(name)
+	values, ok := c.GetQueryArray(name)
 	if !ok {
 		return []string{}
 	}
 
+	symbols := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, symbol := range strings.Split(value, ",") {
+			symbol = strings.TrimSpace(symbol)
+			if symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
 	return symbols
 }
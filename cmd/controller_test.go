@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -12,9 +15,12 @@ import (
 	"github.com/mimir-news/pkg/httputil/auth"
 	"github.com/mimir-news/pkg/id"
 	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/apierror"
 	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/graphqlapi"
 	"github.com/mimir-news/stock-search/pkg/repository"
 	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/validation"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,7 +48,7 @@ func TestHandleStockSearch(t *testing.T) {
 
 	assert.Equal(http.StatusOK, res.Code)
 	assert.Equal(query, stockRepo.SearchArgQuery)
-	assert.Equal(defaultSearchLimit, stockRepo.SearchArgLimit)
+	assert.Equal(defaultRequestRules().SearchLimit.Default, stockRepo.SearchArgLimit)
 	var searchResults []stock.Stock
 	err := json.NewDecoder(res.Body).Decode(&searchResults)
 	assert.NoError(err)
@@ -58,6 +64,15 @@ func TestHandleStockSearch(t *testing.T) {
 	assert.Equal(http.StatusOK, res.Code)
 	assert.Equal(query, stockRepo.SearchArgQuery)
 	assert.Equal(5, stockRepo.SearchArgLimit)
+	assert.Equal("", stockRepo.SearchArgLanguage)
+
+	stockRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks?lang=SV&query="+query)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(query, stockRepo.SearchArgQuery)
+	assert.Equal("sv", stockRepo.SearchArgLanguage)
 
 	stockRepo.UnsetArgs()
 	req = createTestGetRequest(token, "/v1/stocks?limit=5")
@@ -66,6 +81,20 @@ func TestHandleStockSearch(t *testing.T) {
 	assert.Equal(http.StatusBadRequest, res.Code)
 	assert.Equal("", stockRepo.SearchArgQuery)
 	assert.Equal(0, stockRepo.SearchArgLimit)
+	apiErr := decodeTestError(t, res)
+	assert.Equal(apierror.CodeMissingQuery, apiErr.Code)
+	assert.Equal("query", apiErr.Details[0].Field)
+	assert.Equal(res.Header().Get("X-Request-ID"), apiErr.RequestID)
+
+	stockRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks?limit=ten&query="+query)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, stockRepo.SearchInvocations)
+	apiErr = decodeTestError(t, res)
+	assert.Equal(apierror.CodeInvalidLimit, apiErr.Code)
+	assert.Equal("limit", apiErr.Details[0].Field)
 
 	stockRepo.UnsetArgs()
 	stockRepo.SearchErr = errors.New("mock error")
@@ -74,8 +103,98 @@ func TestHandleStockSearch(t *testing.T) {
 
 	assert.Equal(http.StatusInternalServerError, res.Code)
 	assert.Equal(query, stockRepo.SearchArgQuery)
-	assert.Equal(defaultSearchLimit, stockRepo.SearchArgLimit)
+	assert.Equal(defaultRequestRules().SearchLimit.Default, stockRepo.SearchArgLimit)
+	apiErr = decodeTestError(t, res)
+	assert.Equal(apierror.CodeInternalError, apiErr.Code)
+	assert.Equal("Internal error", apiErr.Message)
+
+	stockRepo.UnsetArgs()
+	stockRepo.SearchErr = sql.ErrConnDone
+	req = createTestGetRequest(token, "/v1/stocks?query="+query)
+	req.Header.Set("X-Request-ID", "test-request-id")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusServiceUnavailable, res.Code)
+	apiErr = decodeTestError(t, res)
+	assert.Equal(apierror.CodeDBUnavailable, apiErr.Code)
+	assert.Equal("test-request-id", apiErr.RequestID)
+	assert.Equal("test-request-id", res.Header().Get("X-Request-ID"))
+
+}
+
+func TestHandleGroupedStockSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		SearchStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+		},
+	}
+
+	conf := getTestConfig()
+	server := newServer(getTestEnv(stockRepo, nil), conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	req := createTestGetRequest(token, "/v1/stocks/search?query=%24AAPL+vs+apple+inc.")
+	res := performTestRequest(server.Handler, req)
 
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(2, stockRepo.SearchInvocations)
+	assert.Equal("apple", stockRepo.SearchArgQuery)
+	var result domain.SearchResult
+	err := json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(err)
+	assert.Equal("$AAPL vs apple inc.", result.Query.Raw)
+	assert.Equal([]string{"AAPL"}, result.Query.Cashtags)
+	assert.Equal(2, len(result.Groups))
+	assert.Equal("AAPL", result.Groups[0].Ticker)
+	assert.Equal([]string{"apple"}, result.Groups[1].Terms)
+	assert.Equal("AAPL", result.Groups[1].Stocks[0].Symbol)
+	assert.Nil(result.Groups[1].Hits)
+
+	req = createTestGetRequest(token, "/v1/stocks/search?highlight=true&query=%24AAPL+vs+apple+inc.")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	result = domain.SearchResult{}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(err)
+	assert.Equal(1, len(result.Groups[1].Hits))
+	assert.Equal(domain.MatchPrefix, result.Groups[1].Hits[0].Tier)
+	assert.Equal([]domain.Highlight{domain.Highlight{Field: domain.FieldName, Start: 0, End: 5}}, result.Groups[1].Hits[0].Highlights)
+
+	req = createTestGetRequest(token, "/v1/stocks/search?highlight=yes&query=apple")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+
+	stockRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks?query=%24AAPL+vs+apple+inc.")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	var stocks []stock.Stock
+	err = json.NewDecoder(res.Body).Decode(&stocks)
+	assert.NoError(err)
+	assert.Equal(1, len(stocks))
+
+	req = createTestGetRequest(token, "/v1/stocks/search")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(apierror.CodeMissingQuery, decodeTestError(t, res).Code)
+
+	stockRepo.SearchStocks = []domain.Stock{}
+	stockRepo.FindVocabularyTerms = []domain.Term{
+		domain.Term{Text: "aapl", Count: 100, IsSymbol: true},
+		domain.Term{Text: "apple", Count: 100},
+	}
+	req = createTestGetRequest(token, "/v1/stocks/search?query=appke")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	result = domain.SearchResult{}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(err)
+	assert.Equal(0, len(result.Groups[0].Stocks))
+	assert.Equal([]string{"apple"}, result.Suggestions)
 }
 
 func TestHandleSuggestStocks(t *testing.T) {
@@ -102,7 +221,8 @@ func TestHandleSuggestStocks(t *testing.T) {
 
 	assert.Equal(http.StatusOK, res.Code)
 	assert.Equal(1, stockRepo.FindMostCommonInvocations)
-	assert.Equal(defaultSuggestionLimit, stockRepo.FindMostCommonArgLimit)
+	assert.Equal([]string{"A", "B"}, stockRepo.FindMostCommonArgExcluded)
+	assert.Equal(defaultRequestRules().SuggestionLimit.Default, stockRepo.FindMostCommonArgLimit)
 	var suggestions []stock.Stock
 	err := json.NewDecoder(res.Body).Decode(&suggestions)
 	assert.NoError(err)
@@ -117,12 +237,21 @@ func TestHandleSuggestStocks(t *testing.T) {
 
 	assert.Equal(1, stockRepo.FindMostCommonInvocations)
 	assert.Equal(10, stockRepo.FindMostCommonArgLimit)
+	assert.Equal("", stockRepo.FindMostCommonArgLanguage)
 	err = json.NewDecoder(res.Body).Decode(&suggestions)
 	assert.NoError(err)
 	assert.Equal(len(expectedStocks), len(suggestions))
 	for i, s := range suggestions {
 		assert.Equal(expectedStocks[i].Symbol, s.Symbol)
 	}
+
+	stockRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?lang=sv")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, stockRepo.FindMostCommonInvocations)
+	assert.Equal("sv", stockRepo.FindMostCommonArgLanguage)
 }
 
 func TestHandleStockRanking(t *testing.T) {
@@ -130,7 +259,11 @@ func TestHandleStockRanking(t *testing.T) {
 
 	symbol := "AAPL"
 
-	coutedStock := domain.Stock{Symbol: symbol, Count: 10}
+	coutedStock := domain.Stock{
+		Symbol:         symbol,
+		Count:          10,
+		LanguageCounts: map[string]int64{"en": 7, "sv": 3},
+	}
 
 	stockRepo := &repository.MockStockRepo{}
 	countRepo := &repository.MockCountRepo{
@@ -149,6 +282,7 @@ func TestHandleStockRanking(t *testing.T) {
 	savedStock := stockRepo.SaveArg
 	assert.Equal(symbol, savedStock.Symbol)
 	assert.Equal(coutedStock.Count, savedStock.Count)
+	assert.Equal(int64(3), savedStock.LanguageCounts["sv"])
 
 	countRepo.CountOneErr = repository.ErrNoSuchStock
 	stockRepo.UnsetArgs()
@@ -157,6 +291,7 @@ func TestHandleStockRanking(t *testing.T) {
 
 	assert.Equal(http.StatusNotFound, res.Code)
 	assert.Equal("MISSING", countRepo.CountOneArg)
+	assert.Equal(apierror.CodeStockNotFound, decodeTestError(t, res).Code)
 	savedStock = stockRepo.SaveArg
 	assert.Equal("", savedStock.Symbol)
 	assert.Equal(int64(0), savedStock.Count)
@@ -168,6 +303,61 @@ func TestHandleStockRanking(t *testing.T) {
 	assert.Equal(http.StatusForbidden, res.Code)
 	assert.Equal(0, countRepo.CountOneInvocations)
 
+	req = createTestPutRequest(token, "/v1/stocks/AAPL;DROP")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, countRepo.CountOneInvocations)
+	apiErr := decodeTestError(t, res)
+	assert.Equal(apierror.CodeInvalidParameter, apiErr.Code)
+	assert.Equal("symbol", apiErr.Details[0].Field)
+}
+
+func TestSearchParamValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{}
+	conf := getTestConfig()
+	e := getTestEnv(stockRepo, nil)
+	e.rules.SearchLimit = validation.LimitRule{Default: 10, Min: 1, Max: 20, Policy: validation.Clamp}
+	e.rules.SuggestionLimit = validation.LimitRule{Default: 5, Min: 1, Max: 20, Policy: validation.Reject}
+	e.rules.Query.MaxLength = 10
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	req := createTestGetRequest(token, "/v1/stocks?query=A&limit=-1")
+	res := performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, stockRepo.SearchArgLimit)
+
+	req = createTestGetRequest(token, "/v1/stocks?query=A&limit=1000000")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(20, stockRepo.SearchArgLimit)
+
+	stockRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks?query=Telefonaktiebolaget")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, stockRepo.SearchInvocations)
+	apiErr := decodeTestError(t, res)
+	assert.Equal(apierror.CodeInvalidParameter, apiErr.Code)
+	assert.Equal("query", apiErr.Details[0].Field)
+
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?limit=21")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, stockRepo.FindMostCommonInvocations)
+	apiErr = decodeTestError(t, res)
+	assert.Equal(apierror.CodeInvalidLimit, apiErr.Code)
+	assert.Equal("must be between 1 and 20", apiErr.Details[0].Message)
+
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?exclude=AAPL,GOOG%27--")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, stockRepo.FindMostCommonInvocations)
+	apiErr = decodeTestError(t, res)
+	assert.Equal(apierror.CodeInvalidParameter, apiErr.Code)
+	assert.Equal("exclude", apiErr.Details[0].Field)
 }
 
 func TestHandleStocksRanking(t *testing.T) {
@@ -181,6 +371,10 @@ func TestHandleStocksRanking(t *testing.T) {
 	stockRepo := &repository.MockStockRepo{}
 	countRepo := &repository.MockCountRepo{
 		CountAllStocks: coutedStocks,
+		CountAllReport: domain.FilterReport{
+			CountedMentions: 30,
+			RemovedMentions: map[string]int64{repository.RuleBlockedAuthor: 2},
+		},
 	}
 
 	conf := getTestConfig()
@@ -196,6 +390,12 @@ func TestHandleStocksRanking(t *testing.T) {
 	assert.Equal(len(coutedStocks), stockRepo.SaveInvocations)
 	assert.Equal("GOOG", savedStock.Symbol)
 	assert.Equal(int64(20), savedStock.Count)
+	var report domain.RankingReport
+	err := json.NewDecoder(res.Body).Decode(&report)
+	assert.NoError(err)
+	assert.Equal(len(coutedStocks), report.RankedStocks)
+	assert.Equal(int64(30), report.Filter.CountedMentions)
+	assert.Equal(int64(2), report.Filter.RemovedMentions[repository.RuleBlockedAuthor])
 
 	countRepo.UnsetArgs()
 	wrongToken := getTestToken(conf, id.New(), auth.UserRole)
@@ -206,6 +406,57 @@ func TestHandleStocksRanking(t *testing.T) {
 
 }
 
+func TestHandleGetAnomalies(t *testing.T) {
+	assert := assert.New(t)
+
+	anomalyRepo := &repository.MockAnomalyRepo{
+		FindRecentAnomalies: []domain.Anomaly{
+			domain.Anomaly{Symbol: "AAPL", Count: 100, Mean: 10, StdDev: 2, Deviation: 45},
+		},
+	}
+
+	conf := getTestConfig()
+	e := getTestEnv(&repository.MockStockRepo{}, nil)
+	e.anomalySvc = service.NewAnomalyService(anomalyRepo, nil, nil, service.DefaultAnomalyOptions())
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	req := createTestGetRequest(token, "/v1/stocks/anomalies")
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(defaultAnomalyLimit, anomalyRepo.FindRecentArgLimit)
+	var anomalies []domain.Anomaly
+	err := json.NewDecoder(res.Body).Decode(&anomalies)
+	assert.NoError(err)
+	assert.Equal(1, len(anomalies))
+	assert.Equal("AAPL", anomalies[0].Symbol)
+
+	anomalyRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks/anomalies?limit=3")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(3, anomalyRepo.FindRecentArgLimit)
+
+	anomalyRepo.UnsetArgs()
+	req = createTestGetRequest(token, "/v1/stocks/anomalies?limit=many")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, anomalyRepo.FindRecentInvocations)
+}
+
+func decodeTestError(t *testing.T, res *httptest.ResponseRecorder) apierror.Error {
+	var apiErr apierror.Error
+	err := json.NewDecoder(res.Body).Decode(&apiErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return apiErr
+}
+
 func performTestRequest(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -213,8 +464,13 @@ func performTestRequest(r http.Handler, req *http.Request) *httptest.ResponseRec
 }
 
 func getTestEnv(stockRepo repository.StockRepo, countRepo repository.CountRepo) *env {
+	stockSvc := service.NewStockService(stockRepo, countRepo, repository.NewMemoryRankingLock())
+	rules := defaultRequestRules()
 	return &env{
-		stockSvc: service.NewStockService(stockRepo, countRepo),
+		stockSvc: stockSvc,
+		versions: service.NewVersionTracker(stockRepo, &repository.MockVersionRepo{}, 0),
+		graphql:  newGraphQLExecutor(stockSvc, rules.Rules, graphqlapi.DefaultLimits()),
+		rules:    rules,
 	}
 }
 
@@ -255,7 +511,20 @@ func createTestGetRequest(token, route string) *http.Request {
 }
 
 func createTestRequest(token, route, method string) *http.Request {
-	req, err := http.NewRequest(method, route, nil)
+	return createTestRequestWithBody(token, route, method, nil)
+}
+
+func createTestRequestWithBody(token, route, method string, body interface{}) *http.Request {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, route, reqBody)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -3,32 +3,231 @@ package main
 import (
 	"database/sql"
 	"log"
+	"os"
 
+	"github.com/mimir-news/stock-search/pkg/graphqlapi"
 	"github.com/mimir-news/stock-search/pkg/repository"
 	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/stream"
+	"github.com/mimir-news/stock-search/pkg/validation"
+	"github.com/mimir-news/stock-search/pkg/webhook"
 )
 
 type env struct {
-	db       *sql.DB
-	stockSvc service.StockService
+	db           *sql.DB
+	stockSvc     service.StockService
+	blocklistSvc service.BlocklistService
+	synonymSvc   service.SynonymService
+	stockCache   service.CachedStockService
+	anomalySvc   service.AnomalyService
+	webhookSvc   service.WebhookService
+	versions     *service.VersionTracker
+	broker       *stream.Broker
+	relay        stream.Relay
+	graphql      graphqlapi.Executor
+	rules        requestRules
+}
+
+// repositories the repositories of the configured storage backend.
+type repositories struct {
+	stock     repository.StockRepo
+	count     repository.CountRepo
+	blocklist repository.BlocklistRepo
+	synonym   repository.SynonymRepo
+	anomaly   repository.AnomalyRepo
+	webhook   repository.WebhookRepo
+	version   repository.VersionRepo
+
+	rankingLock repository.RankingLock
 }
 
 func setupEnv(cfg config) *env {
-	db, err := cfg.db.ConnectPostgres()
+	db, repos := setupRepositories(cfg)
+	sender := webhook.NewSender(webhookTimeout)
+	anomalySvc := service.NewAnomalyService(repos.anomaly, repos.count, sender, cfg.anomalyOptions)
+	webhookSvc := service.NewWebhookService(repos.webhook, repos.stock, sender, cfg.webhookOptions)
+	broker := stream.NewBroker(repos.stock, stream.DefaultOptions())
+	relay := setupStreamRelay(db, cfg)
+	if relay != nil {
+		broker.Listen(relay)
+	}
+	tracker := service.NewVersionTracker(repos.stock, repos.version, cfg.cacheOptions.VersionInterval)
+	listeners := []service.RankingListener{tracker, anomalySvc, webhookSvc, broker}
+	var resultCache *service.StockCache
+	if cfg.cacheOptions.Enabled {
+		resultCache = service.NewStockCache(cfg.cacheOptions)
+		listeners = append([]service.RankingListener{resultCache}, listeners...)
+	}
+
+	stockSvc := service.NewStockService(repos.stock, repos.count, repos.rankingLock, listeners...)
+	var stockCache service.CachedStockService
+	invalidators := []service.CacheInvalidator{tracker}
+	if resultCache != nil {
+		stockCache = service.NewCachedStockService(stockSvc, resultCache, tracker)
+		stockSvc = stockCache
+		invalidators = append(invalidators, stockCache)
+	}
+
+	return &env{
+		db:           db,
+		stockSvc:     stockSvc,
+		blocklistSvc: service.NewBlocklistService(repos.blocklist),
+		synonymSvc:   service.NewSynonymService(repos.synonym, invalidators...),
+		stockCache:   stockCache,
+		anomalySvc:   anomalySvc,
+		webhookSvc:   webhookSvc,
+		versions:     tracker,
+		broker:       broker,
+		relay:        relay,
+		graphql:      newGraphQLExecutor(stockSvc, cfg.rules.Rules, cfg.graphqlLimits),
+		rules:        cfg.rules,
+	}
+}
+
+// setupStreamRelay connects the relay of ranking events between replicas, or returns nil if not configured.
+func setupStreamRelay(db *sql.DB, cfg config) stream.Relay {
+	if cfg.streamRelayDSN == "" {
+		return nil
+	}
+
+	relay, err := stream.NewPgRelay(db, cfg.streamRelayDSN)
+	if err != nil {
+		log.Fatalf("Failed to listen for relayed rankings: %s\n", err)
+	}
+
+	return relay
+}
+
+// setupRepositories creates the repositories of the configured backend, applying pending
+// migrations if enabled. The database is nil if the memory backend is used.
+func setupRepositories(cfg config) (*sql.DB, repositories) {
+	if cfg.backend == backendMemory {
+		return nil, newMemoryRepositories(cfg)
+	}
+
+	db, migrator := openDatabase(cfg)
+	if cfg.migrateOnStartup {
+		migrateUp(migrator)
+	}
+
+	if cfg.backend == backendSQLite {
+		return db, newSQLiteRepositories(db, cfg)
+	}
+
+	stockRepo := repository.NewStockRepo(db, cfg.stockOptions)
+	refreshSearchIndex(stockRepo)
+
+	return db, repositories{
+		stock:     stockRepo,
+		count:     repository.NewCountRepo(db, cfg.countOptions),
+		blocklist: repository.NewBlocklistRepo(db),
+		synonym:   repository.NewSynonymRepo(db),
+		anomaly:   repository.NewAnomalyRepo(db),
+		webhook:   repository.NewWebhookRepo(db),
+		version:   repository.NewVersionRepo(db),
+
+		rankingLock: repository.NewRankingLock(db),
+	}
+}
+
+// newSQLiteRepositories creates repositories storing data in a SQLite file. Blocked
+// authors, anomalies, webhooks and data versions are stored with the statements of the postgres repositories.
+// A SQLite file is served by a single process, so rankings are serialized within the process.
+func newSQLiteRepositories(db *sql.DB, cfg config) repositories {
+	stockRepo := repository.NewSQLiteStockRepo(db, cfg.stockOptions)
+	refreshSearchIndex(stockRepo)
+
+	return repositories{
+		stock:     stockRepo,
+		count:     repository.NewSQLiteCountRepo(db, cfg.countOptions),
+		blocklist: repository.NewBlocklistRepo(db),
+		synonym:   repository.NewSQLiteSynonymRepo(db),
+		anomaly:   repository.NewAnomalyRepo(db),
+		webhook:   repository.NewWebhookRepo(db),
+		version:   repository.NewVersionRepo(db),
+
+		rankingLock: repository.NewMemoryRankingLock(),
+	}
+}
+
+// newMemoryRepositories creates repositories sharing an in-memory store,
+// seeded with the stocks and tweets of the seed file if one is configured.
+func newMemoryRepositories(cfg config) repositories {
+	store := repository.NewMemoryStore()
+	if cfg.memorySeedFile != "" {
+		loadMemorySeed(store, cfg.memorySeedFile)
+	}
+
+	return repositories{
+		stock:     repository.NewMemoryStockRepo(store, cfg.stockOptions),
+		count:     repository.NewMemoryCountRepo(store, cfg.countOptions),
+		blocklist: repository.NewMemoryBlocklistRepo(store),
+		synonym:   repository.NewMemorySynonymRepo(store),
+		anomaly:   repository.NewMemoryAnomalyRepo(store),
+		webhook:   repository.NewMemoryWebhookRepo(store),
+		version:   repository.NewMemoryVersionRepo(store),
+
+		rankingLock: repository.NewMemoryRankingLock(),
+	}
+}
+
+func loadMemorySeed(store *repository.MemoryStore, filename string) {
+	f, err := os.Open(filename)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer f.Close()
 
-	stockRepo := repository.NewStockRepo(db)
-	countRepo := repository.NewCountRepo(db)
+	err = store.Load(f)
+	if err != nil {
+		log.Fatalf("Invalid MEMORY_SEED_FILE: %s\n", err)
+	}
+}
 
-	return &env{
-		db:       db,
-		stockSvc: service.NewStockService(stockRepo, countRepo),
+// refreshSearchIndex indexes the names of stocks stored by other
+// services or before the search index was introduced.
+func refreshSearchIndex(stockRepo repository.StockRepo) {
+	indexed, err := stockRepo.RefreshSearchIndex()
+	if err != nil {
+		log.Println("Failed to refresh stock search index:", err)
+		return
 	}
+
+	if indexed > 0 {
+		log.Printf("Indexed names of %d stocks\n", indexed)
+	}
+}
+
+func newGraphQLExecutor(stockSvc service.StockService, rules validation.Rules, limits graphqlapi.Limits) graphqlapi.Executor {
+	schema, err := graphqlapi.NewSchema(stockSvc, rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return graphqlapi.NewExecutor(schema, limits)
 }
 
+// close waits for background work started by rankings before closing the database.
 func (e *env) close() {
+	if !e.anomalySvc.Wait(shutdownTimeout) {
+		log.Println("Timed out waiting for anomaly detection to finish")
+	}
+
+	if !e.webhookSvc.Wait(shutdownTimeout) {
+		log.Println("Timed out waiting for webhook deliveries to finish")
+	}
+
+	if e.relay != nil {
+		err := e.relay.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if e.db == nil {
+		return
+	}
+
 	err := e.db.Close()
 	if err != nil {
 		log.Println(err)
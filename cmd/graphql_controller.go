@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/graphqlapi"
+)
+
+var graphqlAdminFilter = auth.AllowRoles(auth.AdminRole)
+
+func (e *env) handleGraphQL(c *gin.Context) {
+	var req graphqlapi.Request
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(apierror.InvalidBody("must be a JSON object"))
+		return
+	}
+
+	query, errs := e.graphql.Parse(req)
+	if errs != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+		return
+	}
+
+	// Mutations rank stocks and are restricted to admins like their REST counterparts.
+	// The admin filter is the last handler in the chain so it aborts or falls through.
+	if query.IsMutation() {
+		graphqlAdminFilter(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, e.graphql.Execute(c.Request.Context(), query))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/graphqlapi"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type testGraphQLResponse struct {
+	Data   map[string]interface{}   `json:"data"`
+	Errors []map[string]interface{} `json:"errors"`
+}
+
+func TestHandleGraphQL(t *testing.T) {
+	assert := assert.New(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	stockRepo := &repository.MockStockRepo{
+		FindStock: domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+		SearchStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+		},
+		FindMostCommonStocks: []domain.Stock{
+			domain.Stock{Symbol: "TSLA", Name: "Tesla Inc."},
+			domain.Stock{Symbol: "AMD", Name: "Advanced Micro Devices"},
+		},
+	}
+	countRepo := &repository.MockCountRepo{
+		CountStockDailyCounts: []domain.DailyCount{
+			domain.DailyCount{Symbol: "AAPL", Day: today, Count: 4},
+		},
+	}
+
+	conf := getTestConfig()
+	e := getTestEnv(stockRepo, countRepo)
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	query := `query Overview($limit: Int) {
+		stock(symbol: "AAPL") { symbol name history(days: 3) { day count } }
+		search(query: "app", lang: "SV") { symbol }
+		suggestions(exclude: ["AAPL"], limit: $limit) { symbol }
+	}`
+	body := graphqlapi.Request{Query: query, Variables: map[string]interface{}{"limit": 2}}
+	res := performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusOK, res.Code)
+
+	var gqlRes testGraphQLResponse
+	err := json.NewDecoder(res.Body).Decode(&gqlRes)
+	assert.NoError(err)
+	assert.Nil(gqlRes.Errors)
+
+	s := gqlRes.Data["stock"].(map[string]interface{})
+	assert.Equal("AAPL", s["symbol"])
+	assert.Equal("Apple Inc.", s["name"])
+	history := s["history"].([]interface{})
+	assert.Equal(3, len(history))
+	assert.Equal(today.AddDate(0, 0, -2).Format("2006-01-02"), history[0].(map[string]interface{})["day"])
+	assert.Equal(float64(0), history[0].(map[string]interface{})["count"])
+	assert.Equal(float64(4), history[2].(map[string]interface{})["count"])
+	assert.Equal("AAPL", countRepo.CountStockDailyArgSymbol)
+	assert.Equal(today.AddDate(0, 0, -2), countRepo.CountStockDailyArgSince)
+
+	assert.Equal(1, len(gqlRes.Data["search"].([]interface{})))
+	assert.Equal("app", stockRepo.SearchArgQuery)
+	assert.Equal("sv", stockRepo.SearchArgLanguage)
+	assert.Equal(graphqlapi.DefaultSearchLimit, stockRepo.SearchArgLimit)
+
+	assert.Equal(2, len(gqlRes.Data["suggestions"].([]interface{})))
+	assert.Equal([]string{"AAPL"}, stockRepo.FindMostCommonArgExcluded)
+	assert.Equal(2, stockRepo.FindMostCommonArgLimit)
+
+	body = graphqlapi.Request{Query: `{ searchGroups(query: "$AAPL apple") { ticker terms stocks { symbol } } }`}
+	res = performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusOK, res.Code)
+	gqlRes = testGraphQLResponse{}
+	err = json.NewDecoder(res.Body).Decode(&gqlRes)
+	assert.NoError(err)
+	assert.Nil(gqlRes.Errors)
+	groups := gqlRes.Data["searchGroups"].([]interface{})
+	assert.Equal(2, len(groups))
+	assert.Equal("AAPL", groups[0].(map[string]interface{})["ticker"])
+	assert.Nil(groups[1].(map[string]interface{})["ticker"])
+	assert.Equal([]interface{}{"apple"}, groups[1].(map[string]interface{})["terms"])
+	assert.Equal(1, len(groups[1].(map[string]interface{})["stocks"].([]interface{})))
+
+	stockRepo.FindErr = repository.ErrNoSuchStock
+	body = graphqlapi.Request{Query: `{ stock(symbol: "MISSING") { symbol } }`}
+	res = performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusOK, res.Code)
+	gqlRes = testGraphQLResponse{}
+	err = json.NewDecoder(res.Body).Decode(&gqlRes)
+	assert.NoError(err)
+	assert.Nil(gqlRes.Errors)
+	assert.Nil(gqlRes.Data["stock"])
+
+	body = graphqlapi.Request{Query: `{ stock(symbol: "AAPL") { price } }`}
+	res = performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusBadRequest, res.Code)
+
+	res = performTestRequest(server.Handler, createTestRequestWithBody("", "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func TestHandleGraphQLMutations(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{}
+	countRepo := &repository.MockCountRepo{
+		CountOneStock: domain.Stock{Symbol: "AAPL", Count: 10},
+		CountOneReport: domain.FilterReport{
+			CountedMentions: 10,
+			RemovedMentions: map[string]int64{repository.RuleBlockedAuthor: 2},
+		},
+	}
+
+	conf := getTestConfig()
+	server := newServer(getTestEnv(stockRepo, countRepo), conf)
+	body := graphqlapi.Request{
+		Query: `mutation { rankStock(symbol: "AAPL") { rankedStocks filter { countedMentions removedMentions { rule count } } } }`,
+	}
+
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+	res := performTestRequest(server.Handler, createTestRequestWithBody(userToken, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusForbidden, res.Code)
+	assert.Equal(0, countRepo.CountOneInvocations)
+
+	adminToken := getTestToken(conf, id.New(), auth.AdminRole)
+	res = performTestRequest(server.Handler, createTestRequestWithBody(adminToken, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, countRepo.CountOneInvocations)
+	assert.Equal("AAPL", countRepo.CountOneArg)
+
+	var gqlRes testGraphQLResponse
+	err := json.NewDecoder(res.Body).Decode(&gqlRes)
+	assert.NoError(err)
+	assert.Nil(gqlRes.Errors)
+	report := gqlRes.Data["rankStock"].(map[string]interface{})
+	assert.Equal(float64(1), report["rankedStocks"])
+	filter := report["filter"].(map[string]interface{})
+	assert.Equal(float64(10), filter["countedMentions"])
+	removed := filter["removedMentions"].([]interface{})
+	assert.Equal(1, len(removed))
+	assert.Equal(repository.RuleBlockedAuthor, removed[0].(map[string]interface{})["rule"])
+}
+
+func TestHandleGraphQLLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{}
+	conf := getTestConfig()
+	e := getTestEnv(stockRepo, &repository.MockCountRepo{})
+	e.graphql = newGraphQLExecutor(e.stockSvc, e.rules.Rules, graphqlapi.Limits{MaxDepth: 2, MaxComplexity: 50})
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	body := graphqlapi.Request{Query: `{ stock(symbol: "AAPL") { history { day } } }`}
+	res := performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, stockRepo.FindInvocations)
+
+	body = graphqlapi.Request{Query: `{ search(query: "a", limit: 100) { symbol name } }`}
+	res = performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, stockRepo.SearchInvocations)
+
+	body = graphqlapi.Request{Query: `{ search(query: "a", limit: 10) { symbol name } }`}
+	res = performTestRequest(server.Handler, createTestRequestWithBody(token, "/graphql", http.MethodPost, body))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, stockRepo.SearchInvocations)
+}
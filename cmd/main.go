@@ -1,26 +1,64 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/mimir-news/pkg/dbutil"
 	"github.com/mimir-news/pkg/httputil"
 	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/stock-search/pkg/grpcapi"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(getStorageConfig(), os.Args[2:])
+		return
+	}
+
 	conf := getConfig()
 	e := setupEnv(conf)
 	defer e.close()
 	server := newServer(e, conf)
+	grpcServer := newGRPCServer(e, conf)
+	go serveGRPC(grpcServer, conf)
+	stopped := make(chan struct{})
+	go shutdownOnSignal(server, grpcServer, stopped)
 
 	log.Printf("Starting %s on port: %s\n", ServiceName, conf.port)
 	err := server.ListenAndServe()
-	if err != nil {
+	if err != http.ErrServerClosed {
 		log.Println(err)
+		return
+	}
+	<-stopped
+}
+
+// shutdownOnSignal stops the servers gracefully on SIGINT or SIGTERM, closing stopped once
+// ongoing requests have finished. Background work such as webhook deliveries is then
+// awaited when the environment is closed.
+func shutdownOnSignal(server *http.Server, grpcServer *grpc.Server, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Printf("Received %s, shutting down\n", sig)
+
+	grpcServer.GracefulStop()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if err != nil {
+		log.Println("Failed to shut down server:", err)
 	}
 }
 
@@ -28,10 +66,27 @@ func newServer(e *env, conf config) *http.Server {
 	r := newRouter(e, conf)
 
 	adminFilter := auth.AllowRoles(auth.AdminRole)
-	r.GET("/v1/stocks", e.handleStockSearch)
-	r.GET("/v1/stocks/suggestions", e.handleSuggestStocks)
+	r.GET("/v1/stocks", e.conditionalGet(conf.cacheControls.search), e.handleStockSearch)
+	r.GET("/v1/stocks/search", e.conditionalGet(conf.cacheControls.search), e.handleGroupedStockSearch)
+	r.GET("/v1/stocks/suggestions", e.conditionalGet(conf.cacheControls.suggestions), e.handleSuggestStocks)
+	r.GET("/v1/stocks/anomalies", e.conditionalGet(conf.cacheControls.anomalies), e.handleGetAnomalies)
+	r.GET("/v1/stocks/stream", e.handleStockStream)
+	r.GET("/v1/stocks/typeahead", e.handleTypeahead)
 	r.PUT("/v1/stocks", adminFilter, e.handleStocksRanking)
 	r.PUT("/v1/stocks/:symbol", adminFilter, e.handleStockRanking)
+	r.GET("/v1/authors/blocklist", adminFilter, e.handleGetBlockedAuthors)
+	r.PUT("/v1/authors/blocklist/:authorId", adminFilter, e.handleBlockAuthor)
+	r.DELETE("/v1/authors/blocklist/:authorId", adminFilter, e.handleUnblockAuthor)
+	r.GET("/v1/synonyms", adminFilter, e.handleGetSynonyms)
+	r.POST("/v1/synonyms/import", adminFilter, e.handleImportSynonyms)
+	r.PUT("/v1/synonyms/:term", adminFilter, e.handleSaveSynonym)
+	r.DELETE("/v1/synonyms/:term", adminFilter, e.handleDeleteSynonym)
+	r.GET("/v1/cache/stats", adminFilter, e.handleGetCacheStats)
+	r.GET("/v1/webhooks", adminFilter, e.handleGetSubscriptions)
+	r.POST("/v1/webhooks", adminFilter, e.handleSubscribe)
+	r.DELETE("/v1/webhooks/:id", adminFilter, e.handleUnsubscribe)
+	r.GET("/v1/webhooks/:id/deliveries", adminFilter, e.handleGetDeliveries)
+	r.POST("/graphql", e.handleGraphQL)
 
 	return &http.Server{
 		Addr:    ":" + conf.port,
@@ -39,14 +94,40 @@ func newServer(e *env, conf config) *http.Server {
 	}
 }
 
+func newGRPCServer(e *env, conf config) *grpc.Server {
+	authOpts := auth.NewOptions(conf.JWTCredentials)
+	s := grpc.NewServer(grpc.UnaryInterceptor(grpcapi.NewAuthInterceptor(authOpts, grpcapi.AdminMethods...)))
+	grpcapi.RegisterStockSearchServer(s, grpcapi.NewServer(e.stockSvc, e.rules.Rules))
+
+	return s
+}
+
+func serveGRPC(s *grpc.Server, conf config) {
+	lis, err := net.Listen("tcp", ":"+conf.grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port %s: %s\n", conf.grpcPort, err)
+	}
+
+	log.Printf("Starting gRPC server for %s on port: %s\n", ServiceName, conf.grpcPort)
+	err = s.Serve(lis)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
 func newRouter(e *env, cfg config) *gin.Engine {
 	authOpts := auth.NewOptions(cfg.JWTCredentials, unsecuredRoutes...)
 	r := httputil.NewRouter(ServiceName, ServiceVersion, e.healthCheck)
+	r.Use(requestID(), handleErrors())
 	r.Use(auth.RequireToken(authOpts))
 
 	return r
 }
 
 func (e *env) healthCheck() error {
+	if e.db == nil {
+		return nil
+	}
+
 	return dbutil.IsConnected(e.db)
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/service"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestId"
+	maxRequestIDLen = 64
+)
+
+// requestID assigns an id to each request, reusing the id set by upstream proxies if present.
+// The id is returned in the X-Request-ID header and in error bodies.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqID := strings.TrimSpace(c.GetHeader(requestIDHeader))
+		if reqID == "" || len(reqID) > maxRequestIDLen {
+			reqID = id.New()
+		}
+
+		c.Set(requestIDKey, reqID)
+		c.Header(requestIDHeader, reqID)
+		c.Next()
+	}
+}
+
+// handleErrors sends the last error added to the context as an apierror.Error body.
+// Unknown errors are logged and reported without exposing their message.
+func handleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+
+		apiErr := *apierror.Wrap(last.Err)
+		apiErr.RequestID = c.GetString(requestIDKey)
+		if apiErr.Status >= 500 {
+			log.Printf("Request %s failed: %s\n", apiErr.RequestID, last.Err)
+		}
+
+		c.Errors = c.Errors[:0]
+		c.AbortWithStatusJSON(apiErr.Status, apiErr)
+	}
+}
+
+// conditionalGet adds validators derived from the tracked data versions and the request
+// parameters to responses, answering 304 Not Modified if the client already has the
+// current response. The Cache-Control header is set if a cache control is given.
+// Responses which fail are sent without validators.
+func (e *env) conditionalGet(cacheControl string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := e.versions.Versions()
+		if err != nil {
+			log.Println("Failed to get data version:", err)
+			c.Next()
+			return
+		}
+
+		lastModified := version.LastModified().UTC().Truncate(time.Second)
+		etag := computeETag(version, c.Request)
+		header := c.Writer.Header()
+		header.Set("ETag", etag)
+		if !lastModified.IsZero() {
+			header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+		if cacheControl != "" {
+			header.Set("Cache-Control", cacheControl)
+		}
+
+		if isNotModified(c.Request, etag, lastModified) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		c.Next()
+		if len(c.Errors) > 0 {
+			header.Del("ETag")
+			header.Del("Last-Modified")
+			header.Del("Cache-Control")
+		}
+	}
+}
+
+// computeETag derives a weak entity tag from the data version,
+// the path and the query parameters in a canonical order.
+func computeETag(version service.DataVersions, req *http.Request) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%d\n%d\n%d\n%s\n%s", version.LastRanking.UnixNano(), version.Synonyms.Version,
+		version.Anomalies.Version, req.URL.Path, req.URL.Query().Encode())
+	return fmt.Sprintf(`W/"%x"`, h.Sum(nil)[:12])
+}
+
+// isNotModified checks the request preconditions, where If-Modified-Since
+// is only considered if the request does not contain If-None-Match.
+func isNotModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+
+	return !lastModified.After(since)
+}
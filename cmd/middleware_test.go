@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalGet(t *testing.T) {
+	assert := assert.New(t)
+
+	lastUpdated := time.Date(2018, 6, 1, 12, 30, 15, 500, time.UTC)
+	stockRepo := &repository.MockStockRepo{
+		LastUpdatedTime:      lastUpdated,
+		FindMostCommonStocks: []domain.Stock{domain.Stock{Symbol: "TSLA"}},
+	}
+	conf := getTestConfig()
+	conf.cacheControls = defaultCacheControls()
+	e := getTestEnv(stockRepo, nil)
+	versionRepo := &repository.MockVersionRepo{}
+	e.versions = service.NewVersionTracker(stockRepo, versionRepo, 0)
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	res := performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks/suggestions?limit=3&lang=sv"))
+	assert.Equal(http.StatusOK, res.Code)
+	etag := res.Header().Get("ETag")
+	assert.NotEqual("", etag)
+	assert.Equal("Fri, 01 Jun 2018 12:30:15 GMT", res.Header().Get("Last-Modified"))
+	assert.Equal("private, max-age=300", res.Header().Get("Cache-Control"))
+	assert.Equal(1, stockRepo.FindMostCommonInvocations)
+
+	req := createTestGetRequest(token, "/v1/stocks/suggestions?lang=sv&limit=3")
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusNotModified, res.Code)
+	assert.Equal(etag, res.Header().Get("ETag"))
+	assert.Equal(1, stockRepo.FindMostCommonInvocations)
+
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?lang=sv&limit=4")
+	req.Header.Set("If-None-Match", etag)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.NotEqual(etag, res.Header().Get("ETag"))
+
+	req = createTestGetRequest(token, "/v1/stocks/suggestions")
+	req.Header.Set("If-Modified-Since", "Fri, 01 Jun 2018 12:30:15 GMT")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusNotModified, res.Code)
+
+	stockRepo.LastUpdatedTime = lastUpdated.Add(time.Minute)
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?lang=sv&limit=3")
+	req.Header.Set("If-None-Match", etag)
+	req.Header.Set("If-Modified-Since", "Fri, 01 Jun 2018 12:30:15 GMT")
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("Fri, 01 Jun 2018 12:31:15 GMT", res.Header().Get("Last-Modified"))
+	etag = res.Header().Get("ETag")
+
+	versionRepo.FindVersionsResult = map[string]domain.DataVersion{
+		repository.SynonymVersion: domain.DataVersion{Version: 1, UpdatedAt: lastUpdated.Add(2 * time.Minute)},
+	}
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?lang=sv&limit=3")
+	req.Header.Set("If-None-Match", etag)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.NotEqual(etag, res.Header().Get("ETag"))
+	assert.Equal("Fri, 01 Jun 2018 12:32:15 GMT", res.Header().Get("Last-Modified"))
+	etag = res.Header().Get("ETag")
+
+	versionRepo.FindVersionsResult[repository.AnomalyVersion] = domain.DataVersion{Version: 1, UpdatedAt: lastUpdated}
+	req = createTestGetRequest(token, "/v1/stocks/suggestions?lang=sv&limit=3")
+	req.Header.Set("If-None-Match", etag)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.NotEqual(etag, res.Header().Get("ETag"))
+	assert.Equal("Fri, 01 Jun 2018 12:32:15 GMT", res.Header().Get("Last-Modified"))
+
+	res = performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks"))
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal("", res.Header().Get("ETag"))
+	assert.Equal("", res.Header().Get("Cache-Control"))
+
+	versionRepo.FindVersionsErr = errors.New("db error")
+	res = performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks/suggestions"))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("", res.Header().Get("ETag"))
+
+	stockRepo.LastUpdatedErr = errors.New("db error")
+	res = performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks/suggestions"))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("", res.Header().Get("ETag"))
+}
+
+func TestConditionalGetReusesTrackedVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		LastUpdatedTime:      time.Date(2018, 6, 1, 12, 30, 15, 500, time.UTC),
+		FindMostCommonStocks: []domain.Stock{domain.Stock{Symbol: "TSLA"}},
+	}
+	versionRepo := &repository.MockVersionRepo{}
+	conf := getTestConfig()
+	e := getTestEnv(stockRepo, nil)
+	e.versions = service.NewVersionTracker(stockRepo, versionRepo, time.Hour)
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	res := performTestRequest(server.Handler, createTestGetRequest(token, "/v1/stocks/suggestions"))
+	etag := res.Header().Get("ETag")
+	req := createTestGetRequest(token, "/v1/stocks/suggestions")
+	req.Header.Set("If-None-Match", etag)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusNotModified, res.Code)
+	assert.Equal(1, stockRepo.LastUpdatedInvocations)
+	assert.Equal(1, versionRepo.FindVersionsInvocations)
+}
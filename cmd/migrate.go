@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/migration"
+	"github.com/mimir-news/stock-search/pkg/repository"
+)
+
+const migrateUsage = "Usage: stocksearch migrate up | down [steps] | status"
+
+// runMigrate runs the migrate subcommand, which applies pending migrations,
+// reverts the latest applied migrations or prints the state of all migrations.
+func runMigrate(cfg config, args []string) {
+	if cfg.backend == backendMemory {
+		log.Fatalf("The %s backend has no schema to migrate\n", backendMemory)
+	}
+	if len(args) == 0 {
+		log.Fatalln(migrateUsage)
+	}
+
+	db, migrator := openDatabase(cfg)
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		migrateUp(migrator)
+	case "down":
+		migrateDown(migrator, getSteps(args[1:]))
+	case "status":
+		printMigrationStatus(migrator)
+	default:
+		log.Fatalln(migrateUsage)
+	}
+}
+
+// openDatabase opens the database of the configured backend along with its migrator.
+func openDatabase(cfg config) (*sql.DB, migration.Migrator) {
+	if cfg.backend == backendSQLite {
+		db, err := repository.OpenSQLite(cfg.dbFile)
+		if err != nil {
+			log.Fatalf("Failed to open DB_FILE %s: %s\n", cfg.dbFile, err)
+		}
+
+		return db, migration.NewSQLiteMigrator(db)
+	}
+
+	db, err := cfg.db.ConnectPostgres()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return db, migration.NewPostgresMigrator(db)
+}
+
+func migrateUp(migrator migration.Migrator) {
+	applied, err := migrator.Up()
+	if err != nil {
+		log.Fatalf("Failed to apply migrations: %s\n", err)
+	}
+
+	if applied > 0 {
+		log.Printf("Applied %d migrations\n", applied)
+	}
+}
+
+func migrateDown(migrator migration.Migrator, steps int) {
+	reverted, err := migrator.Down(steps)
+	if err != nil {
+		log.Fatalf("Failed to revert migrations, %d reverted: %s\n", reverted, err)
+	}
+
+	log.Printf("Reverted %d migrations\n", reverted)
+}
+
+func printMigrationStatus(migrator migration.Migrator) {
+	statuses, err := migrator.Status()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Unknown:
+			state = "unknown, applied " + s.AppliedAt.Format(time.RFC3339)
+		case s.Modified:
+			state = "modified, applied " + s.AppliedAt.Format(time.RFC3339)
+		case !s.AppliedAt.IsZero():
+			state = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%4d %-30s %s\n", s.Version, s.Name, state)
+	}
+}
+
+// getSteps reads the number of migrations to revert, which defaults to one.
+func getSteps(args []string) int {
+	if len(args) == 0 {
+		return 1
+	}
+
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		log.Fatalf("Invalid number of steps: %s\n", args[0])
+	}
+
+	return steps
+}
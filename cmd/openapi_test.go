@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/openapi"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+const specFile = "../api/openapi.json"
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	assert := assert.New(t)
+
+	spec, err := openapi.LoadFile(specFile)
+	assert.NoError(err)
+	assert.NoError(spec.Validate())
+
+	conf := getTestConfig()
+	server := newServer(getTestEnv(&repository.MockStockRepo{}, &repository.MockCountRepo{}), conf)
+	routes := make(map[string]bool)
+	for _, route := range server.Handler.(*gin.Engine).Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	documented := make(map[string]bool)
+	for _, e := range spec.Endpoints() {
+		route := e.Method + " " + openapi.GinPath(e.Path)
+		documented[route] = true
+		assert.True(routes[route], "Documented route %s is not served", route)
+	}
+
+	for route := range routes {
+		assert.True(documented[route], "Route %s is not documented in %s", route, specFile)
+	}
+}
+
+func TestOpenAPISpecRoles(t *testing.T) {
+	assert := assert.New(t)
+
+	spec, err := openapi.LoadFile(specFile)
+	assert.NoError(err)
+
+	conf := getTestConfig()
+	server := newServer(getTestEnv(&repository.MockStockRepo{}, &repository.MockCountRepo{}), conf)
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+
+	for _, e := range spec.Endpoints() {
+		path := e.Path
+		for _, param := range openapi.PathParams(e.Path) {
+			path = strings.Replace(path, "{"+param+"}", "test", 1)
+		}
+
+		if spec.IsSecured(e.Operation) {
+			res := performTestRequest(server.Handler, createTestRequest("", path, e.Method))
+			assert.Equal(http.StatusUnauthorized, res.Code, "%s %s without token", e.Method, e.Path)
+		}
+
+		if e.Operation.RequiredRole == auth.AdminRole {
+			res := performTestRequest(server.Handler, createTestRequest(userToken, path, e.Method))
+			assert.Equal(http.StatusForbidden, res.Code, "%s %s with user token", e.Method, e.Path)
+		}
+	}
+}
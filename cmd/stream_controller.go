@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/stream"
+)
+
+func (e *env) handleStockStream(c *gin.Context) {
+	top, err := getIntParam(c, "top", 0)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if top < 0 {
+		c.Error(apierror.InvalidParameter("top", "must not be negative"))
+		return
+	}
+
+	lastEventID, err := getLastEventID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	symbols, err := e.rules.Symbols.Apply("symbols", getSymbolsFromQuery(c, "symbols"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filter := stream.NewFilter(symbols, top)
+	sub, missed := e.broker.Subscribe(lastEventID)
+	defer e.broker.Unsubscribe(sub)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range missed {
+		writeStreamEvent(c.Writer, event, filter)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			writeStreamEvent(c.Writer, event, filter)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeStreamEvent(w io.Writer, event stream.Event, filter stream.Filter) {
+	event, ok := filter.Apply(event)
+	if !ok {
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+func getLastEventID(c *gin.Context) (int64, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("lastEventId")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, apierror.InvalidParameter("Last-Event-ID", "must be an integer")
+	}
+
+	return id, nil
+}
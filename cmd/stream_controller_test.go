@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleStockStream(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Count: 30},
+			domain.Stock{Symbol: "AMD", Count: 20},
+		},
+	}
+
+	broker := stream.NewBroker(stockRepo, stream.DefaultOptions())
+	e := getTestEnv(stockRepo, nil)
+	e.broker = broker
+
+	conf := getTestConfig()
+	server := newServer(e, conf)
+	token := getTestToken(conf, id.New(), auth.UserRole)
+
+	sub, _ := broker.Subscribe(0)
+	broker.OnRanking(domain.RankingEvent{})
+	first := <-sub.Events
+	broker.OnRanking(domain.RankingEvent{
+		Symbol: "AMD",
+		Stocks: []domain.Stock{domain.Stock{Symbol: "AMD", Count: 40}},
+	})
+	second := <-sub.Events
+	broker.Unsubscribe(sub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := createTestGetRequest(token, "/v1/stocks/stream?symbols=AMD").WithContext(ctx)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(first.ID, 10))
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("text/event-stream", res.Header().Get("Content-Type"))
+	body := res.Body.String()
+	assert.Equal(1, strings.Count(body, "event: "))
+	assert.Contains(body, "id: "+strconv.FormatInt(second.ID, 10)+"\nevent: stock\n")
+	assert.NotContains(body, "AAPL")
+
+	req = createTestGetRequest(token, "/v1/stocks/stream?symbols=AMD").WithContext(ctx)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(first.ID-1, 10))
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	body = res.Body.String()
+	assert.Equal(1, strings.Count(body, "event: "))
+	assert.Contains(body, "id: "+strconv.FormatInt(second.ID, 10)+"\nevent: ranking\n")
+	assert.NotContains(body, "AAPL")
+
+	req = createTestGetRequest(token, "/v1/stocks/stream?top=1").WithContext(ctx)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(first.ID, 10))
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	body = res.Body.String()
+	assert.Equal(1, strings.Count(body, "event: "))
+	assert.Contains(body, `"symbol":"AMD","name":"","count":40,"rank":1`)
+
+	req = createTestGetRequest(token, "/v1/stocks/stream").WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "latest")
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusBadRequest, res.Code)
+}
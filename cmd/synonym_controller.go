@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/httputil"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+type saveSynonymRequest struct {
+	Symbols []string `json:"symbols"`
+}
+
+type importSynonymsResponse struct {
+	Imported int `json:"imported"`
+}
+
+func (e *env) handleGetSynonyms(c *gin.Context) {
+	synonyms, err := e.synonymSvc.GetAll()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, synonyms)
+}
+
+func (e *env) handleSaveSynonym(c *gin.Context) {
+	var req saveSynonymRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(apierror.InvalidBody("must be a JSON object"))
+		return
+	}
+
+	err = e.checkSynonymSymbols(req.Symbols)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	synonym, err := e.synonymSvc.Save(c.Param("term"), req.Symbols)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, synonym)
+}
+
+func (e *env) handleDeleteSynonym(c *gin.Context) {
+	err := e.synonymSvc.Delete(c.Param("term"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
+
+// handleImportSynonyms imports a synonym dictionary in the format it is listed in,
+// merging it into the existing dictionary unless replace=true is given.
+func (e *env) handleImportSynonyms(c *gin.Context) {
+	replace, err := getBoolParam(c, "replace", false)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var synonyms []domain.Synonym
+	err = c.ShouldBindJSON(&synonyms)
+	if err != nil {
+		c.Error(apierror.InvalidBody("must be a JSON array of synonyms"))
+		return
+	}
+
+	for _, synonym := range synonyms {
+		err = e.checkSynonymSymbols(synonym.Symbols)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	imported, err := e.synonymSvc.Import(synonyms, replace)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, importSynonymsResponse{Imported: imported})
+}
+
+func (e *env) checkSynonymSymbols(symbols []string) error {
+	for _, symbol := range symbols {
+		err := e.rules.Symbols.Check("symbols", symbol)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleGetSynonyms(t *testing.T) {
+	assert := assert.New(t)
+
+	synonymRepo := &repository.MockSynonymRepo{
+		FindAllSynonyms: []domain.Synonym{
+			domain.Synonym{Term: "google", Symbols: []string{"GOOG", "GOOGL"}, UpdatedAt: time.Now().UTC()},
+		},
+	}
+
+	conf := getTestConfig()
+	server := newServer(getTestSynonymEnv(synonymRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	res := performTestRequest(server.Handler, createTestGetRequest(token, "/v1/synonyms"))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal(1, synonymRepo.FindAllInvocations)
+	var synonyms []domain.Synonym
+	err := json.NewDecoder(res.Body).Decode(&synonyms)
+	assert.NoError(err)
+	assert.Equal(1, len(synonyms))
+	assert.Equal("google", synonyms[0].Term)
+
+	synonymRepo.UnsetArgs()
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+	res = performTestRequest(server.Handler, createTestGetRequest(userToken, "/v1/synonyms"))
+	assert.Equal(http.StatusForbidden, res.Code)
+	assert.Equal(0, synonymRepo.FindAllInvocations)
+}
+
+func TestHandleSaveSynonym(t *testing.T) {
+	assert := assert.New(t)
+
+	synonymRepo := &repository.MockSynonymRepo{}
+
+	conf := getTestConfig()
+	server := newServer(getTestSynonymEnv(synonymRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	body := saveSynonymRequest{Symbols: []string{"ko"}}
+	req := createTestRequestWithBody(token, "/v1/synonyms/Coca%20Cola", http.MethodPut, body)
+	res := performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("coca cola", synonymRepo.SaveArg.Term)
+	assert.Equal([]string{"KO"}, synonymRepo.SaveArg.Symbols)
+
+	synonymRepo.UnsetArgs()
+	body = saveSynonymRequest{Symbols: []string{"K O"}}
+	req = createTestRequestWithBody(token, "/v1/synonyms/coke", http.MethodPut, body)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, synonymRepo.SaveInvocations)
+
+	synonymRepo.DeleteErr = repository.ErrNoSuchSynonym
+	res = performTestRequest(server.Handler, createTestRequest(token, "/v1/synonyms/pepsi", http.MethodDelete))
+	assert.Equal(http.StatusNotFound, res.Code)
+	assert.Equal("pepsi", synonymRepo.DeleteArg)
+}
+
+func TestHandleImportSynonyms(t *testing.T) {
+	assert := assert.New(t)
+
+	synonymRepo := &repository.MockSynonymRepo{}
+
+	conf := getTestConfig()
+	server := newServer(getTestSynonymEnv(synonymRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	body := []domain.Synonym{
+		domain.Synonym{Term: "Google", Symbols: []string{"GOOGL", "GOOG"}},
+		domain.Synonym{Term: "Coca Cola", Symbols: []string{"KO"}},
+	}
+	req := createTestRequestWithBody(token, "/v1/synonyms/import?replace=true", http.MethodPost, body)
+	res := performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.True(synonymRepo.ImportArgReplace)
+	assert.Equal(2, len(synonymRepo.ImportArgSynonyms))
+	var imported importSynonymsResponse
+	err := json.NewDecoder(res.Body).Decode(&imported)
+	assert.NoError(err)
+	assert.Equal(2, imported.Imported)
+
+	synonymRepo.UnsetArgs()
+	req = createTestRequestWithBody(token, "/v1/synonyms/import", http.MethodPost, body)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusOK, res.Code)
+	assert.False(synonymRepo.ImportArgReplace)
+
+	synonymRepo.UnsetArgs()
+	req = createTestRequestWithBody(token, "/v1/synonyms/import?replace=maybe", http.MethodPost, body)
+	res = performTestRequest(server.Handler, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, synonymRepo.ImportInvocations)
+}
+
+func getTestSynonymEnv(synonymRepo repository.SynonymRepo) *env {
+	e := getTestEnv(nil, nil)
+	e.synonymSvc = service.NewSynonymService(synonymRepo)
+	return e
+}
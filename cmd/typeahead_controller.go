@@ -0,0 +1,199 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/validation"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// typeaheadQuery partial query sent by a typeahead client.
+type typeaheadQuery struct {
+	Seq       int64  `json:"seq"`
+	Query     string `json:"query"`
+	Lang      string `json:"lang"`
+	Limit     int    `json:"limit"`
+	Highlight bool   `json:"highlight"`
+}
+
+// typeaheadResult search results for the query with the same sequence number.
+// Suggestions are alternative queries given when nothing was found.
+// Hits describe how each result matched if highlighting was requested.
+type typeaheadResult struct {
+	Seq         int64              `json:"seq"`
+	Results     []stock.Stock      `json:"results"`
+	Hits        []domain.SearchHit `json:"hits,omitempty"`
+	Suggestions []string           `json:"suggestions,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+func (e *env) handleTypeahead(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("Websocket upgrade failed:", err)
+		return
+	}
+
+	session := newTypeaheadSession(conn, e.stockSvc, e.rules.Rules, typeaheadDebounce)
+	session.run()
+}
+
+// typeaheadSession serves a single typeahead connection. Queries are debounced so
+// only the latest query is searched and results of queries that have been
+// superseded while searching are discarded.
+type typeaheadSession struct {
+	conn     *websocket.Conn
+	stockSvc service.StockService
+	rules    validation.Rules
+	debounce time.Duration
+
+	mu        sync.Mutex
+	writeMu   sync.Mutex
+	latest    int64
+	pending   *typeaheadQuery
+	timer     *time.Timer
+	searching sync.WaitGroup
+}
+
+func newTypeaheadSession(conn *websocket.Conn, stockSvc service.StockService,
+	rules validation.Rules, debounce time.Duration) *typeaheadSession {
+	return &typeaheadSession{
+		conn:     conn,
+		stockSvc: stockSvc,
+		rules:    rules,
+		debounce: debounce,
+	}
+}
+
+func (s *typeaheadSession) run() {
+	defer s.close()
+	s.conn.SetReadLimit(typeaheadMaxMessageSize)
+
+	for {
+		var query typeaheadQuery
+		err := s.conn.ReadJSON(&query)
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Println("Typeahead connection closed:", err)
+			}
+			return
+		}
+
+		s.enqueue(query)
+	}
+}
+
+// enqueue replaces any pending query and restarts the debounce timer.
+func (s *typeaheadSession) enqueue(query typeaheadQuery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if query.Seq <= s.latest {
+		return
+	}
+
+	s.latest = query.Seq
+	s.pending = &query
+	if s.timer != nil && s.timer.Stop() {
+		s.searching.Done()
+	}
+	s.searching.Add(1)
+	s.timer = time.AfterFunc(s.debounce, s.searchPending)
+}
+
+func (s *typeaheadSession) searchPending() {
+	defer s.searching.Done()
+
+	s.mu.Lock()
+	query := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if query == nil {
+		return
+	}
+
+	result := s.search(*query)
+
+	s.mu.Lock()
+	stale := query.Seq < s.latest
+	s.mu.Unlock()
+	if stale {
+		return
+	}
+
+	s.write(result)
+}
+
+func (s *typeaheadSession) search(query typeaheadQuery) typeaheadResult {
+	result := typeaheadResult{
+		Seq:     query.Seq,
+		Results: []stock.Stock{},
+	}
+
+	if strings.TrimSpace(query.Query) == "" {
+		return result
+	}
+
+	q, err := s.rules.Query.Apply("query", query.Query)
+	if err != nil {
+		result.Error = apierror.Wrap(err).Message
+		return result
+	}
+
+	limit := s.rules.SearchLimit.Default
+	if query.Limit != 0 {
+		limit, err = s.rules.SearchLimit.Apply("limit", query.Limit)
+		if err != nil {
+			result.Error = apierror.Wrap(err).Message
+			return result
+		}
+	}
+
+	searchResult, err := s.stockSvc.Search(q, strings.ToLower(query.Lang), limit, query.Highlight)
+	if err != nil {
+		log.Println("Typeahead search failed:", err)
+		result.Error = "Search failed"
+		return result
+	}
+
+	result.Results = searchResult.Stocks(limit)
+	result.Hits = searchResult.Hits(limit)
+	result.Suggestions = searchResult.Suggestions
+	return result
+}
+
+func (s *typeaheadSession) write(result typeaheadResult) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(typeaheadWriteTimeout))
+	err := s.conn.WriteJSON(result)
+	if err != nil {
+		log.Println("Failed to write typeahead result:", err)
+	}
+}
+
+func (s *typeaheadSession) close() {
+	s.mu.Lock()
+	if s.timer != nil && s.timer.Stop() {
+		s.searching.Done()
+	}
+	s.mu.Unlock()
+
+	s.searching.Wait()
+	s.conn.Close()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleTypeahead(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		SearchStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+		},
+	}
+
+	conf := getTestConfig()
+	server := httptest.NewServer(newServer(getTestEnv(stockRepo, nil), conf).Handler)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/stocks/typeahead"
+	header := http.Header{}
+	header.Set(auth.AuthHeaderKey, auth.AuthTokenPrefix+getTestToken(conf, id.New(), auth.UserRole))
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	assert.NoError(err)
+	defer conn.Close()
+
+	for i, q := range []string{"a", "ap", "app"} {
+		err = conn.WriteJSON(typeaheadQuery{Seq: int64(i + 1), Query: q, Limit: 3})
+		assert.NoError(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var result typeaheadResult
+	err = conn.ReadJSON(&result)
+	assert.NoError(err)
+	assert.Equal(int64(3), result.Seq)
+	assert.Equal(1, len(result.Results))
+	assert.Equal("AAPL", result.Results[0].Symbol)
+	assert.Equal(1, stockRepo.SearchInvocations)
+	assert.Equal("app", stockRepo.SearchArgQuery)
+	assert.Equal(3, stockRepo.SearchArgLimit)
+
+	err = conn.WriteJSON(typeaheadQuery{Seq: 2, Query: "stale"})
+	assert.NoError(err)
+	err = conn.WriteJSON(typeaheadQuery{Seq: 4, Query: " "})
+	assert.NoError(err)
+
+	err = conn.ReadJSON(&result)
+	assert.NoError(err)
+	assert.Equal(int64(4), result.Seq)
+	assert.Equal(0, len(result.Results))
+	assert.Equal(1, stockRepo.SearchInvocations)
+
+	_, res, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Error(err)
+	assert.Equal(http.StatusUnauthorized, res.StatusCode)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/httputil"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+)
+
+type subscribeRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+func (e *env) handleGetSubscriptions(c *gin.Context) {
+	subscriptions, err := e.webhookSvc.GetSubscriptions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (e *env) handleSubscribe(c *gin.Context) {
+	var req subscribeRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(apierror.InvalidBody("must be a JSON object"))
+		return
+	}
+
+	subscription, err := e.webhookSvc.Subscribe(req.URL, req.Secret, req.Events)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+func (e *env) handleUnsubscribe(c *gin.Context) {
+	err := e.webhookSvc.Unsubscribe(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
+
+func (e *env) handleGetDeliveries(c *gin.Context) {
+	limit, err := getLimitParam(c, e.rules.deliveryLimit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	deliveries, err := e.webhookSvc.GetDeliveries(c.Param("id"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
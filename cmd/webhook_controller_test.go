@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleSubscribe(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{}
+
+	conf := getTestConfig()
+	server := newServer(getTestWebhookEnv(webhookRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	body := subscribeRequest{
+		URL:    "https://example.com/hooks",
+		Events: []string{domain.EventEnteredTop},
+	}
+	req := createTestRequestWithBody(token, "/v1/webhooks", http.MethodPost, body)
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusCreated, res.Code)
+	assert.Equal(1, webhookRepo.SaveSubscriptionInvocations)
+	saved := webhookRepo.SaveSubscriptionArg
+	assert.Equal(body.URL, saved.URL)
+	assert.Equal(body.Events, saved.Events)
+	assert.NotEqual("", saved.Secret)
+	var subscription domain.Subscription
+	err := json.NewDecoder(res.Body).Decode(&subscription)
+	assert.NoError(err)
+	assert.Equal(saved.ID, subscription.ID)
+	assert.Equal(saved.Secret, subscription.Secret)
+
+	webhookRepo.UnsetArgs()
+	body = subscribeRequest{URL: "https://example.com/hooks", Secret: "s3cret"}
+	req = createTestRequestWithBody(token, "/v1/webhooks", http.MethodPost, body)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusCreated, res.Code)
+	assert.Equal("s3cret", webhookRepo.SaveSubscriptionArg.Secret)
+	assert.Equal(domain.WebhookEvents, webhookRepo.SaveSubscriptionArg.Events)
+
+	webhookRepo.UnsetArgs()
+	body = subscribeRequest{URL: "ftp://example.com"}
+	req = createTestRequestWithBody(token, "/v1/webhooks", http.MethodPost, body)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, webhookRepo.SaveSubscriptionInvocations)
+
+	body = subscribeRequest{URL: "https://example.com", Events: []string{"unknown"}}
+	req = createTestRequestWithBody(token, "/v1/webhooks", http.MethodPost, body)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusBadRequest, res.Code)
+	assert.Equal(0, webhookRepo.SaveSubscriptionInvocations)
+
+	userToken := getTestToken(conf, id.New(), auth.UserRole)
+	req = createTestRequestWithBody(userToken, "/v1/webhooks", http.MethodPost, body)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusForbidden, res.Code)
+}
+
+func TestHandleGetSubscriptions(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{
+		FindSubscriptionsResult: []domain.Subscription{
+			domain.Subscription{ID: "sub-1", URL: "https://example.com", Secret: "s3cret"},
+		},
+	}
+
+	conf := getTestConfig()
+	server := newServer(getTestWebhookEnv(webhookRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	req := createTestGetRequest(token, "/v1/webhooks")
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	var subscriptions []domain.Subscription
+	err := json.NewDecoder(res.Body).Decode(&subscriptions)
+	assert.NoError(err)
+	assert.Equal(1, len(subscriptions))
+	assert.Equal("sub-1", subscriptions[0].ID)
+	assert.Equal("", subscriptions[0].Secret)
+}
+
+func TestHandleUnsubscribe(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{}
+
+	conf := getTestConfig()
+	server := newServer(getTestWebhookEnv(webhookRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	req := createTestRequest(token, "/v1/webhooks/sub-1", http.MethodDelete)
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("sub-1", webhookRepo.DeleteSubscriptionArg)
+
+	webhookRepo.DeleteSubscriptionErr = repository.ErrNoSuchSubscription
+	req = createTestRequest(token, "/v1/webhooks/missing", http.MethodDelete)
+	res = performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusNotFound, res.Code)
+}
+
+func TestHandleGetDeliveries(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{
+		FindDeliveriesResult: []domain.Delivery{
+			domain.Delivery{ID: "d-1", SubscriptionID: "sub-1", Attempt: 1, Success: true},
+		},
+	}
+
+	conf := getTestConfig()
+	server := newServer(getTestWebhookEnv(webhookRepo), conf)
+	token := getTestToken(conf, id.New(), auth.AdminRole)
+
+	req := createTestGetRequest(token, "/v1/webhooks/sub-1/deliveries")
+	res := performTestRequest(server.Handler, req)
+
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("sub-1", webhookRepo.FindDeliveriesArgID)
+	assert.Equal(defaultDeliveryLimit, webhookRepo.FindDeliveriesArgLimit)
+	var deliveries []domain.Delivery
+	err := json.NewDecoder(res.Body).Decode(&deliveries)
+	assert.NoError(err)
+	assert.Equal(1, len(deliveries))
+}
+
+func getTestWebhookEnv(webhookRepo repository.WebhookRepo) *env {
+	e := getTestEnv(nil, nil)
+	e.webhookSvc = service.NewWebhookService(
+		webhookRepo, nil, &webhook.MockSender{}, service.DefaultWebhookOptions())
+	return e
+}
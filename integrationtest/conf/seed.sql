@@ -0,0 +1,20 @@
+INSERT INTO stock(symbol, name, is_active, total_count, updated_at) VALUES
+    ('TWTR', 'Twitter, Inc.', TRUE, 0, CURRENT_TIMESTAMP),
+    ('T', 'AT&T, Inc.', TRUE, 0, CURRENT_TIMESTAMP);
+
+INSERT INTO tweet(id, text, language) VALUES 
+    ('0', 'TWTR tweet 1', 'en'),
+    ('1', 'T tweet 1', 'sv'),
+    ('2', 'TWTR tweet 2', 'en'),
+    ('3', 'TWTR tweet 3', 'en'),
+    ('4', 'T tweet 2', 'sv'),
+    ('5', 'BOTH tweet 2', 'en');
+
+INSERT INTO tweet_symbol(id, symbol, tweet_id) VALUES
+    (1, 'TWTR', '0'),
+    (2, 'T', '1'),
+    (3, 'TWTR', '2'),
+    (4, 'TWTR', '3'),
+    (5, 'T', '4'),
+    (6, 'TWTR', '5'),
+    (7, 'T', '5');
\ No newline at end of file
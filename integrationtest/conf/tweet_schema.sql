@@ -0,0 +1,20 @@
+CREATE TABLE tweet (
+    id VARCHAR(50) PRIMARY KEY,
+    text VARCHAR(500),
+    language VARCHAR(10),
+    author_id VARCHAR(50),
+    author_followers INTEGER,
+    created_at TIMESTAMP
+);
+
+CREATE TABLE tweet_link (
+    id INTEGER PRIMARY KEY,
+    url VARCHAR(200),
+    tweet_id VARCHAR(50) REFERENCES tweet(id)
+);
+
+CREATE TABLE tweet_symbol (
+    id INTEGER PRIMARY KEY,
+    symbol VARCHAR(20) REFERENCES stock(symbol),
+    tweet_id VARCHAR(50) REFERENCES tweet(id)
+);
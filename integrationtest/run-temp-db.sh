@@ -29,7 +29,16 @@ sleep 5
 
 echo 'Setup up database and user'
 docker exec -i $DB_CONTAINER_NAME psql -U postgres < conf/db_setup.sql
-docker exec -i $DB_CONTAINER_NAME psql -U streamlistner streamlistner < conf/schema.sql
+docker run --rm --network container:$DB_CONTAINER_NAME \
+    -e DB_HOST=localhost \
+    -e DB_PORT=5432 \
+    -e DB_NAME="streamlistner" \
+    -e DB_USERNAME="streamlistner" \
+    -e DB_PASSWORD='password' \
+    $SVC_IMAGE ./stocksearch migrate up
+# The tweet tables are created by the stream listener, which owns them
+docker exec -i $DB_CONTAINER_NAME psql -U streamlistner streamlistner < conf/tweet_schema.sql
+docker exec -i $DB_CONTAINER_NAME psql -U streamlistner streamlistner < conf/seed.sql
 docker exec -i $DB_CONTAINER_NAME psql -U postgres streamlistner < conf/db_user_setup.sql
 
 echo 'Database ready'
@@ -33,7 +33,16 @@ sleep 5
 
 echo 'Setup up database and user'
 docker exec -i $DB_CONTAINER_NAME psql -U postgres < conf/db_setup.sql
-docker exec -i $DB_CONTAINER_NAME psql -U streamlistner streamlistner < conf/schema.sql
+docker run --rm --network $NETWORK_NAME \
+    -e DB_HOST=$DB_CONTAINER_NAME \
+    -e DB_PORT=5432 \
+    -e DB_NAME="streamlistner" \
+    -e DB_USERNAME="streamlistner" \
+    -e DB_PASSWORD='password' \
+    $SVC_IMAGE ./stocksearch migrate up
+# The tweet tables are created by the stream listener, which owns them
+docker exec -i $DB_CONTAINER_NAME psql -U streamlistner streamlistner < conf/tweet_schema.sql
+docker exec -i $DB_CONTAINER_NAME psql -U streamlistner streamlistner < conf/seed.sql
 docker exec -i $DB_CONTAINER_NAME psql -U postgres streamlistner < conf/db_user_setup.sql
 
 echo 'Database ready'
@@ -59,6 +68,15 @@ docker run -d --name $SVC_CONTAINER_NAME \
 echo "Running tests"
 resttest run $SVC_PORT
 
+# The conformance suite deletes all stock and tweet data, so it runs after the API tests
+echo "Running repository conformance tests"
+TEST_IMAGE="$SVC_NAME-test:$SVC_VERSION"
+docker build -t $TEST_IMAGE -f ../Dockerfile.test ..
+docker run --rm --network $NETWORK_NAME \
+    -e TEST_DB_URL="postgres://streamlistner:password@$DB_CONTAINER_NAME:5432/streamlistner?sslmode=disable" \
+    $TEST_IMAGE go test ./pkg/repository/ -run Conformance -v
+docker rmi $TEST_IMAGE
+
 # Stopping containers
 docker stop $SVC_CONTAINER_NAME
 docker stop $DB_CONTAINER_NAME
@@ -0,0 +1,121 @@
+package apierror
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Code stable machine readable error code.
+type Code string
+
+// Error codes. Codes are part of the API and must not be changed once released.
+const (
+	CodeInvalidLimit      Code = "INVALID_LIMIT"
+	CodeMissingQuery      Code = "MISSING_QUERY"
+	CodeInvalidParameter  Code = "INVALID_PARAMETER"
+	CodeInvalidBody       Code = "INVALID_BODY"
+	CodeStockNotFound     Code = "STOCK_NOT_FOUND"
+	CodeNotFound          Code = "NOT_FOUND"
+	CodeRankingInProgress Code = "RANKING_IN_PROGRESS"
+	CodeDBUnavailable     Code = "DB_UNAVAILABLE"
+	CodeInternalError     Code = "INTERNAL_ERROR"
+)
+
+// Detail describes what was wrong with a single request field.
+type Detail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error an error with a status and a stable code, sent as the body of error responses.
+// The request id is set when the error is sent.
+type Error struct {
+	Code      Code     `json:"code"`
+	Message   string   `json:"message"`
+	Status    int      `json:"status"`
+	RequestID string   `json:"requestId,omitempty"`
+	Details   []Detail `json:"details,omitempty"`
+}
+
+// New creates an Error.
+func New(status int, code Code, message string, details ...Detail) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Status:  status,
+		Details: details,
+	}
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Extensions exposes the code of errors returned by GraphQL resolvers.
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+// InvalidLimit creates an error for a limit parameter which is not a valid number or out of range.
+func InvalidLimit(field, message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidLimit, "Invalid "+field, Detail{Field: field, Message: message})
+}
+
+// MissingQuery creates an error for a missing or empty search query.
+func MissingQuery(field string) *Error {
+	return New(http.StatusBadRequest, CodeMissingQuery, "Missing query", Detail{Field: field, Message: "must not be empty"})
+}
+
+// InvalidParameter creates an error for an invalid request parameter.
+func InvalidParameter(field, message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidParameter, "Invalid "+field, Detail{Field: field, Message: message})
+}
+
+// InvalidBody creates an error for a request body which could not be parsed.
+func InvalidBody(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidBody, "Invalid request body", Detail{Field: "body", Message: message})
+}
+
+// StockNotFound creates an error for an unknown stock symbol.
+func StockNotFound(symbol string) *Error {
+	return New(http.StatusNotFound, CodeStockNotFound, "No such stock: "+symbol)
+}
+
+// NotFound creates an error for a missing resource.
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Wrap converts any error to an Error. Errors caused by an unavailable database
+// are reported as such, other unknown errors as internal errors without exposing their message.
+func Wrap(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+
+	if IsUnavailable(err) {
+		return New(http.StatusServiceUnavailable, CodeDBUnavailable, "Database unavailable")
+	}
+
+	return New(http.StatusInternalServerError, CodeInternalError, "Internal error")
+}
+
+// HasCode checks if an error is an Error with the given code.
+func HasCode(err error, code Code) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == code
+}
+
+// IsUnavailable checks if an error is caused by a lost or refused database connection.
+func IsUnavailable(err error) bool {
+	if err == driver.ErrBadConn || err == sql.ErrConnDone {
+		return true
+	}
+
+	_, ok := err.(net.Error)
+	return ok
+}
@@ -0,0 +1,35 @@
+package apierror
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	assert := assert.New(t)
+
+	limitErr := InvalidLimit("limit", "must be a number")
+	assert.Equal(limitErr, Wrap(limitErr))
+	assert.Equal(http.StatusBadRequest, limitErr.Status)
+	assert.Equal([]Detail{Detail{Field: "limit", Message: "must be a number"}}, limitErr.Details)
+
+	err := Wrap(sql.ErrConnDone)
+	assert.Equal(CodeDBUnavailable, err.Code)
+	assert.Equal(http.StatusServiceUnavailable, err.Status)
+
+	err = Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+	assert.Equal(CodeDBUnavailable, err.Code)
+
+	err = Wrap(errors.New("pq: syntax error"))
+	assert.Equal(CodeInternalError, err.Code)
+	assert.Equal(http.StatusInternalServerError, err.Status)
+	assert.Equal("Internal error", err.Message)
+
+	assert.True(HasCode(StockNotFound("AAPL"), CodeStockNotFound))
+	assert.False(HasCode(errors.New("no such stock"), CodeStockNotFound))
+}
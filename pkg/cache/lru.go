@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats counters of cache usage since the cache was created.
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+}
+
+// LRU read-through cache holding up to a fixed number of entries, evicting the least
+// recently used entry when full. Entries expire after the ttl, a zero ttl never expires entries.
+// An LRU is safe for concurrent use.
+type LRU struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	order      *list.List
+	generation uint64
+	stats      Stats
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// New creates an LRU cache.
+func New(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Load returns the cached value of a key, or calls load and caches the value it returns on a miss.
+// Errors are not cached. Values loaded while the cache was purged are returned but not cached,
+// since they may have been read before the change which caused the purge.
+func (c *LRU) Load(key string, load func() (interface{}, error)) (interface{}, error) {
+	value, generation, ok := c.get(key)
+	if ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.add(key, value, generation)
+	return value, nil
+}
+
+// Purge removes all entries.
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.generation++
+}
+
+// Stats returns the usage counters of the cache.
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *LRU) get(key string) (interface{}, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		if c.ttl == 0 || c.now().Before(e.expiresAt) {
+			c.order.MoveToFront(el)
+			c.stats.Hits++
+			return e.value, c.generation, true
+		}
+		c.remove(el)
+	}
+
+	c.stats.Misses++
+	return nil, c.generation, false
+}
+
+func (c *LRU) add(key string, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation || c.size <= 0 {
+		return
+	}
+
+	e := &entry{key: key, value: value, expiresAt: c.now().Add(c.ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	assert := assert.New(t)
+
+	c := New(2, 0)
+	loads := 0
+	loader := func(value string) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			loads++
+			return value, nil
+		}
+	}
+
+	value, err := c.Load("a", loader("A"))
+	assert.NoError(err)
+	assert.Equal("A", value)
+	value, err = c.Load("a", loader("other"))
+	assert.NoError(err)
+	assert.Equal("A", value)
+	assert.Equal(1, loads)
+
+	c.Load("b", loader("B"))
+	c.Load("a", loader("A"))
+	c.Load("c", loader("C"))
+	assert.Equal(Stats{Hits: 2, Misses: 3, Evictions: 1, Entries: 2}, c.Stats())
+
+	value, _ = c.Load("b", loader("new B"))
+	assert.Equal("new B", value)
+	value, _ = c.Load("c", loader("new C"))
+	assert.Equal("C", value)
+
+	_, err = c.Load("d", func() (interface{}, error) {
+		return nil, errors.New("load failed")
+	})
+	assert.Error(err)
+	value, _ = c.Load("d", loader("D"))
+	assert.Equal("D", value)
+
+	c.Purge()
+	assert.Equal(0, c.Stats().Entries)
+	value, _ = c.Load("c", loader("purged C"))
+	assert.Equal("purged C", value)
+}
+
+func TestLRUExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := New(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Load("a", func() (interface{}, error) { return "A", nil })
+	now = now.Add(59 * time.Second)
+	value, _ := c.Load("a", func() (interface{}, error) { return "new A", nil })
+	assert.Equal("A", value)
+
+	now = now.Add(time.Second)
+	value, _ = c.Load("a", func() (interface{}, error) { return "new A", nil })
+	assert.Equal("new A", value)
+	assert.Equal(int64(2), c.Stats().Misses)
+}
+
+func TestLRUPurgeWhileLoading(t *testing.T) {
+	assert := assert.New(t)
+
+	c := New(10, 0)
+	value, _ := c.Load("a", func() (interface{}, error) {
+		c.Purge()
+		return "stale", nil
+	})
+	assert.Equal("stale", value)
+	assert.Equal(0, c.Stats().Entries)
+}
@@ -0,0 +1,103 @@
+// Package client is a typed Go client of the stock-search HTTP API.
+//
+// The request and response types and the API methods in client_gen.go are
+// generated from api/openapi.json, run go generate after changing the spec.
+package client
+
+//go:generate go run ../../tools/clientgen -spec ../../api/openapi.json -package client -out client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the stock-search API.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client calling the API at baseURL and authenticating with the token.
+// If httpClient is nil http.DefaultClient is used.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// Error implements the error interface for error responses.
+func (e *Error) Error() string {
+	return fmt.Sprintf("stock-search: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return newError(res)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+func newError(res *http.Response) error {
+	var e Error
+	err := json.NewDecoder(res.Body).Decode(&e)
+	if err != nil || e.Message == "" {
+		e.Message = http.StatusText(res.StatusCode)
+	}
+	e.Status = res.StatusCode
+
+	return &e
+}
@@ -0,0 +1,492 @@
+// Code generated by clientgen from api/openapi.json. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Anomaly a day where the mentions of a stock deviated significantly from its history.
+type Anomaly struct {
+	Count      int64     `json:"count"`
+	Day        time.Time `json:"day"`
+	DetectedAt time.Time `json:"detectedAt"`
+	// Number of standard deviations from the mean.
+	Deviation float64 `json:"deviation"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"stdDev"`
+	Symbol    string  `json:"symbol"`
+}
+
+// BlockAuthorRequest request to block an author.
+type BlockAuthorRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// BlockedAuthor an author whose tweets are excluded when counting mentions.
+type BlockedAuthor struct {
+	AuthorID  string    `json:"authorId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Reason    string    `json:"reason"`
+}
+
+// CacheStats usage of the search and suggestion cache since startup, all zero when the cache is disabled.
+type CacheStats struct {
+	Enabled bool `json:"enabled"`
+	// Entries currently cached.
+	Entries int `json:"entries"`
+	// Entries removed to make room for new ones.
+	Evictions int `json:"evictions"`
+	Hits      int `json:"hits"`
+	Misses    int `json:"misses"`
+}
+
+// Delivery a single attempt to deliver an event to a subscription.
+type Delivery struct {
+	Attempt        int       `json:"attempt"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Error          string    `json:"error,omitempty"`
+	Event          string    `json:"event"`
+	EventID        string    `json:"eventId"`
+	ID             string    `json:"id"`
+	StatusCode     int       `json:"statusCode"`
+	SubscriptionID string    `json:"subscriptionId"`
+	Success        bool      `json:"success"`
+}
+
+// Error body of all error responses. The request id is also returned in the X-Request-ID response header.
+type Error struct {
+	// Stable machine readable error code.
+	Code      string        `json:"code"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	Message   string        `json:"message"`
+	RequestID string        `json:"requestId,omitempty"`
+	Status    int           `json:"status"`
+}
+
+// ErrorDetail describes what was wrong with a single request field.
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FilterReport summary of which mentions were counted and removed when counting stocks.
+type FilterReport struct {
+	CountedMentions int64 `json:"countedMentions"`
+	// Removed mentions per filter rule.
+	RemovedMentions map[string]int64 `json:"removedMentions"`
+}
+
+// GraphQLRequest a GraphQL request.
+type GraphQLRequest struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLResponse a GraphQL response.
+type GraphQLResponse struct {
+	Data   map[string]interface{}   `json:"data,omitempty"`
+	Errors []map[string]interface{} `json:"errors,omitempty"`
+}
+
+// Highlight matched span of a field, in characters from the start of the field with an exclusive end.
+type Highlight struct {
+	End   int    `json:"end"`
+	Field string `json:"field"`
+	Start int    `json:"start"`
+}
+
+// ImportSynonymsResponse result of a synonym import.
+type ImportSynonymsResponse struct {
+	Imported int `json:"imported"`
+}
+
+// RankingReport summary of a ranking run.
+type RankingReport struct {
+	Filter       FilterReport `json:"filter"`
+	FinishedAt   time.Time    `json:"finishedAt"`
+	RankedStocks int          `json:"rankedStocks"`
+	StartedAt    time.Time    `json:"startedAt"`
+}
+
+// SaveSynonymRequest request to map a term to symbols.
+type SaveSynonymRequest struct {
+	Symbols []string `json:"symbols"`
+}
+
+// SearchGroup stocks matching a cashtag, a synonym or the free text terms of a query.
+type SearchGroup struct {
+	// How each stock matched, in the same order as the stocks. Only present when highlighting.
+	Hits   []SearchHit `json:"hits,omitempty"`
+	Stocks []Stock     `json:"stocks"`
+	// Matched synonym term, absent for cashtags and the free text terms.
+	Synonym string   `json:"synonym,omitempty"`
+	Terms   []string `json:"terms,omitempty"`
+	// Cashtag without the dollar sign, absent for synonyms and the free text terms.
+	Ticker string `json:"ticker,omitempty"`
+}
+
+// SearchHit how a stock matched a search query.
+type SearchHit struct {
+	Highlights []Highlight `json:"highlights"`
+	Symbol     string      `json:"symbol"`
+	// Tier the stock matched in. Synonym matches have no highlights.
+	Tier string `json:"tier"`
+}
+
+// SearchQuery a parsed search query.
+type SearchQuery struct {
+	Cashtags []string `json:"cashtags"`
+	Raw      string   `json:"raw"`
+	Terms    []string `json:"terms"`
+}
+
+// SearchResult search results grouped by cashtag and free text terms.
+type SearchResult struct {
+	Groups []SearchGroup `json:"groups"`
+	Query  SearchQuery   `json:"query"`
+	// Alternative spellings of the query, only given when no stocks were found.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// Status response of requests without a result.
+type Status struct {
+	Status string `json:"status"`
+}
+
+// Stock a stock.
+type Stock struct {
+	IsActive bool   `json:"isActive,omitempty"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+}
+
+// SubscribeRequest request to register a webhook subscription. Subscribes to all events if none are given.
+type SubscribeRequest struct {
+	Events []string `json:"events,omitempty"`
+	// Secret used to sign payloads with HMAC-SHA256. Generated if not given.
+	Secret string `json:"secret,omitempty"`
+	URL    string `json:"url"`
+}
+
+// Subscription a registered webhook receiving events.
+type Subscription struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Events    []string  `json:"events"`
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+}
+
+// Synonym maps a term, such as a brand name, to the symbols of the stocks it refers to.
+type Synonym struct {
+	Symbols []string `json:"symbols"`
+	// Up to 4 words, stored folded to lower case words without diacritics.
+	Term string `json:"term"`
+	// Ignored when importing.
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// ExecuteGraphQL executes a GraphQL query. Mutations require the admin role.
+func (c *Client) ExecuteGraphQL(ctx context.Context, body GraphQLRequest) (GraphQLResponse, error) {
+	var result GraphQLResponse
+	err := c.do(ctx, http.MethodPost, "/graphql", nil, nil, body, &result)
+	return result, err
+}
+
+// GetHealth checks that the service and its database are available.
+func (c *Client) GetHealth(ctx context.Context) (Status, error) {
+	var result Status
+	err := c.do(ctx, http.MethodGet, "/health", nil, nil, nil, &result)
+	return result, err
+}
+
+// GetBlockedAuthors lists blocked authors.
+// Requires the ADMIN role.
+func (c *Client) GetBlockedAuthors(ctx context.Context) ([]BlockedAuthor, error) {
+	var result []BlockedAuthor
+	err := c.do(ctx, http.MethodGet, "/v1/authors/blocklist", nil, nil, nil, &result)
+	return result, err
+}
+
+// UnblockAuthor removes an author from the blocklist.
+// Requires the ADMIN role.
+func (c *Client) UnblockAuthor(ctx context.Context, authorID string) (Status, error) {
+	var result Status
+	err := c.do(ctx, http.MethodDelete, "/v1/authors/blocklist/"+url.PathEscape(authorID), nil, nil, nil, &result)
+	return result, err
+}
+
+// BlockAuthor blocks an author from being counted when ranking stocks.
+// Requires the ADMIN role.
+func (c *Client) BlockAuthor(ctx context.Context, authorID string, body *BlockAuthorRequest) (BlockedAuthor, error) {
+	var reqBody interface{}
+	if body != nil {
+		reqBody = body
+	}
+	var result BlockedAuthor
+	err := c.do(ctx, http.MethodPut, "/v1/authors/blocklist/"+url.PathEscape(authorID), nil, nil, reqBody, &result)
+	return result, err
+}
+
+// GetCacheStats reports the usage of the search and suggestion cache.
+// Requires the ADMIN role.
+func (c *Client) GetCacheStats(ctx context.Context) (CacheStats, error) {
+	var result CacheStats
+	err := c.do(ctx, http.MethodGet, "/v1/cache/stats", nil, nil, nil, &result)
+	return result, err
+}
+
+// SearchStocksParams optional and required parameters of SearchStocks.
+// Optional parameters with a zero value are not sent.
+type SearchStocksParams struct {
+	// Search query of at most 100 characters by default.
+	Query string
+	// Maximum number of results, between 1 and 50 by default. Defaults to 10.
+	Limit int
+	// ISO 639-1 language code. Ranks results by mentions in that language.
+	Lang string
+	// Entity tags of cached responses.
+	IfNoneMatch string
+	// Last-Modified time of a cached response, ignored if If-None-Match is given.
+	IfModifiedSince string
+}
+
+// SearchStocks searches for stocks by symbol and name.
+func (c *Client) SearchStocks(ctx context.Context, params SearchStocksParams) ([]Stock, error) {
+	query := url.Values{}
+	header := http.Header{}
+	query.Add("query", params.Query)
+	if params.Limit != 0 {
+		query.Add("limit", strconv.FormatInt(int64(params.Limit), 10))
+	}
+	if params.Lang != "" {
+		query.Add("lang", params.Lang)
+	}
+	if params.IfNoneMatch != "" {
+		header.Set("If-None-Match", params.IfNoneMatch)
+	}
+	if params.IfModifiedSince != "" {
+		header.Set("If-Modified-Since", params.IfModifiedSince)
+	}
+	var result []Stock
+	err := c.do(ctx, http.MethodGet, "/v1/stocks", query, header, nil, &result)
+	return result, err
+}
+
+// RankStocks counts the mentions of all stocks and updates their ranking.
+// Requires the ADMIN role.
+func (c *Client) RankStocks(ctx context.Context) (RankingReport, error) {
+	var result RankingReport
+	err := c.do(ctx, http.MethodPut, "/v1/stocks", nil, nil, nil, &result)
+	return result, err
+}
+
+// GetAnomaliesParams optional and required parameters of GetAnomalies.
+// Optional parameters with a zero value are not sent.
+type GetAnomaliesParams struct {
+	// Maximum number of results, between 1 and 100 by default. Defaults to 20.
+	Limit int
+	// Entity tags of cached responses.
+	IfNoneMatch string
+	// Last-Modified time of a cached response, ignored if If-None-Match is given.
+	IfModifiedSince string
+}
+
+// GetAnomalies gets the most recently detected mention anomalies.
+func (c *Client) GetAnomalies(ctx context.Context, params GetAnomaliesParams) ([]Anomaly, error) {
+	query := url.Values{}
+	header := http.Header{}
+	if params.Limit != 0 {
+		query.Add("limit", strconv.FormatInt(int64(params.Limit), 10))
+	}
+	if params.IfNoneMatch != "" {
+		header.Set("If-None-Match", params.IfNoneMatch)
+	}
+	if params.IfModifiedSince != "" {
+		header.Set("If-Modified-Since", params.IfModifiedSince)
+	}
+	var result []Anomaly
+	err := c.do(ctx, http.MethodGet, "/v1/stocks/anomalies", query, header, nil, &result)
+	return result, err
+}
+
+// SearchStocksGroupedParams optional and required parameters of SearchStocksGrouped.
+// Optional parameters with a zero value are not sent.
+type SearchStocksGroupedParams struct {
+	// Search query of at most 100 characters by default.
+	Query string
+	// Maximum number of results per group, between 1 and 50 by default. Defaults to 10.
+	Limit int
+	// ISO 639-1 language code. Ranks results by mentions in that language.
+	Lang string
+	// Describe how each stock matched, with the matched spans of its symbol and name. Defaults to false.
+	Highlight bool
+	// Entity tags of cached responses.
+	IfNoneMatch string
+	// Last-Modified time of a cached response, ignored if If-None-Match is given.
+	IfModifiedSince string
+}
+
+// SearchStocksGrouped searches for stocks with results grouped by the cashtags and free text terms of the query.
+func (c *Client) SearchStocksGrouped(ctx context.Context, params SearchStocksGroupedParams) (SearchResult, error) {
+	query := url.Values{}
+	header := http.Header{}
+	query.Add("query", params.Query)
+	if params.Limit != 0 {
+		query.Add("limit", strconv.FormatInt(int64(params.Limit), 10))
+	}
+	if params.Lang != "" {
+		query.Add("lang", params.Lang)
+	}
+	if params.Highlight {
+		query.Add("highlight", strconv.FormatBool(params.Highlight))
+	}
+	if params.IfNoneMatch != "" {
+		header.Set("If-None-Match", params.IfNoneMatch)
+	}
+	if params.IfModifiedSince != "" {
+		header.Set("If-Modified-Since", params.IfModifiedSince)
+	}
+	var result SearchResult
+	err := c.do(ctx, http.MethodGet, "/v1/stocks/search", query, header, nil, &result)
+	return result, err
+}
+
+// GetSuggestionsParams optional and required parameters of GetSuggestions.
+// Optional parameters with a zero value are not sent.
+type GetSuggestionsParams struct {
+	// Symbols to exclude, comma separated or repeated. At most 50 by default.
+	Exclude []string
+	// Maximum number of results, between 1 and 50 by default. Defaults to 5.
+	Limit int
+	// ISO 639-1 language code. Ranks results by mentions in that language.
+	Lang string
+	// Entity tags of cached responses.
+	IfNoneMatch string
+	// Last-Modified time of a cached response, ignored if If-None-Match is given.
+	IfModifiedSince string
+}
+
+// GetSuggestions gets the most mentioned stocks.
+func (c *Client) GetSuggestions(ctx context.Context, params GetSuggestionsParams) ([]Stock, error) {
+	query := url.Values{}
+	header := http.Header{}
+	if len(params.Exclude) > 0 {
+		query.Add("exclude", strings.Join(params.Exclude, ","))
+	}
+	if params.Limit != 0 {
+		query.Add("limit", strconv.FormatInt(int64(params.Limit), 10))
+	}
+	if params.Lang != "" {
+		query.Add("lang", params.Lang)
+	}
+	if params.IfNoneMatch != "" {
+		header.Set("If-None-Match", params.IfNoneMatch)
+	}
+	if params.IfModifiedSince != "" {
+		header.Set("If-Modified-Since", params.IfModifiedSince)
+	}
+	var result []Stock
+	err := c.do(ctx, http.MethodGet, "/v1/stocks/suggestions", query, header, nil, &result)
+	return result, err
+}
+
+// RankStock counts the mentions of a single stock and updates its ranking.
+// Requires the ADMIN role.
+func (c *Client) RankStock(ctx context.Context, symbol string) (RankingReport, error) {
+	var result RankingReport
+	err := c.do(ctx, http.MethodPut, "/v1/stocks/"+url.PathEscape(symbol), nil, nil, nil, &result)
+	return result, err
+}
+
+// GetSynonyms lists the synonym dictionary, in the format accepted by the import.
+// Requires the ADMIN role.
+func (c *Client) GetSynonyms(ctx context.Context) ([]Synonym, error) {
+	var result []Synonym
+	err := c.do(ctx, http.MethodGet, "/v1/synonyms", nil, nil, nil, &result)
+	return result, err
+}
+
+// ImportSynonymsParams optional and required parameters of ImportSynonyms.
+// Optional parameters with a zero value are not sent.
+type ImportSynonymsParams struct {
+	// Replace the whole dictionary instead of merging into it. Defaults to false.
+	Replace bool
+}
+
+// ImportSynonyms imports a synonym dictionary, replacing the symbols of existing terms.
+// Requires the ADMIN role.
+func (c *Client) ImportSynonyms(ctx context.Context, params ImportSynonymsParams, body []Synonym) (ImportSynonymsResponse, error) {
+	query := url.Values{}
+	if params.Replace {
+		query.Add("replace", strconv.FormatBool(params.Replace))
+	}
+	var result ImportSynonymsResponse
+	err := c.do(ctx, http.MethodPost, "/v1/synonyms/import", query, nil, body, &result)
+	return result, err
+}
+
+// DeleteSynonym removes a term from the synonym dictionary.
+// Requires the ADMIN role.
+func (c *Client) DeleteSynonym(ctx context.Context, term string) (Status, error) {
+	var result Status
+	err := c.do(ctx, http.MethodDelete, "/v1/synonyms/"+url.PathEscape(term), nil, nil, nil, &result)
+	return result, err
+}
+
+// SaveSynonym maps a term to the symbols of the stocks it refers to.
+// Requires the ADMIN role.
+func (c *Client) SaveSynonym(ctx context.Context, term string, body SaveSynonymRequest) (Synonym, error) {
+	var result Synonym
+	err := c.do(ctx, http.MethodPut, "/v1/synonyms/"+url.PathEscape(term), nil, nil, body, &result)
+	return result, err
+}
+
+// GetSubscriptions lists webhook subscriptions.
+// Requires the ADMIN role.
+func (c *Client) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var result []Subscription
+	err := c.do(ctx, http.MethodGet, "/v1/webhooks", nil, nil, nil, &result)
+	return result, err
+}
+
+// Subscribe registers a webhook subscription.
+// Requires the ADMIN role.
+func (c *Client) Subscribe(ctx context.Context, body SubscribeRequest) (Subscription, error) {
+	var result Subscription
+	err := c.do(ctx, http.MethodPost, "/v1/webhooks", nil, nil, body, &result)
+	return result, err
+}
+
+// Unsubscribe deletes a webhook subscription.
+// Requires the ADMIN role.
+func (c *Client) Unsubscribe(ctx context.Context, id string) (Status, error) {
+	var result Status
+	err := c.do(ctx, http.MethodDelete, "/v1/webhooks/"+url.PathEscape(id), nil, nil, nil, &result)
+	return result, err
+}
+
+// GetDeliveriesParams optional and required parameters of GetDeliveries.
+// Optional parameters with a zero value are not sent.
+type GetDeliveriesParams struct {
+	// Maximum number of results, between 1 and 200 by default. Defaults to 50.
+	Limit int
+}
+
+// GetDeliveries lists the most recent delivery attempts of a subscription.
+// Requires the ADMIN role.
+func (c *Client) GetDeliveries(ctx context.Context, id string, params GetDeliveriesParams) ([]Delivery, error) {
+	query := url.Values{}
+	if params.Limit != 0 {
+		query.Add("limit", strconv.FormatInt(int64(params.Limit), 10))
+	}
+	var result []Delivery
+	err := c.do(ctx, http.MethodGet, "/v1/webhooks/"+url.PathEscape(id)+"/deliveries", query, nil, nil, &result)
+	return result, err
+}
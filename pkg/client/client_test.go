@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mimir-news/stock-search/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	assert := assert.New(t)
+
+	spec, err := openapi.LoadFile("../../api/openapi.json")
+	assert.NoError(err)
+	expected, err := openapi.GenerateClient(spec, "client", "api/openapi.json")
+	assert.NoError(err)
+
+	actual, err := ioutil.ReadFile("client_gen.go")
+	assert.NoError(err)
+	assert.Equal(string(expected), string(actual), "client_gen.go is out of date, run go generate ./pkg/client/")
+}
+
+func TestClientRequests(t *testing.T) {
+	assert := assert.New(t)
+
+	var lastReq *http.Request
+	var lastBody SubscribeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastReq = r
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/stocks/suggestions":
+			json.NewEncoder(w).Encode([]Stock{Stock{Symbol: "AAPL", Name: "Apple Inc."}})
+		case "/v1/webhooks":
+			json.NewDecoder(r.Body).Decode(&lastBody)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Subscription{ID: "sub-1", URL: lastBody.URL})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(Error{Code: "STOCK_NOT_FOUND", Message: "No such stock: A/B", Status: http.StatusNotFound})
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL+"/", "test-token", nil)
+	ctx := context.Background()
+
+	stocks, err := c.GetSuggestions(ctx, GetSuggestionsParams{Exclude: []string{"A", "B"}, Lang: "sv"})
+	assert.NoError(err)
+	assert.Equal(1, len(stocks))
+	assert.Equal("AAPL", stocks[0].Symbol)
+	assert.Equal(http.MethodGet, lastReq.Method)
+	assert.Equal("A,B", lastReq.URL.Query().Get("exclude"))
+	assert.Equal("sv", lastReq.URL.Query().Get("lang"))
+	assert.Equal("", lastReq.URL.Query().Get("limit"))
+	assert.Equal("Bearer test-token", lastReq.Header.Get("Authorization"))
+
+	sub, err := c.Subscribe(ctx, SubscribeRequest{URL: "http://hook", Secret: "s"})
+	assert.NoError(err)
+	assert.Equal("sub-1", sub.ID)
+	assert.Equal(http.MethodPost, lastReq.Method)
+	assert.Equal("http://hook", lastBody.URL)
+	assert.Equal("application/json", lastReq.Header.Get("Content-Type"))
+
+	_, err = c.RankStock(ctx, "A/B")
+	assert.Equal("/v1/stocks/A%2FB", lastReq.URL.EscapedPath())
+	apiErr, ok := err.(*Error)
+	assert.True(ok)
+	assert.Equal(http.StatusNotFound, apiErr.Status)
+	assert.Equal("STOCK_NOT_FOUND", apiErr.Code)
+	assert.Equal("No such stock: A/B", apiErr.Message)
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Anomaly a day where the mentions of a stock deviated significantly from its history.
+type Anomaly struct {
+	Symbol     string    `json:"symbol"`
+	Day        time.Time `json:"day"`
+	Count      int64     `json:"count"`
+	Mean       float64   `json:"mean"`
+	StdDev     float64   `json:"stdDev"`
+	Deviation  float64   `json:"deviation"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// RankingEvent describes a completed ranking run.
+// Symbol is set if only a single stock was ranked.
+type RankingEvent struct {
+	Symbol string
+	Stocks []Stock
+	Report RankingReport
+}
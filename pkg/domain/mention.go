@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// Mention a single mention of a stock in a tweet.
+// AuthorFollowers is negative if the follower count of the author is unknown.
+type Mention struct {
+	Symbol           string
+	TweetID          string
+	AuthorID         string
+	AuthorFollowers  int64
+	AuthorDailyPosts int64
+	AuthorBlocked    bool
+	Language         string
+	Text             string
+	CashtagCount     int64
+	CreatedAt        time.Time
+}
+
+// Tweet a tweet along with the symbols of the stocks it mentions.
+// AuthorFollowers is negative if the follower count of the author is unknown.
+type Tweet struct {
+	ID              string
+	Text            string
+	Language        string
+	AuthorID        string
+	AuthorFollowers int64
+	Symbols         []string
+	CreatedAt       time.Time
+}
+
+// BlockedAuthor an author whose tweets are excluded when counting mentions.
+type BlockedAuthor struct {
+	AuthorID  string    `json:"authorId"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// FilterReport summary of which mentions were counted and removed when counting stocks.
+type FilterReport struct {
+	CountedMentions int64            `json:"countedMentions"`
+	RemovedMentions map[string]int64 `json:"removedMentions"`
+}
+
+// NewFilterReport creates an empty filter report.
+func NewFilterReport() FilterReport {
+	return FilterReport{
+		RemovedMentions: make(map[string]int64),
+	}
+}
+
+// RankingReport summary of a ranking run.
+type RankingReport struct {
+	StartedAt    time.Time    `json:"startedAt"`
+	FinishedAt   time.Time    `json:"finishedAt"`
+	RankedStocks int          `json:"rankedStocks"`
+	Filter       FilterReport `json:"filter"`
+}
+
+// DailyCount the number of mentions of a stock during a single day.
+type DailyCount struct {
+	Symbol string
+	Day    time.Time
+	Count  int64
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/mimir-news/pkg/schema/stock"
+)
+
+// SearchQuery parsed search query.
+type SearchQuery struct {
+	Raw      string   `json:"raw"`
+	Cashtags []string `json:"cashtags"`
+	Terms    []string `json:"terms"`
+}
+
+// IsEmpty checks if nothing searchable was found in the query.
+func (q SearchQuery) IsEmpty() bool {
+	return len(q.Cashtags) == 0 && len(q.Terms) == 0
+}
+
+// SearchGroup stocks matching either a cashtag, a synonym or the free text terms of a query.
+// Hits describe how each stock matched when highlighting is requested, in the same order as the stocks.
+type SearchGroup struct {
+	Ticker  string        `json:"ticker,omitempty"`
+	Synonym string        `json:"synonym,omitempty"`
+	Terms   []string      `json:"terms,omitempty"`
+	Stocks  []stock.Stock `json:"stocks"`
+	Hits    []SearchHit   `json:"hits,omitempty"`
+}
+
+// Match tiers, in the order search results are ranked. Synonym matches are found
+// through the synonym dictionary and have no highlighted spans.
+const (
+	MatchPrefix  = "prefix"
+	MatchToken   = "token"
+	MatchFuzzy   = "fuzzy"
+	MatchSynonym = "synonym"
+)
+
+// Highlight fields.
+const (
+	FieldSymbol = "symbol"
+	FieldName   = "name"
+)
+
+// Highlight span of a field which matched the query. Start and End are
+// offsets in characters from the start of the field, End is exclusive.
+type Highlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// SearchHit describes which tier a stock matched in and which parts of it matched.
+type SearchHit struct {
+	Symbol     string      `json:"symbol"`
+	Tier       string      `json:"tier"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// SearchResult search results grouped by cashtag, followed by the
+// synonym matches and the results of the free text terms if there were any.
+// Suggestions are alternative queries given when nothing was found.
+type SearchResult struct {
+	Query       SearchQuery   `json:"query"`
+	Groups      []SearchGroup `json:"groups"`
+	Suggestions []string      `json:"suggestions,omitempty"`
+}
+
+// Stocks returns the distinct stocks of all groups in order, at most limit.
+func (r SearchResult) Stocks(limit int) []stock.Stock {
+	stocks := make([]stock.Stock, 0, limit)
+	r.distinct(limit, func(group SearchGroup, i int) {
+		stocks = append(stocks, group.Stocks[i])
+	})
+
+	return stocks
+}
+
+// Hits returns the hits of the stocks returned by Stocks, or nil if the results are not highlighted.
+func (r SearchResult) Hits(limit int) []SearchHit {
+	var hits []SearchHit
+	r.distinct(limit, func(group SearchGroup, i int) {
+		if i < len(group.Hits) {
+			hits = append(hits, group.Hits[i])
+		}
+	})
+
+	return hits
+}
+
+// distinct calls fn for the first occurrence of each stock of all groups in order, at most limit times.
+func (r SearchResult) distinct(limit int, fn func(group SearchGroup, i int)) {
+	seen := make(map[string]bool)
+	for _, group := range r.Groups {
+		for i, s := range group.Stocks {
+			if len(seen) == limit {
+				return
+			}
+			if seen[s.Symbol] {
+				continue
+			}
+			seen[s.Symbol] = true
+			fn(group, i)
+		}
+	}
+}
+
+// Term word of the search vocabulary, either a symbol or a word of a stock name,
+// along with the total mentions of the stocks it belongs to.
+type Term struct {
+	Text     string
+	Count    int64
+	IsSymbol bool
+}
+
+// Synonym maps a term, such as a brand name, to the symbols of the stocks it refers to.
+type Synonym struct {
+	Term      string    `json:"term"`
+	Symbols   []string  `json:"symbols"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
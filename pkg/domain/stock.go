@@ -6,9 +6,10 @@ import (
 
 // Stock holds stock data.
 type Stock struct {
-	Name   string
-	Symbol string
-	Count  int64
+	Name           string
+	Symbol         string
+	Count          int64
+	LanguageCounts map[string]int64
 }
 
 // NewDomainStock converts a stock to the internal domain structure.
@@ -0,0 +1,9 @@
+package domain
+
+import "time"
+
+// DataVersion the version of a data set, which is bumped by each write to it, and the time of the last write.
+type DataVersion struct {
+	Version   int64
+	UpdatedAt time.Time
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// Webhook event names.
+const (
+	EventRankingCompleted = "ranking.completed"
+	EventEnteredTop       = "stock.entered_top"
+	EventLeftTop          = "stock.left_top"
+)
+
+// WebhookEvents all events which can be subscribed to.
+var WebhookEvents = []string{EventRankingCompleted, EventEnteredTop, EventLeftTop}
+
+// Subscription a registered webhook receiving events.
+type Subscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Subscribes checks if the subscription should receive an event.
+func (s Subscription) Subscribes(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookEvent payload sent to subscriptions.
+type WebhookEvent struct {
+	ID        string         `json:"id"`
+	Event     string         `json:"event"`
+	Symbol    string         `json:"symbol,omitempty"`
+	Rank      int            `json:"rank,omitempty"`
+	TopN      int            `json:"topN,omitempty"`
+	Report    *RankingReport `json:"report,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Delivery a single attempt to deliver an event to a subscription.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscriptionId"`
+	EventID        string    `json:"eventId"`
+	Event          string    `json:"event"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"statusCode"`
+	Error          string    `json:"error,omitempty"`
+	Success        bool      `json:"success"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
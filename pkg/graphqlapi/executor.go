@@ -0,0 +1,120 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request a GraphQL request as sent by clients.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query a parsed and validated GraphQL request.
+type Query struct {
+	req       Request
+	doc       *ast.Document
+	operation *ast.OperationDefinition
+}
+
+// IsMutation returns true if the requested operation is a mutation.
+func (q *Query) IsMutation() bool {
+	return q.operation.Operation == ast.OperationTypeMutation
+}
+
+// Executor parses and executes GraphQL requests.
+type Executor interface {
+	Parse(req Request) (*Query, []gqlerrors.FormattedError)
+	Execute(ctx context.Context, q *Query) *graphql.Result
+}
+
+// NewExecutor creates an Executor which rejects queries exceeding the limits.
+func NewExecutor(schema graphql.Schema, limits Limits) Executor {
+	return &executor{
+		schema: schema,
+		limits: limits,
+	}
+}
+
+type executor struct {
+	schema graphql.Schema
+	limits Limits
+}
+
+// Parse parses and validates a request against the schema and the query limits.
+func (e *executor) Parse(req Request) (*Query, []gqlerrors.FormattedError) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{
+			Body: []byte(req.Query),
+			Name: "GraphQL request",
+		}),
+	})
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+
+	validation := graphql.ValidateDocument(&e.schema, doc, nil)
+	if !validation.IsValid {
+		return nil, validation.Errors
+	}
+
+	operation, err := findOperation(doc, req.OperationName)
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+
+	err = e.limits.check(doc, operation, req.Variables)
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+
+	return &Query{
+		req:       req,
+		doc:       doc,
+		operation: operation,
+	}, nil
+}
+
+// Execute executes a parsed query.
+func (e *executor) Execute(ctx context.Context, q *Query) *graphql.Result {
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           q.doc,
+		OperationName: q.req.OperationName,
+		Args:          q.req.Variables,
+		Context:       ctx,
+	})
+}
+
+func findOperation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if name == "" {
+			if operation != nil {
+				return nil, fmt.Errorf("operationName is required when the query contains multiple operations")
+			}
+			operation = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op, nil
+		}
+	}
+
+	if operation == nil {
+		return nil, fmt.Errorf("no operation named %q", name)
+	}
+
+	return operation, nil
+}
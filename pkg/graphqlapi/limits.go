@@ -0,0 +1,140 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits restricts how expensive a single query can be.
+// The depth is the deepest level of nested fields and the complexity is the number
+// of fields resolved, where fields below a list count once per requested list item.
+// Introspection fields are not counted. A zero value disables the limit.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// DefaultLimits returns the default query limits.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxDepth:      6,
+		MaxComplexity: 1000,
+	}
+}
+
+// listSizeArgs arguments which control the size of list fields.
+var listSizeArgs = []string{"limit", "days"}
+
+// defaultListSizes expected size of list fields when not given by an argument.
+var defaultListSizes = map[string]int{
+	"search":       DefaultSearchLimit,
+	"searchGroups": DefaultSearchLimit,
+	"stocks":       DefaultSearchLimit,
+	"suggestions":  DefaultSuggestionLimit,
+	"history":      DefaultHistoryDays,
+}
+
+func (l Limits) check(doc *ast.Document, operation *ast.OperationDefinition, vars map[string]interface{}) error {
+	m := newMeasurer(doc, vars)
+	depth, complexity := m.measure(operation.SelectionSet)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, l.MaxDepth)
+	}
+
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, l.MaxComplexity)
+	}
+
+	return nil
+}
+
+type measurer struct {
+	fragments map[string]*ast.FragmentDefinition
+	vars      map[string]interface{}
+}
+
+func newMeasurer(doc *ast.Document, vars map[string]interface{}) *measurer {
+	m := &measurer{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		vars:      vars,
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	return m
+}
+
+// measure returns the depth and complexity of a selection set.
+func (m *measurer) measure(set *ast.SelectionSet) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			d, c = m.measure(s.SelectionSet)
+			d, c = d+1, 1+m.listSize(s)*c
+		case *ast.InlineFragment:
+			d, c = m.measure(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[s.Name.Value]; ok {
+				d, c = m.measure(fragment.SelectionSet)
+			}
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+// listSize returns the number of items a field is expected to resolve.
+func (m *measurer) listSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		for _, name := range listSizeArgs {
+			if arg.Name.Value == name {
+				if size, ok := m.intValue(arg.Value); ok && size > 0 {
+					return size
+				}
+			}
+		}
+	}
+
+	if size, ok := defaultListSizes[field.Name.Value]; ok {
+		return size
+	}
+
+	return 1
+}
+
+func (m *measurer) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		i, err := strconv.Atoi(v.Value)
+		return i, err == nil
+	case *ast.Variable:
+		switch n := m.vars[v.Name.Value].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,57 @@
+package graphqlapi
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasureQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		query      string
+		vars       map[string]interface{}
+		depth      int
+		complexity int
+	}{
+		{query: `{ stock(symbol: "A") { symbol name } }`, depth: 2, complexity: 3},
+		{query: `{ search(query: "a") { symbol } }`, depth: 2, complexity: 1 + DefaultSearchLimit},
+		{query: `{ search(query: "a", limit: 3) { symbol name } }`, depth: 2, complexity: 7},
+		{
+			query:      `query($n: Int) { suggestions(limit: $n) { symbol } }`,
+			vars:       map[string]interface{}{"n": float64(20)},
+			depth:      2,
+			complexity: 21,
+		},
+		{
+			query:      `{ stock(symbol: "A") { ...f } } fragment f on Stock { history(days: 2) { day count } }`,
+			depth:      3,
+			complexity: 6,
+		},
+		{
+			query:      `{ stock(symbol: "A") { ... on Stock { symbol } } __schema { types { name } } }`,
+			depth:      2,
+			complexity: 2,
+		},
+	}
+
+	for _, test := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: test.query})
+		assert.NoError(err)
+		operation, err := findOperation(doc, "")
+		assert.NoError(err)
+
+		depth, complexity := newMeasurer(doc, test.vars).measure(operation.SelectionSet)
+		assert.Equal(test.depth, depth, test.query)
+		assert.Equal(test.complexity, complexity, test.query)
+
+		limits := Limits{MaxDepth: test.depth, MaxComplexity: test.complexity}
+		assert.NoError(limits.check(doc, operation, test.vars))
+		limits = Limits{MaxDepth: test.depth - 1}
+		assert.Error(limits.check(doc, operation, test.vars))
+		limits = Limits{MaxComplexity: test.complexity - 1}
+		assert.Error(limits.check(doc, operation, test.vars))
+	}
+}
@@ -0,0 +1,465 @@
+package graphqlapi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/validation"
+)
+
+const dayFormat = "2006-01-02"
+
+// Default values of optional arguments.
+const (
+	DefaultSearchLimit     = 10
+	DefaultSuggestionLimit = 5
+	DefaultHistoryDays     = 7
+)
+
+// NewSchema creates the GraphQL schema with resolvers delegating to a StockService.
+// Arguments are validated according to the rules before being passed on.
+func NewSchema(stockSvc service.StockService, rules validation.Rules) (graphql.Schema, error) {
+	r := &resolver{stockSvc: stockSvc, rules: rules}
+
+	dailyCountType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "DailyCount",
+		Description: "Number of mentions of a stock during a day.",
+		Fields: graphql.Fields{
+			"day": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.DailyCount).Day.Format(dayFormat), nil
+				},
+			},
+			"count": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.DailyCount).Count, nil
+				},
+			},
+		},
+	})
+
+	stockType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Stock",
+		Fields: graphql.Fields{
+			"symbol": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(stock.Stock).Symbol, nil
+				},
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(stock.Stock).Name, nil
+				},
+			},
+			"history": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(dailyCountType))),
+				Description: "Mentions per day, oldest first, ending today.",
+				Args: graphql.FieldConfigArgument{
+					"days": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultHistoryDays},
+				},
+				Resolve: r.history,
+			},
+		},
+	})
+
+	filterReportType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "FilterReport",
+		Fields: graphql.Fields{
+			"countedMentions": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.FilterReport).CountedMentions, nil
+				},
+			},
+			"removedMentions": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.NewObject(graphql.ObjectConfig{
+					Name: "RemovedMentions",
+					Fields: graphql.Fields{
+						"rule":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+						"count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+					},
+				})))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return removedMentions(p.Source.(domain.FilterReport)), nil
+				},
+			},
+		},
+	})
+
+	rankingReportType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RankingReport",
+		Fields: graphql.Fields{
+			"startedAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.RankingReport).StartedAt, nil
+				},
+			},
+			"finishedAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.RankingReport).FinishedAt, nil
+				},
+			},
+			"rankedStocks": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.RankingReport).RankedStocks, nil
+				},
+			},
+			"filter": &graphql.Field{
+				Type: graphql.NewNonNull(filterReportType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.RankingReport).Filter, nil
+				},
+			},
+		},
+	})
+
+	stockList := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stockType)))
+	highlightType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Highlight",
+		Description: "Matched span of a field in characters, the end is exclusive.",
+		Fields: graphql.Fields{
+			"field": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Either symbol or name.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.Highlight).Field, nil
+				},
+			},
+			"start": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.Highlight).Start, nil
+				},
+			},
+			"end": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.Highlight).End, nil
+				},
+			},
+		},
+	})
+
+	searchHitType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "SearchHit",
+		Description: "How a stock matched a search query.",
+		Fields: graphql.Fields{
+			"symbol": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.SearchHit).Symbol, nil
+				},
+			},
+			"tier": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The match tier, one of prefix, token, fuzzy or synonym.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.SearchHit).Tier, nil
+				},
+			},
+			"highlights": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(highlightType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.SearchHit).Highlights, nil
+				},
+			},
+		},
+	})
+
+	searchGroupType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "SearchGroup",
+		Description: "Stocks matching a cashtag, a synonym or the free text terms of a search query.",
+		Fields: graphql.Fields{
+			"ticker": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The cashtag without the dollar sign, null for synonyms and the free text terms.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ticker := p.Source.(domain.SearchGroup).Ticker
+					if ticker == "" {
+						return nil, nil
+					}
+					return ticker, nil
+				},
+			},
+			"synonym": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The matched synonym term, null for cashtags and the free text terms.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					synonym := p.Source.(domain.SearchGroup).Synonym
+					if synonym == "" {
+						return nil, nil
+					}
+					return synonym, nil
+				},
+			},
+			"terms": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					terms := p.Source.(domain.SearchGroup).Terms
+					if terms == nil {
+						return []string{}, nil
+					}
+					return terms, nil
+				},
+			},
+			"stocks": &graphql.Field{
+				Type: stockList,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(domain.SearchGroup).Stocks, nil
+				},
+			},
+			"hits": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(searchHitType))),
+				Description: "How each stock matched in the same order as the stocks, empty unless highlighting was requested.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					hits := p.Source.(domain.SearchGroup).Hits
+					if hits == nil {
+						return []domain.SearchHit{}, nil
+					}
+					return hits, nil
+				},
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"stock": &graphql.Field{
+				Type:        stockType,
+				Description: "Looks up a stock by its symbol, null if there is no such stock.",
+				Args: graphql.FieldConfigArgument{
+					"symbol": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.stock,
+			},
+			"search": &graphql.Field{
+				Type:        stockList,
+				Description: "Searches for stocks by symbol and name.",
+				Args: graphql.FieldConfigArgument{
+					"query": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"lang":  &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultSearchLimit},
+				},
+				Resolve: r.search,
+			},
+			"searchGroups": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(searchGroupType))),
+				Description: "Searches for stocks with results grouped by the cashtags, synonyms and free text terms of the query.",
+				Args: graphql.FieldConfigArgument{
+					"query":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"lang":      &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"limit":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultSearchLimit},
+					"highlight": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+				},
+				Resolve: r.searchGroups,
+			},
+			"didYouMean": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Description: "Alternative spellings of a query which finds no stocks, empty if there are matches.",
+				Args: graphql.FieldConfigArgument{
+					"query": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"lang":  &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultSearchLimit},
+				},
+				Resolve: r.didYouMean,
+			},
+			"suggestions": &graphql.Field{
+				Type:        stockList,
+				Description: "The most mentioned stocks.",
+				Args: graphql.FieldConfigArgument{
+					"exclude": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"lang":    &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"limit":   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultSuggestionLimit},
+				},
+				Resolve: r.suggestions,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"rankStocks": &graphql.Field{
+				Type:        graphql.NewNonNull(rankingReportType),
+				Description: "Ranks all stocks. Requires the admin role.",
+				Resolve:     r.rankStocks,
+			},
+			"rankStock": &graphql.Field{
+				Type:        graphql.NewNonNull(rankingReportType),
+				Description: "Ranks a single stock. Requires the admin role.",
+				Args: graphql.FieldConfigArgument{
+					"symbol": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.rankStock,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+type resolver struct {
+	stockSvc service.StockService
+	rules    validation.Rules
+}
+
+func (r *resolver) stock(p graphql.ResolveParams) (interface{}, error) {
+	symbol := p.Args["symbol"].(string)
+	err := r.rules.Symbols.Check("symbol", symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := r.stockSvc.GetStock(symbol)
+	if apierror.HasCode(err, apierror.CodeStockNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, apierror.Wrap(err)
+	}
+
+	return s, nil
+}
+
+func (r *resolver) search(p graphql.ResolveParams) (interface{}, error) {
+	res, limit, err := r.searchResult(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Stocks(limit), nil
+}
+
+func (r *resolver) searchGroups(p graphql.ResolveParams) (interface{}, error) {
+	res, _, err := r.searchResult(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Groups, nil
+}
+
+func (r *resolver) didYouMean(p graphql.ResolveParams) (interface{}, error) {
+	res, _, err := r.searchResult(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Suggestions == nil {
+		return []string{}, nil
+	}
+
+	return res.Suggestions, nil
+}
+
+func (r *resolver) searchResult(p graphql.ResolveParams) (domain.SearchResult, int, error) {
+	query, err := r.rules.Query.Apply("query", p.Args["query"].(string))
+	if err != nil {
+		return domain.SearchResult{}, 0, err
+	}
+
+	limit, err := r.rules.SearchLimit.Apply("limit", p.Args["limit"].(int))
+	if err != nil {
+		return domain.SearchResult{}, 0, err
+	}
+
+	highlight, _ := p.Args["highlight"].(bool)
+	res, err := r.stockSvc.Search(query, language(p), limit, highlight)
+	if err != nil {
+		return domain.SearchResult{}, 0, apierror.Wrap(err)
+	}
+
+	return res, limit, nil
+}
+
+func (r *resolver) suggestions(p graphql.ResolveParams) (interface{}, error) {
+	excluded := make([]string, 0)
+	if values, ok := p.Args["exclude"].([]interface{}); ok {
+		for _, v := range values {
+			excluded = append(excluded, v.(string))
+		}
+	}
+
+	excluded, err := r.rules.Symbols.Apply("exclude", excluded)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := r.rules.SuggestionLimit.Apply("limit", p.Args["limit"].(int))
+	if err != nil {
+		return nil, err
+	}
+
+	stocks, err := r.stockSvc.GetSuggestions(excluded, language(p), limit)
+	return result(stocks, err)
+}
+
+func (r *resolver) history(p graphql.ResolveParams) (interface{}, error) {
+	symbol := p.Source.(stock.Stock).Symbol
+	history, err := r.stockSvc.GetHistory(symbol, p.Args["days"].(int))
+	return result(history, err)
+}
+
+func (r *resolver) rankStocks(p graphql.ResolveParams) (interface{}, error) {
+	report, err := r.stockSvc.RankStocks()
+	return result(report, err)
+}
+
+func (r *resolver) rankStock(p graphql.ResolveParams) (interface{}, error) {
+	symbol := p.Args["symbol"].(string)
+	err := r.rules.Symbols.Check("symbol", symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := r.stockSvc.RankStock(symbol)
+	return result(report, err)
+}
+
+// result converts errors to API errors so their code is exposed as
+// an error extension and internal messages are not leaked.
+func result(value interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, apierror.Wrap(err)
+	}
+
+	return value, nil
+}
+
+func language(p graphql.ResolveParams) string {
+	lang, _ := p.Args["lang"].(string)
+	return strings.ToLower(strings.TrimSpace(lang))
+}
+
+func removedMentions(report domain.FilterReport) []map[string]interface{} {
+	rules := make([]string, 0, len(report.RemovedMentions))
+	for rule := range report.RemovedMentions {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	removed := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		removed = append(removed, map[string]interface{}{
+			"rule":  rule,
+			"count": report.RemovedMentions[rule],
+		})
+	}
+
+	return removed
+}
@@ -0,0 +1,119 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mimir-news/pkg/httputil/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authMetadataKey metadata key carrying the bearer token, gRPC lower cases all keys.
+const authMetadataKey = "authorization"
+
+// NewAuthInterceptor creates an interceptor which authenticates calls using the
+// bearer token in the authorization metadata. Tokens are checked by the same
+// middleware as the HTTP API so both APIs accept and reject the same tokens.
+// Calls to adminMethods additionally require the admin role.
+func NewAuthInterceptor(opts *auth.Options, adminMethods ...string) grpc.UnaryServerInterceptor {
+	a := newAuthenticator(opts, adminMethods)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// WithToken returns a context which sends the token as authorization metadata
+// on outgoing calls.
+func WithToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, authMetadataKey, auth.AuthTokenPrefix+token)
+}
+
+type authenticator struct {
+	user    *gin.Engine
+	admin   *gin.Engine
+	isAdmin map[string]bool
+}
+
+func newAuthenticator(opts *auth.Options, adminMethods []string) *authenticator {
+	isAdmin := make(map[string]bool)
+	for _, method := range adminMethods {
+		isAdmin[method] = true
+	}
+
+	return &authenticator{
+		user:    newAuthEngine(auth.RequireToken(opts)),
+		admin:   newAuthEngine(auth.RequireToken(opts), auth.AllowRoles(auth.AdminRole)),
+		isAdmin: isAdmin,
+	}
+}
+
+func newAuthEngine(filters ...gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(filters...)
+	r.POST("/*method", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return r
+}
+
+func (a *authenticator) authenticate(ctx context.Context, method string) error {
+	req, err := http.NewRequest(http.MethodPost, method, nil)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(authMetadataKey); len(values) > 0 {
+		req.Header.Set(auth.AuthHeaderKey, values[0])
+	}
+
+	engine := a.user
+	if a.isAdmin[method] {
+		engine = a.admin
+	}
+
+	rec := &statusRecorder{header: make(http.Header)}
+	engine.ServeHTTP(rec, req)
+
+	switch rec.status {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, "forbidden")
+	default:
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+}
+
+// statusRecorder minimal http.ResponseWriter recording the response status.
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *statusRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// StockSearchClient client side of the stock search service.
+// Use WithToken to authenticate calls.
+type StockSearchClient interface {
+	Search(ctx context.Context, req *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	GetSuggestions(ctx context.Context, req *SuggestionsRequest, opts ...grpc.CallOption) (*StocksResponse, error)
+	RankStocks(ctx context.Context, req *RankStocksRequest, opts ...grpc.CallOption) (*RankingResponse, error)
+	RankStock(ctx context.Context, req *RankStockRequest, opts ...grpc.CallOption) (*RankingResponse, error)
+	GetStock(ctx context.Context, req *GetStockRequest, opts ...grpc.CallOption) (*StockResponse, error)
+}
+
+// NewStockSearchClient creates a StockSearchClient using a client connection.
+func NewStockSearchClient(cc *grpc.ClientConn) StockSearchClient {
+	return &client{cc: cc}
+}
+
+type client struct {
+	cc *grpc.ClientConn
+}
+
+func (c *client) Search(ctx context.Context, req *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	res := new(SearchResponse)
+	err := c.invoke(ctx, SearchMethod, req, res, opts)
+	return res, err
+}
+
+func (c *client) GetSuggestions(ctx context.Context, req *SuggestionsRequest, opts ...grpc.CallOption) (*StocksResponse, error) {
+	res := new(StocksResponse)
+	err := c.invoke(ctx, GetSuggestionsMethod, req, res, opts)
+	return res, err
+}
+
+func (c *client) RankStocks(ctx context.Context, req *RankStocksRequest, opts ...grpc.CallOption) (*RankingResponse, error) {
+	res := new(RankingResponse)
+	err := c.invoke(ctx, RankStocksMethod, req, res, opts)
+	return res, err
+}
+
+func (c *client) RankStock(ctx context.Context, req *RankStockRequest, opts ...grpc.CallOption) (*RankingResponse, error) {
+	res := new(RankingResponse)
+	err := c.invoke(ctx, RankStockMethod, req, res, opts)
+	return res, err
+}
+
+func (c *client) GetStock(ctx context.Context, req *GetStockRequest, opts ...grpc.CallOption) (*StockResponse, error) {
+	res := new(StockResponse)
+	err := c.invoke(ctx, GetStockMethod, req, res, opts)
+	return res, err
+}
+
+func (c *client) invoke(ctx context.Context, method string, req, res interface{}, opts []grpc.CallOption) error {
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	return c.cc.Invoke(ctx, method, req, res, opts...)
+}
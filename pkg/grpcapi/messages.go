@@ -0,0 +1,60 @@
+package grpcapi
+
+import (
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// SearchRequest request to search for stocks matching a query.
+// Highlight requests a description of how each stock matched.
+type SearchRequest struct {
+	Query     string `json:"query"`
+	Language  string `json:"language,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Highlight bool   `json:"highlight,omitempty"`
+}
+
+// SuggestionsRequest request for the most mentioned stocks.
+type SuggestionsRequest struct {
+	Exclude  []string `json:"exclude,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+}
+
+// RankStocksRequest request to rank all stocks.
+type RankStocksRequest struct{}
+
+// RankStockRequest request to rank a single stock.
+type RankStockRequest struct {
+	Symbol string `json:"symbol"`
+}
+
+// GetStockRequest request to look up a stock by its symbol.
+type GetStockRequest struct {
+	Symbol string `json:"symbol"`
+}
+
+// SearchResponse distinct matching stocks along with the results grouped by cashtag, synonym and
+// free text terms. Hits describe how the distinct stocks matched if highlighting was requested.
+// Suggestions are alternative queries given when nothing was found.
+type SearchResponse struct {
+	Stocks      []stock.Stock        `json:"stocks"`
+	Hits        []domain.SearchHit   `json:"hits,omitempty"`
+	Groups      []domain.SearchGroup `json:"groups"`
+	Suggestions []string             `json:"suggestions,omitempty"`
+}
+
+// StocksResponse list of stocks.
+type StocksResponse struct {
+	Stocks []stock.Stock `json:"stocks"`
+}
+
+// StockResponse a single stock.
+type StockResponse struct {
+	Stock stock.Stock `json:"stock"`
+}
+
+// RankingResponse report of a completed ranking.
+type RankingResponse struct {
+	Report domain.RankingReport `json:"report"`
+}
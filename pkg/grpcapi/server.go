@@ -0,0 +1,263 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName fully qualified name of the stock search gRPC service.
+const ServiceName = "mimir.stocksearch.StockSearch"
+
+// Full method names of the stock search service.
+const (
+	SearchMethod         = "/" + ServiceName + "/Search"
+	GetSuggestionsMethod = "/" + ServiceName + "/GetSuggestions"
+	RankStocksMethod     = "/" + ServiceName + "/RankStocks"
+	RankStockMethod      = "/" + ServiceName + "/RankStock"
+	GetStockMethod       = "/" + ServiceName + "/GetStock"
+)
+
+// AdminMethods methods which require the caller to have the admin role.
+var AdminMethods = []string{RankStocksMethod, RankStockMethod}
+
+// StockSearchServer server side of the stock search service.
+type StockSearchServer interface {
+	Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error)
+	GetSuggestions(ctx context.Context, req *SuggestionsRequest) (*StocksResponse, error)
+	RankStocks(ctx context.Context, req *RankStocksRequest) (*RankingResponse, error)
+	RankStock(ctx context.Context, req *RankStockRequest) (*RankingResponse, error)
+	GetStock(ctx context.Context, req *GetStockRequest) (*StockResponse, error)
+}
+
+// RegisterStockSearchServer registers a StockSearchServer on a grpc server.
+func RegisterStockSearchServer(s *grpc.Server, srv StockSearchServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// NewServer creates a StockSearchServer backed by a StockService.
+// Requests are validated according to the rules, which also
+// provide the limits used when a request does not specify one.
+func NewServer(stockSvc service.StockService, rules validation.Rules) StockSearchServer {
+	return &server{
+		stockSvc: stockSvc,
+		rules:    rules,
+	}
+}
+
+type server struct {
+	stockSvc service.StockService
+	rules    validation.Rules
+}
+
+func (s *server) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	query, err := s.rules.Query.Apply("query", req.Query)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	limit, err := getLimit(req.Limit, s.rules.SearchLimit)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	result, err := s.stockSvc.Search(query, normalizeLanguage(req.Language), limit, req.Highlight)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &SearchResponse{
+		Stocks:      result.Stocks(limit),
+		Hits:        result.Hits(limit),
+		Groups:      result.Groups,
+		Suggestions: result.Suggestions,
+	}, nil
+}
+
+func (s *server) GetSuggestions(ctx context.Context, req *SuggestionsRequest) (*StocksResponse, error) {
+	excluded, err := s.rules.Symbols.Apply("exclude", req.Exclude)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	limit, err := getLimit(req.Limit, s.rules.SuggestionLimit)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	stocks, err := s.stockSvc.GetSuggestions(excluded, normalizeLanguage(req.Language), limit)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &StocksResponse{Stocks: stocks}, nil
+}
+
+func (s *server) RankStocks(ctx context.Context, req *RankStocksRequest) (*RankingResponse, error) {
+	report, err := s.stockSvc.RankStocks()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &RankingResponse{Report: report}, nil
+}
+
+func (s *server) RankStock(ctx context.Context, req *RankStockRequest) (*RankingResponse, error) {
+	err := s.rules.Symbols.Check("symbol", req.Symbol)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	report, err := s.stockSvc.RankStock(req.Symbol)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &RankingResponse{Report: report}, nil
+}
+
+func (s *server) GetStock(ctx context.Context, req *GetStockRequest) (*StockResponse, error) {
+	err := s.rules.Symbols.Check("symbol", req.Symbol)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	stock, err := s.stockSvc.GetStock(req.Symbol)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &StockResponse{Stock: stock}, nil
+}
+
+// toStatusError converts service errors to gRPC status errors with the
+// code corresponding to the HTTP status of the error.
+func toStatusError(err error) error {
+	apiErr := apierror.Wrap(err)
+	code := codes.Internal
+	switch apiErr.Status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, apiErr.Error())
+}
+
+// getLimit validates a requested limit, zero meaning that none was specified.
+func getLimit(limit int, rule validation.LimitRule) (int, error) {
+	if limit == 0 {
+		return rule.Default, nil
+	}
+
+	return rule.Apply("limit", limit)
+}
+
+func normalizeLanguage(language string) string {
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*StockSearchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Search", Handler: searchHandler},
+		{MethodName: "GetSuggestions", Handler: getSuggestionsHandler},
+		{MethodName: "RankStocks", Handler: rankStocksHandler},
+		{MethodName: "RankStock", Handler: rankStockHandler},
+		{MethodName: "GetStock", Handler: getStockHandler},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func searchHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(SearchRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockSearchServer).Search(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: SearchMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockSearchServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func getSuggestionsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(SuggestionsRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockSearchServer).GetSuggestions(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: GetSuggestionsMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockSearchServer).GetSuggestions(ctx, req.(*SuggestionsRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func rankStocksHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(RankStocksRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockSearchServer).RankStocks(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: RankStocksMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockSearchServer).RankStocks(ctx, req.(*RankStocksRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func rankStockHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(RankStockRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockSearchServer).RankStock(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: RankStockMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockSearchServer).RankStock(ctx, req.(*RankStockRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func getStockHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(GetStockRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockSearchServer).GetStock(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: GetStockMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockSearchServer).GetStock(ctx, req.(*GetStockRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
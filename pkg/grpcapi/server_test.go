@@ -0,0 +1,163 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/pkg/httputil/auth"
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/service"
+	"github.com/mimir-news/stock-search/pkg/validation"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSearchAndSuggestions(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		SearchStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+		},
+		FindMostCommonStocks: []domain.Stock{
+			domain.Stock{Symbol: "TSLA", Name: "Tesla Inc."},
+		},
+	}
+	client, creds, stop := startTestServer(t, stockRepo, nil)
+	defer stop()
+	ctx := WithToken(context.Background(), getTestToken(creds, auth.UserRole))
+
+	res, err := client.Search(ctx, &SearchRequest{Query: "app", Language: " SV "})
+	assert.NoError(err)
+	assert.Equal(1, len(res.Stocks))
+	assert.Equal("AAPL", res.Stocks[0].Symbol)
+	assert.Equal("app", stockRepo.SearchArgQuery)
+	assert.Equal("sv", stockRepo.SearchArgLanguage)
+	assert.Equal(10, stockRepo.SearchArgLimit)
+	assert.Equal(1, len(res.Groups))
+	assert.Equal([]string{"app"}, res.Groups[0].Terms)
+
+	res, err = client.Search(ctx, &SearchRequest{Query: "$aapl vs $tsla"})
+	assert.NoError(err)
+	assert.Equal(2, len(res.Groups))
+	assert.Equal("AAPL", res.Groups[0].Ticker)
+	assert.Equal("TSLA", res.Groups[1].Ticker)
+	assert.Equal(1, len(res.Stocks))
+
+	_, err = client.Search(ctx, &SearchRequest{})
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Search(ctx, &SearchRequest{Query: "app", Limit: 1000000})
+	assert.NoError(err)
+	assert.Equal(50, stockRepo.SearchArgLimit)
+
+	_, err = client.GetSuggestions(ctx, &SuggestionsRequest{Exclude: []string{"AAPL'--"}})
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+
+	suggestions, err := client.GetSuggestions(ctx, &SuggestionsRequest{Exclude: []string{"AAPL"}, Limit: 3})
+	assert.NoError(err)
+	assert.Equal(1, len(suggestions.Stocks))
+	assert.Equal("TSLA", suggestions.Stocks[0].Symbol)
+	assert.Equal([]string{"AAPL"}, stockRepo.FindMostCommonArgExcluded)
+	assert.Equal(3, stockRepo.FindMostCommonArgLimit)
+}
+
+func TestGetStock(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindStock: domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+	}
+	client, creds, stop := startTestServer(t, stockRepo, nil)
+	defer stop()
+	ctx := WithToken(context.Background(), getTestToken(creds, auth.UserRole))
+
+	res, err := client.GetStock(ctx, &GetStockRequest{Symbol: "AAPL"})
+	assert.NoError(err)
+	assert.Equal("AAPL", res.Stock.Symbol)
+	assert.Equal("Apple Inc.", res.Stock.Name)
+	assert.Equal("AAPL", stockRepo.FindArgSymbol)
+
+	stockRepo.FindErr = repository.ErrNoSuchStock
+	_, err = client.GetStock(ctx, &GetStockRequest{Symbol: "MISSING"})
+	assert.Equal(codes.NotFound, status.Code(err))
+}
+
+func TestRankStockRequiresAdmin(t *testing.T) {
+	assert := assert.New(t)
+
+	countRepo := &repository.MockCountRepo{
+		CountOneStock: domain.Stock{Symbol: "AAPL", Count: 10},
+	}
+	client, creds, stop := startTestServer(t, &repository.MockStockRepo{}, countRepo)
+	defer stop()
+
+	_, err := client.RankStock(context.Background(), &RankStockRequest{Symbol: "AAPL"})
+	assert.Equal(codes.Unauthenticated, status.Code(err))
+	assert.Equal(0, countRepo.CountOneInvocations)
+
+	_, err = client.RankStock(WithToken(context.Background(), "invalid-token"), &RankStockRequest{Symbol: "AAPL"})
+	assert.Equal(codes.Unauthenticated, status.Code(err))
+
+	userCtx := WithToken(context.Background(), getTestToken(creds, auth.UserRole))
+	_, err = client.RankStock(userCtx, &RankStockRequest{Symbol: "AAPL"})
+	assert.Equal(codes.PermissionDenied, status.Code(err))
+	assert.Equal(0, countRepo.CountOneInvocations)
+
+	adminCtx := WithToken(context.Background(), getTestToken(creds, auth.AdminRole))
+	res, err := client.RankStock(adminCtx, &RankStockRequest{Symbol: "AAPL"})
+	assert.NoError(err)
+	assert.Equal(1, res.Report.RankedStocks)
+	assert.Equal("AAPL", countRepo.CountOneArg)
+
+	countRepo.CountOneErr = repository.ErrNoSuchStock
+	_, err = client.RankStock(adminCtx, &RankStockRequest{Symbol: "MISSING"})
+	assert.Equal(codes.NotFound, status.Code(err))
+}
+
+func startTestServer(t *testing.T, stockRepo repository.StockRepo, countRepo repository.CountRepo) (StockSearchClient, auth.JWTCredentials, func()) {
+	creds := auth.JWTCredentials{
+		Issuer: "stock-search-test",
+		Secret: id.New(),
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stockSvc := service.NewStockService(stockRepo, countRepo, repository.NewMemoryRankingLock())
+	s := grpc.NewServer(grpc.UnaryInterceptor(NewAuthInterceptor(auth.NewOptions(creds), AdminMethods...)))
+	RegisterStockSearchServer(s, NewServer(stockSvc, validation.DefaultRules()))
+	go s.Serve(lis)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := func() {
+		conn.Close()
+		s.Stop()
+	}
+
+	return NewStockSearchClient(conn), creds, stop
+}
+
+func getTestToken(creds auth.JWTCredentials, role string) string {
+	signer := auth.NewSigner(creds, time.Hour)
+	token, err := signer.Sign(id.New(), auth.User{ID: id.New(), Role: role})
+	if err != nil {
+		panic(err)
+	}
+
+	return token
+}
@@ -0,0 +1,400 @@
+// Package migration applies versioned schema migrations embedded in the service, recording
+// applied migrations in the schema_migration table along with checksums of their statements.
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration errors.
+var (
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+	ErrIrreversible     = errors.New("migration cannot be reverted")
+	ErrUnknownVersion   = errors.New("applied migration is unknown to this version of the service")
+)
+
+// Error an error concerning a single migration.
+type Error struct {
+	Version int
+	Name    string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("migration %d %s: %s", e.Version, e.Name, e.Err)
+}
+
+// Migration a versioned change of the schema, where Down reverts Up.
+// Migrations with an empty Down cannot be reverted.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum returns the hex encoded SHA-256 hash of the up statements.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// Status state of a migration, AppliedAt is the zero time if the migration is pending.
+// Modified is set if an applied migration differs from the embedded one, and
+// Unknown if it was applied by a newer version of the service.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+	Modified  bool
+	Unknown   bool
+}
+
+// Migrator applies and reverts migrations.
+type Migrator interface {
+	Up() (int, error)
+	Down(steps int) (int, error)
+	Status() ([]Status, error)
+}
+
+// lockFunc acquires a lock within a transaction, which is held until the transaction ends.
+type lockFunc func(ctx context.Context, tx *sql.Tx) error
+
+// sqlMigrator Migrator running migrations over a single connection. Each migration is
+// applied in its own transaction, which holds the lock of the dialect while checking that
+// the migration is still pending and applying it.
+type sqlMigrator struct {
+	db         *sql.DB
+	migrations []Migration
+	lock       lockFunc
+}
+
+func newSQLMigrator(db *sql.DB, migrations []Migration, lock lockFunc) *sqlMigrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &sqlMigrator{
+		db:         db,
+		migrations: sorted,
+		lock:       lock,
+	}
+}
+
+// applied a migration recorded in the migrations table.
+type applied struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+const createMigrationTableQuery = `
+	CREATE TABLE IF NOT EXISTS schema_migration (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+
+const findAppliedQuery = `
+	SELECT version, name, checksum, applied_at FROM schema_migration
+	ORDER BY version`
+
+const isAppliedQuery = `
+	SELECT COUNT(*) FROM schema_migration WHERE version = $1`
+
+const saveAppliedQuery = `
+	INSERT INTO schema_migration(version, name, checksum, applied_at) VALUES($1, $2, $3, $4)`
+
+const deleteAppliedQuery = `
+	DELETE FROM schema_migration WHERE version = $1`
+
+// Up applies all pending migrations in order of version, returning the number of applied migrations.
+// Nothing is applied if any applied migration has been modified. Migrations applied by a newer
+// version of the service are left as is, so older replicas can start during a rolling upgrade.
+func (m *sqlMigrator) Up() (int, error) {
+	count := 0
+	err := m.withConn(func(ctx context.Context, conn *sql.Conn) error {
+		done, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			ok, err := m.apply(ctx, conn, migration)
+			if err != nil {
+				return &Error{Version: migration.Version, Name: migration.Name, Err: err}
+			}
+			if ok {
+				count++
+			}
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down reverts the given number of the latest applied migrations, returning the number of
+// reverted migrations. It stops early if another process reverts the same migrations meanwhile.
+func (m *sqlMigrator) Down(steps int) (int, error) {
+	count := 0
+	err := m.withConn(func(ctx context.Context, conn *sql.Conn) error {
+		done, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(done))
+		for version := range done {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for _, version := range versions {
+			if count == steps {
+				break
+			}
+
+			migration, ok := m.find(version)
+			if !ok {
+				return &Error{Version: version, Name: done[version].name, Err: ErrUnknownVersion}
+			}
+			if migration.Down == "" {
+				return &Error{Version: version, Name: migration.Name, Err: ErrIrreversible}
+			}
+
+			ok, err := m.revert(ctx, conn, migration)
+			if err != nil {
+				return &Error{Version: version, Name: migration.Name, Err: err}
+			}
+			if !ok {
+				return nil
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status returns the state of the embedded migrations and of any
+// migrations applied by a newer version of the service, ordered by version.
+func (m *sqlMigrator) Status() ([]Status, error) {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = m.createMigrationTable(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := findApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if a, ok := done[migration.Version]; ok {
+			status.AppliedAt = a.appliedAt
+			status.Modified = a.checksum != migration.Checksum()
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, a := range done {
+		if _, ok := m.find(a.version); !ok {
+			statuses = append(statuses, Status{Version: a.version, Name: a.name, AppliedAt: a.appliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// withConn runs a function on a single connection once the migrations table exists.
+func (m *sqlMigrator) withConn(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = m.createMigrationTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(ctx, conn)
+}
+
+// createMigrationTable creates the migrations table if missing while holding
+// the lock, as replicas starting at the same time would otherwise race to create it.
+func (m *sqlMigrator) createMigrationTable(ctx context.Context, conn *sql.Conn) error {
+	tx, err := m.begin(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, createMigrationTableQuery)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// begin starts a transaction holding the lock of the dialect.
+func (m *sqlMigrator) begin(ctx context.Context, conn *sql.Conn) (*sql.Tx, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.lock(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// verify finds the applied migrations, checking that none of them have been modified.
+func (m *sqlMigrator) verify(ctx context.Context, conn *sql.Conn) (map[int]applied, error) {
+	done, err := findApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		a, ok := done[migration.Version]
+		if ok && a.checksum != migration.Checksum() {
+			return nil, &Error{Version: migration.Version, Name: migration.Name, Err: ErrChecksumMismatch}
+		}
+	}
+
+	return done, nil
+}
+
+// apply runs the up statements of a migration and records it in a single transaction,
+// returning false if the migration turns out to have been applied already.
+func (m *sqlMigrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) (bool, error) {
+	tx, err := m.begin(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := isApplied(ctx, tx, migration.Version)
+	if err != nil || ok {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, migration.Up)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, saveAppliedQuery,
+		migration.Version, migration.Name, migration.Checksum(), time.Now().UTC())
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// revert runs the down statements of a migration and removes its record in a single transaction,
+// returning false if the migration turns out to have been reverted already.
+func (m *sqlMigrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) (bool, error) {
+	tx, err := m.begin(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := isApplied(ctx, tx, migration.Version)
+	if err != nil || !ok {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, migration.Down)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, deleteAppliedQuery, migration.Version)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func isApplied(ctx context.Context, tx *sql.Tx, version int) (bool, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, isAppliedQuery, version).Scan(&count)
+	return count > 0, err
+}
+
+func (m *sqlMigrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+func findApplied(ctx context.Context, conn *sql.Conn) (map[int]applied, error) {
+	rows, err := conn.QueryContext(ctx, findAppliedQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int]applied)
+	for rows.Next() {
+		var a applied
+		err = rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		done[a.version] = a
+	}
+
+	return done, rows.Err()
+}
@@ -0,0 +1,164 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	// SQLite driver registered as sqlite3.
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigratorUpAndDown(t *testing.T) {
+	assert := assert.New(t)
+	db := openTestDB(t)
+	defer db.Close()
+
+	migrator := NewSQLiteMigrator(db)
+	applied, err := migrator.Up()
+	assert.NoError(err)
+	assert.Equal(len(sqliteMigrations), applied)
+	assert.True(tableExists(t, db, "stock_token"))
+
+	applied, err = migrator.Up()
+	assert.NoError(err)
+	assert.Equal(0, applied)
+
+	reverted, err := migrator.Down(2)
+	assert.NoError(err)
+	assert.Equal(2, reverted)
+	assert.False(tableExists(t, db, "data_version"))
+	assert.False(tableExists(t, db, "stock_token"))
+	assert.True(tableExists(t, db, "author_blocklist"))
+
+	statuses, err := migrator.Status()
+	assert.NoError(err)
+	assert.Equal(4, len(statuses))
+	assert.False(statuses[1].AppliedAt.IsZero())
+	assert.True(statuses[2].AppliedAt.IsZero())
+
+	reverted, err = migrator.Down(5)
+	assert.Equal(1, reverted)
+	assert.Equal(ErrIrreversible, err.(*Error).Err)
+	assert.Equal(1, err.(*Error).Version)
+	assert.False(tableExists(t, db, "author_blocklist"))
+
+	applied, err = migrator.Up()
+	assert.NoError(err)
+	assert.Equal(3, applied)
+	assert.True(tableExists(t, db, "stock_token"))
+}
+
+func TestMigratorChecksumVerification(t *testing.T) {
+	assert := assert.New(t)
+	db := openTestDB(t)
+	defer db.Close()
+
+	migrator := newSQLMigrator(db, sqliteMigrations[:2], noLock)
+	_, err := migrator.Up()
+	assert.NoError(err)
+
+	_, err = db.Exec("UPDATE schema_migration SET checksum = 'modified' WHERE version = 2")
+	assert.NoError(err)
+
+	applied, err := NewSQLiteMigrator(db).Up()
+	assert.Equal(0, applied)
+	assert.Equal(ErrChecksumMismatch, err.(*Error).Err)
+	assert.Equal(2, err.(*Error).Version)
+	assert.False(tableExists(t, db, "stock_token"))
+
+	statuses, err := NewSQLiteMigrator(db).Status()
+	assert.NoError(err)
+	assert.True(statuses[1].Modified)
+}
+
+func TestMigratorUnknownVersion(t *testing.T) {
+	assert := assert.New(t)
+	db := openTestDB(t)
+	defer db.Close()
+
+	_, err := NewSQLiteMigrator(db).Up()
+	assert.NoError(err)
+
+	migrator := newSQLMigrator(db, sqliteMigrations[:2], noLock)
+	applied, err := migrator.Up()
+	assert.NoError(err)
+	assert.Equal(0, applied)
+
+	statuses, err := migrator.Status()
+	assert.NoError(err)
+	assert.Equal(4, len(statuses))
+	assert.True(statuses[2].Unknown)
+	assert.True(statuses[3].Unknown)
+
+	reverted, err := migrator.Down(1)
+	assert.Equal(0, reverted)
+	assert.Equal(ErrUnknownVersion, err.(*Error).Err)
+}
+
+func TestMigratorChecksPendingWhileLocked(t *testing.T) {
+	assert := assert.New(t)
+	db := openTestDB(t)
+	defer db.Close()
+
+	// Another process applies the second migration while the lock is awaited.
+	locks := 0
+	lock := func(ctx context.Context, tx *sql.Tx) error {
+		locks++
+		if locks < 3 {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, saveAppliedQuery, 2, sqliteMigrations[1].Name, sqliteMigrations[1].Checksum(), time.Now().UTC())
+		return err
+	}
+
+	applied, err := newSQLMigrator(db, sqliteMigrations[:2], lock).Up()
+	assert.NoError(err)
+	assert.Equal(1, applied)
+	assert.Equal(3, locks)
+	assert.True(tableExists(t, db, "stock"))
+	assert.False(tableExists(t, db, "author_blocklist"))
+
+	applied, err = newSQLMigrator(db, sqliteMigrations[:2], noLock).Up()
+	assert.NoError(err)
+	assert.Equal(1, applied)
+
+	// Another process reverts the second migration while the lock is awaited.
+	locks = 0
+	lock = func(ctx context.Context, tx *sql.Tx) error {
+		locks++
+		if locks < 2 {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, deleteAppliedQuery, 2)
+		return err
+	}
+
+	reverted, err := newSQLMigrator(db, sqliteMigrations[:2], lock).Down(1)
+	assert.NoError(err)
+	assert.Equal(0, reverted)
+	assert.Equal(2, locks)
+	assert.True(tableExists(t, db, "author_blocklist"))
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1", table).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return count > 0
+}
@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+)
+
+// advisoryLockKey key of the postgres advisory lock held while migrating.
+const advisoryLockKey = 7267358
+
+// serviceRole the postgres role the service connects as, which is granted access to the tables
+// of the service by the migrations if it exists. New tables must be granted in their migration.
+const serviceRole = "stocksearch"
+
+// NewPostgresMigrator creates a Migrator applying the postgres migrations. Processes migrating at
+// the same time are serialized by a transaction level advisory lock, so each migration is applied
+// once. Unlike a session level lock it is released with the transaction, which also works through
+// a transaction pooler.
+func NewPostgresMigrator(db *sql.DB) Migrator {
+	return newSQLMigrator(db, postgresMigrations, pgAdvisoryLock)
+}
+
+func pgAdvisoryLock(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", advisoryLockKey)
+	return err
+}
+
+// postgresMigrations creates tables if missing, so databases created before migrations
+// were introduced are adopted as they are. The tweet tables are owned by the stream
+// listener service, which collects tweets, and are not created here.
+var postgresMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_stock_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS stock (
+				symbol VARCHAR(20) PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				is_active BOOLEAN,
+				total_count INTEGER,
+				updated_at TIMESTAMP
+			);`,
+	},
+	{
+		Version: 2,
+		Name:    "create_service_tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS stock_language_count (
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				language VARCHAR(10),
+				mention_count INTEGER,
+				updated_at TIMESTAMP,
+				PRIMARY KEY (symbol, language)
+			);
+
+			CREATE TABLE IF NOT EXISTS author_blocklist (
+				author_id VARCHAR(50) PRIMARY KEY,
+				reason VARCHAR(200),
+				created_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS stock_anomaly (
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				day DATE,
+				mention_count INTEGER,
+				mean DOUBLE PRECISION,
+				std_dev DOUBLE PRECISION,
+				deviation DOUBLE PRECISION,
+				detected_at TIMESTAMP,
+				PRIMARY KEY (symbol, day)
+			);
+
+			CREATE TABLE IF NOT EXISTS webhook_subscription (
+				id VARCHAR(50) PRIMARY KEY,
+				url VARCHAR(500) NOT NULL,
+				secret VARCHAR(100) NOT NULL,
+				events VARCHAR(200) NOT NULL,
+				created_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS webhook_delivery (
+				id VARCHAR(50) PRIMARY KEY,
+				subscription_id VARCHAR(50) REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+				event_id VARCHAR(50),
+				event VARCHAR(50),
+				attempt INTEGER,
+				status_code INTEGER,
+				error VARCHAR(500),
+				success BOOLEAN,
+				created_at TIMESTAMP
+			);`,
+		Down: `
+			DROP TABLE webhook_delivery;
+			DROP TABLE webhook_subscription;
+			DROP TABLE stock_anomaly;
+			DROP TABLE author_blocklist;
+			DROP TABLE stock_language_count;`,
+	},
+	{
+		Version: 3,
+		Name:    "create_search_index",
+		Up: `
+			ALTER TABLE stock
+				ADD COLUMN IF NOT EXISTS search_name VARCHAR(200),
+				ADD COLUMN IF NOT EXISTS search_version INTEGER;
+
+			CREATE TABLE IF NOT EXISTS stock_token (
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				token VARCHAR(100),
+				word_start BOOLEAN,
+				phonetic VARCHAR(100),
+				PRIMARY KEY (symbol, token)
+			);
+
+			CREATE INDEX IF NOT EXISTS stock_token_token_idx ON stock_token(token text_pattern_ops);
+			CREATE INDEX IF NOT EXISTS stock_token_phonetic_idx ON stock_token(phonetic);
+
+			CREATE TABLE IF NOT EXISTS stock_synonym (
+				term VARCHAR(100),
+				symbol VARCHAR(20),
+				updated_at TIMESTAMP,
+				PRIMARY KEY (term, symbol)
+			);`,
+		Down: `
+			DROP TABLE stock_synonym;
+			DROP TABLE stock_token;
+			ALTER TABLE stock
+				DROP COLUMN search_name,
+				DROP COLUMN search_version;`,
+	},
+	{
+		Version: 4,
+		Name:    "create_data_version",
+		Up: `
+			CREATE TABLE IF NOT EXISTS data_version (
+				name VARCHAR(50) PRIMARY KEY,
+				version INTEGER NOT NULL,
+				updated_at TIMESTAMP
+			);`,
+		Down: `
+			DROP TABLE data_version;`,
+	},
+	{
+		Version: 5,
+		Name:    "grant_service_role",
+		Up: `
+			DO $$
+			BEGIN
+				IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '` + serviceRole + `') THEN
+					GRANT INSERT, UPDATE, SELECT ON stock TO ` + serviceRole + `;
+					GRANT INSERT, DELETE, SELECT ON stock_language_count TO ` + serviceRole + `;
+					GRANT INSERT, UPDATE, DELETE, SELECT ON author_blocklist TO ` + serviceRole + `;
+					GRANT INSERT, UPDATE, SELECT ON stock_anomaly TO ` + serviceRole + `;
+					GRANT INSERT, DELETE, SELECT ON webhook_subscription TO ` + serviceRole + `;
+					GRANT INSERT, DELETE, SELECT ON webhook_delivery TO ` + serviceRole + `;
+					GRANT INSERT, DELETE, SELECT ON stock_token TO ` + serviceRole + `;
+					GRANT INSERT, DELETE, SELECT ON stock_synonym TO ` + serviceRole + `;
+					GRANT INSERT, UPDATE, SELECT ON data_version TO ` + serviceRole + `;
+					GRANT SELECT ON schema_migration TO ` + serviceRole + `;
+				END IF;
+			END
+			$$;`,
+		Down: `
+			DO $$
+			BEGIN
+				IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '` + serviceRole + `') THEN
+					REVOKE ALL ON stock, stock_language_count, author_blocklist, stock_anomaly, webhook_subscription,
+						webhook_delivery, stock_token, stock_synonym, data_version, schema_migration FROM ` + serviceRole + `;
+				END IF;
+			END
+			$$;`,
+	},
+}
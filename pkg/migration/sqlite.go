@@ -0,0 +1,152 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+)
+
+// NewSQLiteMigrator creates a Migrator applying the SQLite migrations. SQLite has no advisory
+// locks, instead each migration checks that it is still pending within its transaction, which
+// processes sharing the file serialize when it is opened with immediate transactions.
+func NewSQLiteMigrator(db *sql.DB) Migrator {
+	return newSQLMigrator(db, sqliteMigrations, noLock)
+}
+
+func noLock(ctx context.Context, tx *sql.Tx) error {
+	return nil
+}
+
+// sqliteMigrations the postgres migrations in SQLite syntax. SQLite cannot add columns only
+// if missing, so the search columns are created along with the stock table. Files created
+// before migrations were introduced already contain all tables and are adopted as they are.
+// Unlike on postgres the tweet tables are created as well, since a file has no other owner.
+var sqliteMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_stock_tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS stock (
+				symbol VARCHAR(20) PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				search_name VARCHAR(200),
+				search_version INTEGER,
+				is_active BOOLEAN,
+				total_count INTEGER,
+				updated_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS tweet (
+				id VARCHAR(50) PRIMARY KEY,
+				text VARCHAR(500),
+				language VARCHAR(10),
+				author_id VARCHAR(50),
+				author_followers INTEGER,
+				created_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS tweet_link (
+				id INTEGER PRIMARY KEY,
+				url VARCHAR(200),
+				tweet_id VARCHAR(50) REFERENCES tweet(id)
+			);
+
+			CREATE TABLE IF NOT EXISTS tweet_symbol (
+				id INTEGER PRIMARY KEY,
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				tweet_id VARCHAR(50) REFERENCES tweet(id)
+			);`,
+	},
+	{
+		Version: 2,
+		Name:    "create_service_tables",
+		Up: `
+			CREATE TABLE IF NOT EXISTS stock_language_count (
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				language VARCHAR(10),
+				mention_count INTEGER,
+				updated_at TIMESTAMP,
+				PRIMARY KEY (symbol, language)
+			);
+
+			CREATE TABLE IF NOT EXISTS author_blocklist (
+				author_id VARCHAR(50) PRIMARY KEY,
+				reason VARCHAR(200),
+				created_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS stock_anomaly (
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				day DATE,
+				mention_count INTEGER,
+				mean DOUBLE PRECISION,
+				std_dev DOUBLE PRECISION,
+				deviation DOUBLE PRECISION,
+				detected_at TIMESTAMP,
+				PRIMARY KEY (symbol, day)
+			);
+
+			CREATE TABLE IF NOT EXISTS webhook_subscription (
+				id VARCHAR(50) PRIMARY KEY,
+				url VARCHAR(500) NOT NULL,
+				secret VARCHAR(100) NOT NULL,
+				events VARCHAR(200) NOT NULL,
+				created_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS webhook_delivery (
+				id VARCHAR(50) PRIMARY KEY,
+				subscription_id VARCHAR(50) REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+				event_id VARCHAR(50),
+				event VARCHAR(50),
+				attempt INTEGER,
+				status_code INTEGER,
+				error VARCHAR(500),
+				success BOOLEAN,
+				created_at TIMESTAMP
+			);`,
+		Down: `
+			DROP TABLE webhook_delivery;
+			DROP TABLE webhook_subscription;
+			DROP TABLE stock_anomaly;
+			DROP TABLE author_blocklist;
+			DROP TABLE stock_language_count;`,
+	},
+	{
+		Version: 3,
+		Name:    "create_search_index",
+		Up: `
+			CREATE TABLE IF NOT EXISTS stock_token (
+				symbol VARCHAR(20) REFERENCES stock(symbol),
+				token VARCHAR(100),
+				word_start BOOLEAN,
+				phonetic VARCHAR(100),
+				PRIMARY KEY (symbol, token)
+			);
+
+			CREATE INDEX IF NOT EXISTS stock_token_token_idx ON stock_token(token);
+			CREATE INDEX IF NOT EXISTS stock_token_phonetic_idx ON stock_token(phonetic);
+
+			CREATE TABLE IF NOT EXISTS stock_synonym (
+				term VARCHAR(100),
+				symbol VARCHAR(20),
+				updated_at TIMESTAMP,
+				PRIMARY KEY (term, symbol)
+			);`,
+		Down: `
+			DROP TABLE stock_synonym;
+			DROP TABLE stock_token;
+			UPDATE stock SET search_name = NULL, search_version = NULL;`,
+	},
+	{
+		Version: 4,
+		Name:    "create_data_version",
+		Up: `
+			CREATE TABLE IF NOT EXISTS data_version (
+				name VARCHAR(50) PRIMARY KEY,
+				version INTEGER NOT NULL,
+				updated_at TIMESTAMP
+			);`,
+		Down: `
+			DROP TABLE data_version;`,
+	},
+}
@@ -0,0 +1,409 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateClient generates Go types for all schema components and a Client method
+// for every operation with a JSON or empty response. The generated code relies on a
+// hand written Client type in the same package providing the do method:
+//
+//	do(ctx context.Context, method, path string, query url.Values, header http.Header, body, result interface{}) error
+func GenerateClient(spec *Spec, pkgName, source string) ([]byte, error) {
+	g := &generator{
+		spec:    spec,
+		imports: map[string]bool{"context": true, "net/http": true},
+	}
+
+	var body bytes.Buffer
+	err := g.writeTypes(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.writeOperations(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by clientgen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\n", pkgName)
+	out.WriteString("import (\n")
+	for _, pkg := range g.sortedImports() {
+		fmt.Fprintf(&out, "\t%q\n", pkg)
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+type generator struct {
+	spec    *Spec
+	imports map[string]bool
+}
+
+func (g *generator) writeTypes(w *bytes.Buffer) error {
+	for _, name := range schemaNames(g.spec.Components.Schemas) {
+		schema := g.spec.Components.Schemas[name]
+		fmt.Fprintf(w, "\n%s\n", docComment(name, schema.Description))
+		if schema.Type != "object" || len(schema.Properties) == 0 {
+			fmt.Fprintf(w, "type %s %s\n", name, g.goType(schema))
+			continue
+		}
+
+		required := toSet(schema.Required)
+		fmt.Fprintf(w, "type %s struct {\n", name)
+		for _, property := range schemaNames(schema.Properties) {
+			s := schema.Properties[property]
+			fieldType := g.goType(s)
+			tag := property
+			if !required[property] {
+				tag += ",omitempty"
+				if s.Ref != "" {
+					fieldType = "*" + fieldType
+				}
+			}
+			if s.Description != "" {
+				fmt.Fprintf(w, "\t// %s\n", s.Description)
+			}
+			fmt.Fprintf(w, "\t%s %s `json:%q`\n", goName(property), fieldType, tag)
+		}
+		w.WriteString("}\n")
+	}
+
+	return nil
+}
+
+func (g *generator) writeOperations(w *bytes.Buffer) error {
+	for _, e := range g.spec.Endpoints() {
+		result, ok, err := g.resultSchema(e.Operation)
+		if err != nil {
+			return fmt.Errorf("%s %s: %s", e.Method, e.Path, err)
+		}
+		if !ok {
+			continue
+		}
+
+		err = g.writeOperation(w, e, result)
+		if err != nil {
+			return fmt.Errorf("%s %s: %s", e.Method, e.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) writeOperation(w *bytes.Buffer, e Endpoint, result *Schema) error {
+	op := e.Operation
+	name := goName(op.OperationID)
+
+	pathParams := make([]Parameter, 0)
+	params := make([]Parameter, 0)
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query", "header":
+			params = append(params, p)
+		default:
+			return fmt.Errorf("unsupported parameter location %s", p.In)
+		}
+	}
+
+	if len(params) > 0 {
+		fmt.Fprintf(w, "\n// %sParams optional and required parameters of %s.\n", name, name)
+		w.WriteString("// Optional parameters with a zero value are not sent.\n")
+		fmt.Fprintf(w, "type %sParams struct {\n", name)
+		for _, p := range params {
+			if p.Description != "" {
+				fmt.Fprintf(w, "\t// %s\n", p.Description)
+			}
+			fmt.Fprintf(w, "\t%s %s\n", goName(p.Name), g.goType(p.Schema))
+		}
+		w.WriteString("}\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, p := range pathParams {
+		args = append(args, argName(p.Name)+" string")
+	}
+	if len(params) > 0 {
+		args = append(args, "params "+name+"Params")
+	}
+
+	bodyType := ""
+	if op.RequestBody != nil {
+		media, ok := op.RequestBody.Content[JSONContent]
+		if !ok {
+			return fmt.Errorf("unsupported request body")
+		}
+		bodyType = g.goType(media.Schema)
+		if !op.RequestBody.Required {
+			bodyType = "*" + bodyType
+		}
+		args = append(args, "body "+bodyType)
+	}
+
+	resultType := ""
+	returns := "error"
+	if result != nil {
+		resultType = g.goType(result)
+		returns = "(" + resultType + ", error)"
+	}
+
+	summary := lowerFirst(op.Summary)
+	if summary == "" {
+		summary = "calls " + e.Method + " " + e.Path + "."
+	}
+	fmt.Fprintf(w, "\n// %s %s\n", name, summary)
+	if op.RequiredRole != "" {
+		fmt.Fprintf(w, "// Requires the %s role.\n", op.RequiredRole)
+	}
+	fmt.Fprintf(w, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), returns)
+
+	path := strconv.Quote(e.Path)
+	for _, p := range pathParams {
+		g.imports["net/url"] = true
+		placeholder := "{" + p.Name + "}"
+		path = strings.Replace(path, placeholder, `" + url.PathEscape(`+argName(p.Name)+`) + "`, 1)
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, `"" + `), ` + ""`)
+
+	query := "nil"
+	header := "nil"
+	for _, p := range params {
+		if p.In == "query" && query == "nil" {
+			g.imports["net/url"] = true
+			query = "query"
+			w.WriteString("\tquery := url.Values{}\n")
+		}
+		if p.In == "header" && header == "nil" {
+			header = "header"
+			w.WriteString("\theader := http.Header{}\n")
+		}
+	}
+	for _, p := range params {
+		err := g.writeParam(w, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	reqBody := "nil"
+	if bodyType != "" {
+		reqBody = "body"
+		if strings.HasPrefix(bodyType, "*") {
+			reqBody = "reqBody"
+			w.WriteString("\tvar reqBody interface{}\n\tif body != nil {\n\t\treqBody = body\n\t}\n")
+		}
+	}
+
+	call := fmt.Sprintf("c.do(ctx, http.Method%s, %s, %s, %s, %s", methodName(e.Method), path, query, header, reqBody)
+	if result == nil {
+		fmt.Fprintf(w, "\treturn %s, nil)\n}\n", call)
+		return nil
+	}
+
+	fmt.Fprintf(w, "\tvar result %s\n", resultType)
+	fmt.Fprintf(w, "\terr := %s, &result)\n", call)
+	w.WriteString("\treturn result, err\n}\n")
+	return nil
+}
+
+func (g *generator) writeParam(w *bytes.Buffer, p Parameter) error {
+	field := "params." + goName(p.Name)
+	set := "query.Add(" + strconv.Quote(p.Name) + ", %s)"
+	if p.In == "header" {
+		set = "header.Set(" + strconv.Quote(p.Name) + ", %s)"
+	}
+
+	var value, zeroCheck string
+	schema := p.Schema
+	switch schema.Type {
+	case "string":
+		value, zeroCheck = field, field+` != ""`
+	case "integer":
+		g.imports["strconv"] = true
+		value, zeroCheck = "strconv.FormatInt(int64("+field+"), 10)", field+" != 0"
+	case "number":
+		g.imports["strconv"] = true
+		value, zeroCheck = "strconv.FormatFloat("+field+", 'f', -1, 64)", field+" != 0"
+	case "boolean":
+		g.imports["strconv"] = true
+		value, zeroCheck = "strconv.FormatBool("+field+")", field
+	case "array":
+		if schema.Items == nil || schema.Items.Type != "string" {
+			return fmt.Errorf("unsupported array parameter %s", p.Name)
+		}
+		zeroCheck = "len(" + field + ") > 0"
+		if p.Explode != nil && !*p.Explode || p.In == "header" {
+			g.imports["strings"] = true
+			value = "strings.Join(" + field + `, ",")`
+		} else {
+			fmt.Fprintf(w, "\tfor _, v := range %s {\n\t\t%s\n\t}\n", field, fmt.Sprintf(set, "v"))
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported parameter type %s of %s", schema.Type, p.Name)
+	}
+
+	if p.Required {
+		fmt.Fprintf(w, "\t%s\n", fmt.Sprintf(set, value))
+		return nil
+	}
+
+	fmt.Fprintf(w, "\tif %s {\n\t\t%s\n\t}\n", zeroCheck, fmt.Sprintf(set, value))
+	return nil
+}
+
+// resultSchema returns the schema of the successful response of an operation.
+// Operations are skipped if the response is not JSON, e.g. streams and protocol upgrades.
+func (g *generator) resultSchema(op *Operation) (*Schema, bool, error) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+
+		r, err := g.spec.Response(op.Responses[code])
+		if err != nil {
+			return nil, false, err
+		}
+		if len(r.Content) == 0 {
+			return nil, true, nil
+		}
+
+		media, ok := r.Content[JSONContent]
+		if !ok {
+			return nil, false, nil
+		}
+		return media.Schema, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (g *generator) goType(s *Schema) string {
+	if s.Ref != "" {
+		return SchemaName(s.Ref)
+	}
+
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int64" {
+			return "int64"
+		}
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(s.Items)
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties)
+		}
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+func (g *generator) sortedImports() []string {
+	imports := make([]string, 0, len(g.imports))
+	for pkg := range g.imports {
+		imports = append(imports, pkg)
+	}
+	sort.Strings(imports)
+
+	return imports
+}
+
+// goName converts a JSON or parameter name to an exported Go identifier.
+func goName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	s := b.String()
+	for _, initialism := range []string{"Id", "Url"} {
+		if strings.HasSuffix(s, initialism) {
+			s = strings.TrimSuffix(s, initialism) + strings.ToUpper(initialism)
+		}
+	}
+
+	return s
+}
+
+func argName(name string) string {
+	s := goName(name)
+	if strings.ToUpper(s) == s {
+		return strings.ToLower(s)
+	}
+
+	return lowerFirst(s)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func methodName(method string) string {
+	return method[:1] + strings.ToLower(method[1:])
+}
+
+func docComment(name, description string) string {
+	if description == "" {
+		return "// " + name + " generated type."
+	}
+
+	return "// " + name + " " + lowerFirst(description)
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
+
+func schemaNames(schemas map[string]*Schema) []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,316 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Spec the subset of an OpenAPI 3 document used to describe the service.
+type Spec struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Components reusable parts of the spec.
+type Components struct {
+	SecuritySchemes map[string]interface{} `json:"securitySchemes,omitempty"`
+	Responses       map[string]*Response   `json:"responses,omitempty"`
+	Schemas         map[string]*Schema     `json:"schemas,omitempty"`
+}
+
+// Operation a single method on a path.
+// RequiredRole is the role a token must have to call the operation, if any.
+type Operation struct {
+	OperationID  string                `json:"operationId"`
+	Summary      string                `json:"summary,omitempty"`
+	Tags         []string              `json:"tags,omitempty"`
+	Parameters   []Parameter           `json:"parameters,omitempty"`
+	RequestBody  *RequestBody          `json:"requestBody,omitempty"`
+	Responses    map[string]*Response  `json:"responses"`
+	Security     []map[string][]string `json:"security,omitempty"`
+	RequiredRole string                `json:"x-required-role,omitempty"`
+}
+
+// Parameter a path, query or header parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response a response or a reference to a response component.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType content of a specific media type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema a JSON schema or a reference to a schema component.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Endpoint an operation together with its method and path.
+type Endpoint struct {
+	Method    string
+	Path      string
+	Operation *Operation
+}
+
+// JSONContent media type of JSON bodies.
+const JSONContent = "application/json"
+
+const (
+	schemaRefPrefix   = "#/components/schemas/"
+	responseRefPrefix = "#/components/responses/"
+)
+
+// LoadFile reads a spec from a JSON file.
+func LoadFile(filename string) (*Spec, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var spec Spec
+	err = json.NewDecoder(f).Decode(&spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %s", filename, err)
+	}
+
+	return &spec, nil
+}
+
+// Endpoints returns all operations ordered by path and method.
+func (s *Spec) Endpoints() []Endpoint {
+	endpoints := make([]Endpoint, 0)
+	for path, item := range s.Paths {
+		for method, op := range item {
+			endpoints = append(endpoints, Endpoint{
+				Method:    strings.ToUpper(method),
+				Path:      path,
+				Operation: op,
+			})
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	return endpoints
+}
+
+// Response resolves a response reference.
+func (s *Spec) Response(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+
+	resolved, ok := s.Components.Responses[strings.TrimPrefix(r.Ref, responseRefPrefix)]
+	if !ok || !strings.HasPrefix(r.Ref, responseRefPrefix) {
+		return nil, fmt.Errorf("unresolved response reference %s", r.Ref)
+	}
+
+	return resolved, nil
+}
+
+// SchemaName returns the name of a referenced schema component.
+func SchemaName(ref string) string {
+	return strings.TrimPrefix(ref, schemaRefPrefix)
+}
+
+// IsSecured returns true if the operation requires a bearer token.
+func (s *Spec) IsSecured(op *Operation) bool {
+	if op.Security != nil {
+		return len(op.Security) > 0
+	}
+
+	return len(s.Security) > 0
+}
+
+// Validate checks that all references resolve, that operation ids are unique
+// and that every path parameter is declared by the operations on the path.
+func (s *Spec) Validate() error {
+	operationIDs := make(map[string]bool)
+	for _, e := range s.Endpoints() {
+		op := e.Operation
+		if op.OperationID == "" {
+			return fmt.Errorf("%s %s: missing operationId", e.Method, e.Path)
+		}
+		if operationIDs[op.OperationID] {
+			return fmt.Errorf("%s %s: duplicate operationId %s", e.Method, e.Path, op.OperationID)
+		}
+		operationIDs[op.OperationID] = true
+
+		err := s.validateOperation(e)
+		if err != nil {
+			return fmt.Errorf("%s %s: %s", e.Method, e.Path, err)
+		}
+	}
+
+	for name, schema := range s.Components.Schemas {
+		err := s.validateSchema(schema)
+		if err != nil {
+			return fmt.Errorf("schema %s: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Spec) validateOperation(e Endpoint) error {
+	declared := make(map[string]bool)
+	for _, p := range e.Operation.Parameters {
+		if p.In == "path" {
+			if !p.Required {
+				return fmt.Errorf("path parameter %s must be required", p.Name)
+			}
+			declared[p.Name] = true
+		}
+		err := s.validateSchema(p.Schema)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range PathParams(e.Path) {
+		if !declared[name] {
+			return fmt.Errorf("undeclared path parameter %s", name)
+		}
+		delete(declared, name)
+	}
+	for name := range declared {
+		return fmt.Errorf("path parameter %s is not part of the path", name)
+	}
+
+	if e.Operation.RequestBody != nil {
+		for _, media := range e.Operation.RequestBody.Content {
+			err := s.validateSchema(media.Schema)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(e.Operation.Responses) == 0 {
+		return fmt.Errorf("no responses")
+	}
+	for _, r := range e.Operation.Responses {
+		resolved, err := s.Response(r)
+		if err != nil {
+			return err
+		}
+		for _, media := range resolved.Content {
+			err := s.validateSchema(media.Schema)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Spec) validateSchema(schema *Schema) error {
+	if schema == nil {
+		return fmt.Errorf("missing schema")
+	}
+
+	if schema.Ref != "" {
+		if _, ok := s.Components.Schemas[SchemaName(schema.Ref)]; !ok || !strings.HasPrefix(schema.Ref, schemaRefPrefix) {
+			return fmt.Errorf("unresolved schema reference %s", schema.Ref)
+		}
+		return nil
+	}
+
+	if schema.Items != nil {
+		err := s.validateSchema(schema.Items)
+		if err != nil {
+			return err
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		err := s.validateSchema(schema.AdditionalProperties)
+		if err != nil {
+			return err
+		}
+	}
+	for _, property := range schema.Properties {
+		err := s.validateSchema(property)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			return fmt.Errorf("required property %s is not defined", name)
+		}
+	}
+
+	return nil
+}
+
+// PathParams returns the names of the parameters in a path template.
+func PathParams(path string) []string {
+	params := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+
+	return params
+}
+
+// GinPath converts a path template to the gin route syntax, e.g. /stocks/{symbol} to /stocks/:symbol.
+func GinPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// AnomalyRepo handles storing and retrival of mention anomalies.
+type AnomalyRepo interface {
+	Save(a domain.Anomaly) (bool, error)
+	FindRecent(limit int) ([]domain.Anomaly, error)
+}
+
+// NewAnomalyRepo creates an AnomalyRepo using the default implementation.
+func NewAnomalyRepo(db *sql.DB) AnomalyRepo {
+	return &pgAnomalyRepo{
+		db: db,
+	}
+}
+
+// pgAnomalyRepo postgres implementation of AnomalyRepo.
+type pgAnomalyRepo struct {
+	db *sql.DB
+}
+
+const insertAnomalyQuery = `
+	INSERT INTO stock_anomaly(symbol, day, mention_count, mean, std_dev, deviation, detected_at)
+	VALUES($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (symbol, day) DO NOTHING`
+
+const updateAnomalyQuery = `
+	UPDATE stock_anomaly SET mention_count = $1, mean = $2, std_dev = $3, deviation = $4
+	WHERE symbol = $5 AND day = $6`
+
+// Save saves an anomaly, returning true if no anomaly of the same stock and day was stored before.
+// Otherwise the statistics of the earlier anomaly are replaced, keeping when it was first detected.
+func (pg *pgAnomalyRepo) Save(a domain.Anomaly) (bool, error) {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := saveAnomaly(tx, a)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return inserted, tx.Commit()
+}
+
+func saveAnomaly(tx *sql.Tx, a domain.Anomaly) (bool, error) {
+	res, err := tx.Exec(insertAnomalyQuery,
+		a.Symbol, a.Day, a.Count, a.Mean, a.StdDev, a.Deviation, a.DetectedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	inserted := affected > 0
+	if !inserted {
+		_, err = tx.Exec(updateAnomalyQuery, a.Count, a.Mean, a.StdDev, a.Deviation, a.Symbol, a.Day)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return inserted, bumpVersion(tx, AnomalyVersion)
+}
+
+const findRecentAnomaliesQuery = `
+	SELECT symbol, day, mention_count, mean, std_dev, deviation, detected_at 
+	FROM stock_anomaly
+	ORDER BY day DESC, deviation DESC
+	LIMIT $1`
+
+// FindRecent finds the most recent anomalies.
+func (pg *pgAnomalyRepo) FindRecent(limit int) ([]domain.Anomaly, error) {
+	rows, err := pg.db.Query(findRecentAnomaliesQuery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := make([]domain.Anomaly, 0)
+	for rows.Next() {
+		var a domain.Anomaly
+		err := rows.Scan(&a.Symbol, &a.Day, &a.Count, &a.Mean, &a.StdDev, &a.Deviation, &a.DetectedAt)
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, a)
+	}
+
+	return anomalies, rows.Err()
+}
+
+// MockAnomalyRepo mock implementation of AnomalyRepo.
+type MockAnomalyRepo struct {
+	SaveArgs        []domain.Anomaly
+	SaveNew         bool
+	SaveErr         error
+	SaveInvocations int
+
+	FindRecentArgLimit    int
+	FindRecentAnomalies   []domain.Anomaly
+	FindRecentErr         error
+	FindRecentInvocations int
+}
+
+// UnsetArgs sets all repo arguments to their default value.
+func (ar *MockAnomalyRepo) UnsetArgs() {
+	ar.SaveArgs = nil
+	ar.SaveInvocations = 0
+
+	ar.FindRecentArgLimit = 0
+	ar.FindRecentInvocations = 0
+}
+
+// Save mock implementation of saving an anomaly.
+func (ar *MockAnomalyRepo) Save(a domain.Anomaly) (bool, error) {
+	ar.SaveArgs = append(ar.SaveArgs, a)
+	ar.SaveInvocations++
+	return ar.SaveNew, ar.SaveErr
+}
+
+// FindRecent mock implementation of finding recent anomalies.
+func (ar *MockAnomalyRepo) FindRecent(limit int) ([]domain.Anomaly, error) {
+	ar.FindRecentArgLimit = limit
+	ar.FindRecentInvocations++
+	return ar.FindRecentAnomalies, ar.FindRecentErr
+}
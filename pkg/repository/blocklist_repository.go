@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// Blocklist errors.
+var (
+	ErrNoSuchAuthor = errors.New("no such author")
+)
+
+// BlocklistRepo handles storing and retrival of blocked authors.
+type BlocklistRepo interface {
+	Save(author domain.BlockedAuthor) error
+	Delete(authorID string) error
+	FindAll() ([]domain.BlockedAuthor, error)
+}
+
+// NewBlocklistRepo creates a BlocklistRepo using the default implementation.
+func NewBlocklistRepo(db *sql.DB) BlocklistRepo {
+	return &pgBlocklistRepo{
+		db: db,
+	}
+}
+
+// pgBlocklistRepo postgres implementation of BlocklistRepo.
+type pgBlocklistRepo struct {
+	db *sql.DB
+}
+
+const saveBlockedAuthorQuery = `
+	INSERT INTO author_blocklist(author_id, reason, created_at)
+	VALUES($1, $2, $3) ON CONFLICT (author_id)
+	DO UPDATE SET reason = $2`
+
+// Save adds an author to the blocklist or updates the reason if already blocked.
+func (pg *pgBlocklistRepo) Save(author domain.BlockedAuthor) error {
+	_, err := pg.db.Exec(saveBlockedAuthorQuery, author.AuthorID, author.Reason, author.CreatedAt)
+	return err
+}
+
+const deleteBlockedAuthorQuery = `
+	DELETE FROM author_blocklist WHERE author_id = $1`
+
+// Delete removes an author from the blocklist.
+func (pg *pgBlocklistRepo) Delete(authorID string) error {
+	res, err := pg.db.Exec(deleteBlockedAuthorQuery, authorID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNoSuchAuthor
+	}
+
+	return nil
+}
+
+const findBlockedAuthorsQuery = `
+	SELECT author_id, reason, created_at FROM author_blocklist
+	ORDER BY created_at DESC`
+
+// FindAll finds all blocked authors.
+func (pg *pgBlocklistRepo) FindAll() ([]domain.BlockedAuthor, error) {
+	rows, err := pg.db.Query(findBlockedAuthorsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := make([]domain.BlockedAuthor, 0)
+	for rows.Next() {
+		var a domain.BlockedAuthor
+		err := rows.Scan(&a.AuthorID, &a.Reason, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, a)
+	}
+
+	return authors, rows.Err()
+}
+
+// MockBlocklistRepo mock implementation of BlocklistRepo.
+type MockBlocklistRepo struct {
+	SaveArg         domain.BlockedAuthor
+	SaveErr         error
+	SaveInvocations int
+
+	DeleteArg         string
+	DeleteErr         error
+	DeleteInvocations int
+
+	FindAllAuthors     []domain.BlockedAuthor
+	FindAllErr         error
+	FindAllInvocations int
+}
+
+// UnsetArgs sets all repo arguments to their default value.
+func (br *MockBlocklistRepo) UnsetArgs() {
+	br.SaveArg = domain.BlockedAuthor{}
+	br.SaveInvocations = 0
+
+	br.DeleteArg = ""
+	br.DeleteInvocations = 0
+
+	br.FindAllInvocations = 0
+}
+
+// Save mock implementation of saving a blocked author.
+func (br *MockBlocklistRepo) Save(author domain.BlockedAuthor) error {
+	br.SaveArg = author
+	br.SaveInvocations++
+	return br.SaveErr
+}
+
+// Delete mock implementation of deleting a blocked author.
+func (br *MockBlocklistRepo) Delete(authorID string) error {
+	br.DeleteArg = authorID
+	br.DeleteInvocations++
+	return br.DeleteErr
+}
+
+// FindAll mock implementation of finding all blocked authors.
+func (br *MockBlocklistRepo) FindAll() ([]domain.BlockedAuthor, error) {
+	br.FindAllInvocations++
+	return br.FindAllAuthors, br.FindAllErr
+}
@@ -0,0 +1,510 @@
+package repository_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/migration"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, opts conformanceOptions) conformanceRepos {
+		store := repository.NewMemoryStore()
+		return conformanceRepos{
+			stocks:    repository.NewMemoryStockRepo(store, opts.stock),
+			counts:    repository.NewMemoryCountRepo(store, opts.count),
+			blocklist: repository.NewMemoryBlocklistRepo(store),
+			seeder:    memorySeeder{store: store},
+		}
+	})
+}
+
+// TestPostgresConformance runs the conformance suite against the database in TEST_DB_URL, which
+// must have been migrated with the migrate subcommand. All stock and tweet data in it is deleted.
+func TestPostgresConformance(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	runConformance(t, func(t *testing.T, opts conformanceOptions) conformanceRepos {
+		_, err := db.Exec(truncateTablesQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return conformanceRepos{
+			stocks:    repository.NewStockRepo(db, opts.stock),
+			counts:    repository.NewCountRepo(db, opts.count),
+			blocklist: repository.NewBlocklistRepo(db),
+			seeder:    &sqlSeeder{db: db},
+		}
+	})
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, opts conformanceOptions) conformanceRepos {
+		db, err := repository.OpenSQLite(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = migration.NewSQLiteMigrator(db).Up()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return conformanceRepos{
+			stocks:    repository.NewSQLiteStockRepo(db, opts.stock),
+			counts:    repository.NewSQLiteCountRepo(db, opts.count),
+			blocklist: repository.NewBlocklistRepo(db),
+			seeder:    &sqlSeeder{db: db},
+		}
+	})
+}
+
+type memorySeeder struct {
+	store *repository.MemoryStore
+}
+
+func (s memorySeeder) AddStock(stock domain.Stock, active bool) error {
+	s.store.AddStock(stock, active)
+	return nil
+}
+
+func (s memorySeeder) AddTweet(tweet domain.Tweet) error {
+	s.store.AddTweet(tweet)
+	return nil
+}
+
+const truncateTablesQuery = `
+	TRUNCATE stock, stock_token, stock_language_count, stock_synonym, stock_anomaly,
+		tweet, tweet_symbol, tweet_link, author_blocklist CASCADE`
+
+const insertStockQuery = `
+	INSERT INTO stock(symbol, name, is_active, total_count, updated_at) VALUES($1, $2, $3, $4, $5)`
+
+const insertLanguageCountQuery = `
+	INSERT INTO stock_language_count(symbol, language, mention_count, updated_at) VALUES($1, $2, $3, $4)`
+
+const insertTweetQuery = `
+	INSERT INTO tweet(id, text, language, author_id, author_followers, created_at)
+	VALUES($1, $2, $3, NULLIF($4, ''), $5, $6)`
+
+const insertTweetSymbolQuery = `
+	INSERT INTO tweet_symbol(id, symbol, tweet_id) VALUES($1, $2, $3)`
+
+// sqlSeeder inserts stocks and tweets like the services collecting them, leaving stocks
+// to be indexed by RefreshSearchIndex. Its statements work in both postgres and SQLite.
+type sqlSeeder struct {
+	db       *sql.DB
+	symbolID int
+}
+
+func (s *sqlSeeder) AddStock(stock domain.Stock, active bool) error {
+	updatedAt := time.Now().UTC()
+	_, err := s.db.Exec(insertStockQuery, stock.Symbol, stock.Name, active, stock.Count, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	for language, count := range stock.LanguageCounts {
+		_, err = s.db.Exec(insertLanguageCountQuery, stock.Symbol, language, count, updatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlSeeder) AddTweet(tweet domain.Tweet) error {
+	followers := sql.NullInt64{Int64: tweet.AuthorFollowers, Valid: tweet.AuthorFollowers >= 0}
+	createdAt := pq.NullTime{Time: tweet.CreatedAt, Valid: !tweet.CreatedAt.IsZero()}
+	_, err := s.db.Exec(insertTweetQuery, tweet.ID, tweet.Text, tweet.Language, tweet.AuthorID, followers, createdAt)
+	if err != nil {
+		return err
+	}
+
+	for _, symbol := range tweet.Symbols {
+		s.symbolID++
+		_, err = s.db.Exec(insertTweetSymbolQuery, s.symbolID, symbol, tweet.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seeder stores stocks and tweets the way the services collecting them do.
+type seeder interface {
+	AddStock(s domain.Stock, active bool) error
+	AddTweet(t domain.Tweet) error
+}
+
+// conformanceRepos repositories of an implementation, all backed by the same storage.
+type conformanceRepos struct {
+	stocks    repository.StockRepo
+	counts    repository.CountRepo
+	blocklist repository.BlocklistRepo
+	seeder    seeder
+}
+
+// conformanceOptions options of the repositories created for a test.
+type conformanceOptions struct {
+	stock repository.StockOptions
+	count repository.CountOptions
+}
+
+// reposFactory creates repositories backed by empty storage.
+type reposFactory func(t *testing.T, opts conformanceOptions) conformanceRepos
+
+// runConformance runs the conformance suite verifying that implementations of
+// repository.StockRepo and repository.CountRepo behave the same as subtests of t.
+// Stocks and tweets are seeded before each subtest, stocks being indexed
+// with RefreshSearchIndex like stocks added by other services.
+func runConformance(t *testing.T, newRepos reposFactory) {
+	t.Run("SearchOrdering", func(t *testing.T) { testSearchOrdering(t, newRepos) })
+	t.Run("Punctuation", func(t *testing.T) { testPunctuation(t, newRepos) })
+	t.Run("InactiveFiltering", func(t *testing.T) { testInactiveFiltering(t, newRepos) })
+	t.Run("Exclusion", func(t *testing.T) { testExclusion(t, newRepos) })
+	t.Run("Upsert", func(t *testing.T) { testUpsert(t, newRepos) })
+	t.Run("Counting", func(t *testing.T) { testCounting(t, newRepos) })
+}
+
+// seedStock an active or inactive stock to seed.
+type seedStock struct {
+	stock  domain.Stock
+	active bool
+}
+
+var searchStocks = []seedStock{
+	{stock: domain.Stock{Symbol: "BANK", Name: "Bank Corp", Count: 5, LanguageCounts: map[string]int64{"sv": 2}}, active: true},
+	{stock: domain.Stock{Symbol: "BNKY", Name: "Bankey Holdings", Count: 1, LanguageCounts: map[string]int64{"sv": 10}}, active: true},
+	{stock: domain.Stock{Symbol: "WB", Name: "West Bank Group", Count: 40}, active: true},
+	{stock: domain.Stock{Symbol: "SHB-A", Name: "Svenska Handelsbanken AB", Count: 50, LanguageCounts: map[string]int64{"sv": 30}}, active: true},
+	{stock: domain.Stock{Symbol: "BNQ", Name: "Banque Nationale", Count: 30}, active: true},
+	{stock: domain.Stock{Symbol: "NESN", Name: "Nestlé S.A.", Count: 20}, active: true},
+	{stock: domain.Stock{Symbol: "OLD", Name: "Olden Mining", Count: 100}, active: false},
+}
+
+func testSearchOrdering(t *testing.T, newRepos reposFactory) {
+	assert := assert.New(t)
+	repos := newRepos(t, conformanceOptions{
+		stock: repository.StockOptions{PhoneticMatching: true},
+		count: repository.DefaultCountOptions(),
+	})
+	seedStocks(t, repos, searchStocks)
+
+	stocks, err := repos.stocks.Search("bank", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"BANK", "BNKY", "WB", "SHB-A", "BNQ"}, symbols(stocks))
+
+	stocks, err = repos.stocks.Search("BANK", "", 2)
+	assert.NoError(err)
+	assert.Equal([]string{"BANK", "BNKY"}, symbols(stocks))
+
+	stocks, err = repos.stocks.Search("bank", "sv", 3)
+	assert.NoError(err)
+	assert.Equal([]string{"BNKY", "BANK", "WB"}, symbols(stocks))
+	assert.Equal(int64(10), stocks[0].Count)
+	assert.Equal(int64(0), stocks[2].Count)
+
+	stocks, err = repos.stocks.Search("group west", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"WB"}, symbols(stocks))
+
+	stocks, err = repos.stocks.Search("nestle", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"NESN"}, symbols(stocks))
+	assert.Equal("Nestlé S.A.", stocks[0].Name)
+
+	stocks, err = repos.stocks.Search("missing", "", 10)
+	assert.NoError(err)
+	assert.Equal(0, len(stocks))
+
+	repos = newRepos(t, conformanceOptions{
+		stock: repository.DefaultStockOptions(),
+		count: repository.DefaultCountOptions(),
+	})
+	seedStocks(t, repos, searchStocks)
+
+	stocks, err = repos.stocks.Search("bank", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"BANK", "BNKY", "WB", "SHB-A"}, symbols(stocks))
+}
+
+func testPunctuation(t *testing.T, newRepos reposFactory) {
+	assert := assert.New(t)
+	repos := newRepos(t, conformanceOptions{
+		stock: repository.DefaultStockOptions(),
+		count: repository.DefaultCountOptions(),
+	})
+	seedStocks(t, repos, append(searchStocks, seedStock{
+		stock:  domain.Stock{Symbol: "HM-B", Name: "H & M Hennes & Mauritz AB", Count: 10},
+		active: true,
+	}))
+
+	stocks, err := repos.stocks.Search("h&m", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"HM-B"}, symbols(stocks))
+
+	stocks, err = repos.stocks.Search("s%ka", "", 10)
+	assert.NoError(err)
+	assert.Equal(0, len(stocks))
+
+	stocks, err = repos.stocks.Search("ban_ue", "", 10)
+	assert.NoError(err)
+	assert.Equal(0, len(stocks))
+
+	stocks, err = repos.stocks.Search("west-bank", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"WB"}, symbols(stocks))
+}
+
+func testInactiveFiltering(t *testing.T, newRepos reposFactory) {
+	assert := assert.New(t)
+	repos := newRepos(t, defaultConformanceOptions())
+	seedStocks(t, repos, searchStocks)
+
+	stocks, err := repos.stocks.Search("olden", "", 10)
+	assert.NoError(err)
+	assert.Equal(0, len(stocks))
+
+	stocks, err = repos.stocks.FindMostCommon(nil, "", 10)
+	assert.NoError(err)
+	assert.NotContains(symbols(stocks), "OLD")
+
+	terms, err := repos.stocks.FindVocabulary(3, 10)
+	assert.NoError(err)
+	vocabulary := make(map[string]domain.Term)
+	for _, term := range terms {
+		vocabulary[term.Text] = term
+	}
+	assert.NotContains(vocabulary, "old")
+	assert.NotContains(vocabulary, "olden")
+	assert.NotContains(vocabulary, "handelsbanken")
+	assert.Equal(int64(50), vocabulary["bank"].Count)
+	assert.True(vocabulary["bank"].IsSymbol)
+	assert.False(vocabulary["west"].IsSymbol)
+
+	old, err := repos.stocks.Find("OLD")
+	assert.NoError(err)
+	assert.Equal("Olden Mining", old.Name)
+	assert.Equal(int64(100), old.Count)
+
+	_, err = repos.stocks.Find("MISSING")
+	assert.Equal(repository.ErrNoSuchStock, err)
+}
+
+func testExclusion(t *testing.T, newRepos reposFactory) {
+	assert := assert.New(t)
+	repos := newRepos(t, defaultConformanceOptions())
+	seedStocks(t, repos, searchStocks)
+
+	stocks, err := repos.stocks.FindMostCommon(nil, "", 3)
+	assert.NoError(err)
+	assert.Equal([]string{"SHB-A", "WB", "BNQ"}, symbols(stocks))
+
+	stocks, err = repos.stocks.FindMostCommon([]string{"SHB-A", "BNQ", "MISSING"}, "", 3)
+	assert.NoError(err)
+	assert.Equal([]string{"WB", "NESN", "BANK"}, symbols(stocks))
+
+	stocks, err = repos.stocks.FindMostCommon([]string{"shb-a"}, "", 1)
+	assert.NoError(err)
+	assert.Equal([]string{"SHB-A"}, symbols(stocks))
+
+	stocks, err = repos.stocks.FindMostCommon([]string{"SHB-A"}, "sv", 3)
+	assert.NoError(err)
+	assert.Equal([]string{"BNKY", "BANK", "WB"}, symbols(stocks))
+	assert.Equal(int64(10), stocks[0].Count)
+}
+
+func testUpsert(t *testing.T, newRepos reposFactory) {
+	assert := assert.New(t)
+	repos := newRepos(t, defaultConformanceOptions())
+	start := time.Now().UTC().Add(-time.Second)
+
+	lastUpdated, err := repos.stocks.LastUpdated()
+	assert.NoError(err)
+	assert.True(lastUpdated.IsZero())
+
+	err = repos.stocks.Save(domain.Stock{Symbol: "ACME", Name: "Acme Industries", Count: 3, LanguageCounts: map[string]int64{"en": 3}})
+	assert.NoError(err)
+
+	acme, err := repos.stocks.Find("ACME")
+	assert.NoError(err)
+	assert.Equal(domain.Stock{Symbol: "ACME", Name: "Acme Industries", Count: 3}, acme)
+
+	stocks, err := repos.stocks.Search("industries", "en", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"ACME"}, symbols(stocks))
+	assert.Equal(int64(3), stocks[0].Count)
+
+	err = repos.stocks.Save(domain.Stock{Symbol: "ACME", Name: "Renamed", Count: 7, LanguageCounts: map[string]int64{"sv": 7}})
+	assert.NoError(err)
+
+	acme, err = repos.stocks.Find("ACME")
+	assert.NoError(err)
+	assert.Equal(domain.Stock{Symbol: "ACME", Name: "Acme Industries", Count: 7}, acme)
+
+	stocks, err = repos.stocks.Search("acme", "en", 10)
+	assert.NoError(err)
+	assert.Equal(int64(0), stocks[0].Count)
+	stocks, err = repos.stocks.Search("acme", "sv", 10)
+	assert.NoError(err)
+	assert.Equal(int64(7), stocks[0].Count)
+
+	lastUpdated, err = repos.stocks.LastUpdated()
+	assert.NoError(err)
+	assert.False(lastUpdated.Before(start))
+}
+
+func testCounting(t *testing.T, newRepos reposFactory) {
+	assert := assert.New(t)
+	now := time.Now().UTC()
+	stocks := []seedStock{
+		{stock: domain.Stock{Symbol: "AAPL", Name: "Apple Inc."}, active: true},
+		{stock: domain.Stock{Symbol: "AMD", Name: "Advanced Micro Devices, Inc."}, active: true},
+		{stock: domain.Stock{Symbol: "MSFT", Name: "Microsoft Corporation", Count: 9, LanguageCounts: map[string]int64{"en": 9}}, active: true},
+	}
+	tweets := []domain.Tweet{
+		{ID: "1", AuthorID: "a", AuthorFollowers: 100, Language: "en", Text: "$AAPL $AMD", Symbols: []string{"AAPL", "AMD"}, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "2", AuthorID: "b", AuthorFollowers: -1, Language: "sv", Text: "Köper $AAPL", Symbols: []string{"AAPL"}, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "3", AuthorID: "a", AuthorFollowers: 100, Language: "en", Text: "$AAPL again", Symbols: []string{"AAPL"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "4", AuthorID: "spammer", AuthorFollowers: 5, Language: "en", Text: "$AAPL free money", Symbols: []string{"AAPL"}, CreatedAt: now.Add(-30 * time.Minute)},
+		{ID: "5", AuthorID: "c", AuthorFollowers: 10, Language: "en", Text: "$AAPL last week", Symbols: []string{"AAPL"}, CreatedAt: now.Add(-72 * time.Hour)},
+	}
+	seed := func(opts conformanceOptions) conformanceRepos {
+		repos := newRepos(t, opts)
+		seedStocks(t, repos, stocks)
+		for _, tweet := range tweets {
+			assert.NoError(repos.seeder.AddTweet(tweet))
+		}
+		err := repos.blocklist.Save(domain.BlockedAuthor{AuthorID: "spammer", Reason: "spam", CreatedAt: now})
+		assert.NoError(err)
+		return repos
+	}
+
+	repos := seed(defaultConformanceOptions())
+	counted, report, err := repos.counts.CountAll()
+	assert.NoError(err)
+	assert.Equal([]string{"AAPL", "AMD", "MSFT"}, symbols(counted))
+	assert.Equal(int64(4), counted[0].Count)
+	assert.Equal(int64(3), counted[0].LanguageCounts["en"])
+	assert.Equal(int64(1), counted[0].LanguageCounts["sv"])
+	assert.Equal(int64(1), counted[1].Count)
+	assert.Equal(int64(0), counted[2].Count)
+	assert.Equal(0, len(counted[2].LanguageCounts))
+	assert.Equal(int64(5), report.CountedMentions)
+	assert.Equal(int64(1), report.RemovedMentions[repository.RuleBlockedAuthor])
+
+	amd, _, err := repos.counts.CountOne("AMD")
+	assert.NoError(err)
+	assert.Equal(int64(1), amd.Count)
+
+	// Existing stocks without mentions are told apart from unknown ones.
+	_, _, err = repos.counts.CountOne("MSFT")
+	assert.Equal(repository.ErrNoMentions, err)
+	_, _, err = repos.counts.CountOne("MISSING")
+	assert.Equal(repository.ErrNoSuchStock, err)
+
+	err = repos.stocks.Save(counted[2])
+	assert.NoError(err)
+	ranked, err := repos.stocks.Search("microsoft", "en", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"MSFT"}, symbols(ranked))
+	assert.Equal(int64(0), ranked[0].Count)
+
+	daily, err := repos.counts.CountDaily(now.Add(-48 * time.Hour))
+	assert.NoError(err)
+	assert.Equal(map[string]int64{"AAPL": 3, "AMD": 1}, dailyTotals(daily))
+
+	daily, err = repos.counts.CountStockDaily("AAPL", now.Add(-96*time.Hour))
+	assert.NoError(err)
+	assert.Equal(map[string]int64{"AAPL": 4}, dailyTotals(daily))
+
+	repos = seed(conformanceOptions{
+		stock: repository.DefaultStockOptions(),
+		count: repository.CountOptions{Mode: repository.CountDistinctAuthors},
+	})
+	counted, _, err = repos.counts.CountAll()
+	assert.NoError(err)
+	assert.Equal([]string{"AAPL", "AMD", "MSFT"}, symbols(counted))
+	assert.Equal(int64(3), counted[0].Count)
+	assert.Equal(int64(0), counted[2].Count)
+
+	repos = seed(conformanceOptions{
+		stock: repository.DefaultStockOptions(),
+		count: repository.CountOptions{Mode: repository.CountAllMentions, Period: 24 * time.Hour, Spam: repository.SpamFilter{MinFollowers: 50}},
+	})
+	aapl, report, err := repos.counts.CountOne("AAPL")
+	assert.NoError(err)
+	assert.Equal(int64(3), aapl.Count)
+	assert.Equal(int64(3), report.CountedMentions)
+	assert.Equal(int64(1), report.RemovedMentions[repository.RuleBlockedAuthor])
+
+	repos = seed(conformanceOptions{
+		stock: repository.DefaultStockOptions(),
+		count: repository.CountOptions{Mode: repository.CountAllMentions, Period: 24 * time.Hour},
+	})
+	aapl, report, err = repos.counts.CountOne("AAPL")
+	assert.NoError(err)
+	assert.Equal(int64(3), aapl.Count)
+	assert.Equal(map[string]int64{"en": 2, "sv": 1}, aapl.LanguageCounts)
+	assert.Equal(int64(3), report.CountedMentions)
+	assert.Equal(int64(1), report.RemovedMentions[repository.RuleBlockedAuthor])
+}
+
+func defaultConformanceOptions() conformanceOptions {
+	return conformanceOptions{
+		stock: repository.DefaultStockOptions(),
+		count: repository.DefaultCountOptions(),
+	}
+}
+
+func seedStocks(t *testing.T, repos conformanceRepos, stocks []seedStock) {
+	for _, s := range stocks {
+		err := repos.seeder.AddStock(s.stock, s.active)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := repos.stocks.RefreshSearchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symbols(stocks []domain.Stock) []string {
+	symbols := make([]string, 0, len(stocks))
+	for _, s := range stocks {
+		symbols = append(symbols, s.Symbol)
+	}
+
+	return symbols
+}
+
+func dailyTotals(counts []domain.DailyCount) map[string]int64 {
+	totals := make(map[string]int64)
+	for _, c := range counts {
+		totals[c.Symbol] += c.Count
+	}
+
+	return totals
+}
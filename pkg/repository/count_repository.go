@@ -3,89 +3,237 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/mimir-news/stock-search/pkg/domain"
 )
 
 // Common errors.
 var (
 	ErrNoSuchStock = errors.New("no such stock")
+	ErrNoMentions  = errors.New("no mentions of stock")
 )
 
 // CountRepo handles volume counting of stocks.
 type CountRepo interface {
-	CountOne(symbol string) (domain.Stock, error)
-	CountAll() ([]domain.Stock, error)
+	CountOne(symbol string) (domain.Stock, domain.FilterReport, error)
+	CountAll() ([]domain.Stock, domain.FilterReport, error)
+	CountDaily(since time.Time) ([]domain.DailyCount, error)
+	CountStockDaily(symbol string, since time.Time) ([]domain.DailyCount, error)
 }
 
 // NewCountRepo returns a defult implementation of CountRepo.
-func NewCountRepo(db *sql.DB) CountRepo {
+func NewCountRepo(db *sql.DB, opts CountOptions) CountRepo {
 	return &pgCountRepo{
-		db: db,
+		db:   db,
+		opts: opts,
 	}
 }
 
 type pgCountRepo struct {
-	db *sql.DB
-}
-
-const countStockQuery = `
-	SELECT symbol, COUNT(*) FROM tweet_symbol
-	WHERE symbol = $1
-	GROUP BY symbol`
-
-// CountOne counts the total tweet volume of a single stock.
-func (cr *pgCountRepo) CountOne(symbol string) (domain.Stock, error) {
-	var s domain.Stock
-	err := cr.db.QueryRow(countStockQuery, symbol).Scan(&s.Symbol, &s.Count)
-	if err == sql.ErrNoRows {
-		return domain.Stock{}, ErrNoSuchStock
-	} else if err != nil {
-		return domain.Stock{}, err
+	db   *sql.DB
+	opts CountOptions
+}
+
+// countMentionsQuery counts the mentions of every stock per language and whether their author is
+// blocked, or of a single stock if a symbol is given. Mentions of tweets which are missing are counted
+// with an unknown language and creation time, so they are only counted if the period is unbounded.
+// Stocks without mentions are counted with a zero count.
+const countMentionsQuery = `
+	WITH mentions AS (
+		SELECT ts.symbol, t.language, b.author_id IS NOT NULL AS blocked FROM tweet_symbol ts
+		LEFT JOIN tweet t ON t.id = ts.tweet_id
+		LEFT JOIN author_blocklist b ON b.author_id = t.author_id
+		WHERE ($1::TIMESTAMP IS NULL OR t.created_at >= $1)
+		AND ($2::VARCHAR IS NULL OR ts.symbol = $2)
+	)
+	SELECT s.symbol, COALESCE(m.language, ''), COALESCE(m.blocked, FALSE), COUNT(m.symbol)
+	FROM stock s
+	LEFT JOIN mentions m ON m.symbol = s.symbol
+	WHERE ($2::VARCHAR IS NULL OR s.symbol = $2)
+	GROUP BY s.symbol, m.language, m.blocked`
+
+// findMentionsQuery selects the mentions of every stock, or of a single stock if a symbol is given,
+// along with the properties filtered on in order of creation. Stocks without mentions are selected
+// with a null tweet id.
+const findMentionsQuery = `
+	WITH cashtags AS (
+		SELECT tweet_id, COUNT(*) AS cashtag_count FROM tweet_symbol
+		GROUP BY tweet_id
+	), daily_posts AS (
+		SELECT author_id, DATE(created_at) AS day, COUNT(*) AS post_count FROM tweet
+		WHERE ($1::TIMESTAMP IS NULL OR created_at >= $1)
+		GROUP BY author_id, DATE(created_at)
+	), mentions AS (
+		SELECT ts.symbol, ts.tweet_id, t.author_id, t.author_followers, d.post_count,
+			b.author_id IS NOT NULL AS blocked, t.language, t.text, c.cashtag_count, t.created_at
+		FROM tweet_symbol ts
+		LEFT JOIN tweet t ON t.id = ts.tweet_id
+		LEFT JOIN cashtags c ON c.tweet_id = ts.tweet_id
+		LEFT JOIN daily_posts d ON d.author_id = t.author_id AND d.day = DATE(t.created_at)
+		LEFT JOIN author_blocklist b ON b.author_id = t.author_id
+		WHERE ($1::TIMESTAMP IS NULL OR t.created_at >= $1)
+		AND ($2::VARCHAR IS NULL OR ts.symbol = $2)
+	)
+	SELECT s.symbol, m.tweet_id, COALESCE(m.author_id, ''), COALESCE(m.author_followers, -1),
+		COALESCE(m.post_count, 0), COALESCE(m.blocked, FALSE), COALESCE(m.language, ''),
+		COALESCE(m.text, ''), COALESCE(m.cashtag_count, 0), m.created_at
+	FROM stock s
+	LEFT JOIN mentions m ON m.symbol = s.symbol
+	WHERE ($2::VARCHAR IS NULL OR s.symbol = $2)
+	ORDER BY m.created_at`
+
+// CountOne counts the total and per language tweet volume of a single stock.
+func (cr *pgCountRepo) CountOne(symbol string) (domain.Stock, domain.FilterReport, error) {
+	counter, err := cr.countStocks(symbolArg(symbol))
+	if err != nil {
+		return domain.Stock{}, domain.NewFilterReport(), err
 	}
 
-	return s, nil
+	s, err := counter.one()
+	return s, counter.filterReport(), err
 }
 
-const countStocksQuery = `
-	SELECT symbol, COUNT(*) FROM tweet_symbol
-	GROUP BY symbol`
+// CountAll counts the total and per language tweet volume of all stocks in the system,
+// including stocks without mentions.
+func (cr *pgCountRepo) CountAll() ([]domain.Stock, domain.FilterReport, error) {
+	counter, err := cr.countStocks(sql.NullString{})
+	if err != nil {
+		return nil, domain.NewFilterReport(), err
+	}
 
-// CountAll counts the total tweet volume of all stocks in the system.
-func (cr *pgCountRepo) CountAll() ([]domain.Stock, error) {
-	rows, err := cr.db.Query(countStocksQuery)
+	return counter.result(), counter.filterReport(), nil
+}
+
+// CountDaily counts the mentions per stock and day from the given point in time.
+func (cr *pgCountRepo) CountDaily(since time.Time) ([]domain.DailyCount, error) {
+	counter, err := cr.countMentions(pq.NullTime{Time: since, Valid: true}, sql.NullString{})
 	if err != nil {
 		return nil, err
 	}
 
-	return mapRowsToCountedStocks(rows)
+	return counter.dailyCounts(), nil
 }
 
-func mapRowsToCountedStocks(rows *sql.Rows) ([]domain.Stock, error) {
-	stocks := make([]domain.Stock, 0)
+// CountStockDaily counts the mentions per day of a single stock from the given point in time.
+func (cr *pgCountRepo) CountStockDaily(symbol string, since time.Time) ([]domain.DailyCount, error) {
+	counter, err := cr.countMentions(pq.NullTime{Time: since, Valid: true}, symbolArg(symbol))
+	if err != nil {
+		return nil, err
+	}
 
+	return counter.dailyCounts(), nil
+}
+
+// countStocks counts mentions during the counting period, only selecting every
+// mention if they must be deduplicated or filtered as spam.
+func (cr *pgCountRepo) countStocks(symbol sql.NullString) (*mentionCounter, error) {
+	if cr.opts.needsMentions() {
+		return cr.countMentions(cr.sinceArg(), symbol)
+	}
+
+	return groupMentions(cr.db, countMentionsQuery, cr.opts, cr.sinceArg(), symbol)
+}
+
+func (cr *pgCountRepo) countMentions(since pq.NullTime, symbol sql.NullString) (*mentionCounter, error) {
+	return selectMentions(cr.db, findMentionsQuery, cr.opts, since, symbol)
+}
+
+// groupMentions counts mentions grouped by a query selecting the
+// symbol, language, whether the author is blocked and number of mentions.
+func groupMentions(db *sql.DB, query string, opts CountOptions, args ...interface{}) (*mentionCounter, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counter := newMentionCounter(opts)
 	for rows.Next() {
-		var s domain.Stock
-		err := rows.Scan(&s.Symbol, &s.Count)
+		var symbol, language string
+		var blocked bool
+		var count int64
+		err := rows.Scan(&symbol, &language, &blocked, &count)
 		if err != nil {
 			return nil, err
 		}
-		stocks = append(stocks, s)
+		counter.addCount(symbol, language, blocked, count)
 	}
 
-	return stocks, nil
+	return counter, rows.Err()
+}
+
+// selectMentions counts the mentions selected by a query one by one. Stocks
+// selected without a tweet id are counted without mentions.
+func selectMentions(db *sql.DB, query string, opts CountOptions, args ...interface{}) (*mentionCounter, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counter := newMentionCounter(opts)
+	for rows.Next() {
+		var m domain.Mention
+		var tweetID sql.NullString
+		var createdAt pq.NullTime
+		err := rows.Scan(
+			&m.Symbol, &tweetID, &m.AuthorID, &m.AuthorFollowers, &m.AuthorDailyPosts,
+			&m.AuthorBlocked, &m.Language, &m.Text, &m.CashtagCount, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if !tweetID.Valid {
+			counter.addStock(m.Symbol)
+			continue
+		}
+		m.TweetID = tweetID.String
+		m.CreatedAt = createdAt.Time
+		counter.add(m)
+	}
+
+	return counter, rows.Err()
+}
+
+// symbolArg returns a symbol as a query argument.
+func symbolArg(symbol string) sql.NullString {
+	return sql.NullString{String: symbol, Valid: true}
+}
+
+// sinceArg returns the lower time bound of the counting period as a query argument.
+func (cr *pgCountRepo) sinceArg() pq.NullTime {
+	since := cr.opts.since(time.Now().UTC())
+	return pq.NullTime{
+		Time:  since,
+		Valid: !since.IsZero(),
+	}
 }
 
 // MockCountRepo mock implementation of CountRepo.
 type MockCountRepo struct {
 	CountOneArg         string
 	CountOneStock       domain.Stock
+	CountOneReport      domain.FilterReport
 	CountOneErr         error
 	CountOneInvocations int
 
 	CountAllStocks      []domain.Stock
+	CountAllReport      domain.FilterReport
 	CountAllErr         error
 	CountAllInvocations int
+
+	CountDailyArgSince    time.Time
+	CountDailyCounts      []domain.DailyCount
+	CountDailyErr         error
+	CountDailyInvocations int
+
+	CountStockDailyArgSymbol   string
+	CountStockDailyArgSince    time.Time
+	CountStockDailyCounts      []domain.DailyCount
+	CountStockDailyErr         error
+	CountStockDailyInvocations int
 }
 
 // UnsetArgs sets all repo arguments to their default value.
@@ -93,17 +241,37 @@ func (cr *MockCountRepo) UnsetArgs() {
 	cr.CountOneArg = ""
 	cr.CountOneInvocations = 0
 	cr.CountAllInvocations = 0
+	cr.CountDailyArgSince = time.Time{}
+	cr.CountDailyInvocations = 0
+	cr.CountStockDailyArgSymbol = ""
+	cr.CountStockDailyArgSince = time.Time{}
+	cr.CountStockDailyInvocations = 0
 }
 
 // CountOne mock CountOne implementation.
-func (cr *MockCountRepo) CountOne(symbol string) (domain.Stock, error) {
+func (cr *MockCountRepo) CountOne(symbol string) (domain.Stock, domain.FilterReport, error) {
 	cr.CountOneArg = symbol
 	cr.CountOneInvocations++
-	return cr.CountOneStock, cr.CountOneErr
+	return cr.CountOneStock, cr.CountOneReport, cr.CountOneErr
 }
 
 // CountAll mock CountAll implementation.
-func (cr *MockCountRepo) CountAll() ([]domain.Stock, error) {
+func (cr *MockCountRepo) CountAll() ([]domain.Stock, domain.FilterReport, error) {
 	cr.CountAllInvocations++
-	return cr.CountAllStocks, cr.CountAllErr
+	return cr.CountAllStocks, cr.CountAllReport, cr.CountAllErr
+}
+
+// CountDaily mock CountDaily implementation.
+func (cr *MockCountRepo) CountDaily(since time.Time) ([]domain.DailyCount, error) {
+	cr.CountDailyArgSince = since
+	cr.CountDailyInvocations++
+	return cr.CountDailyCounts, cr.CountDailyErr
+}
+
+// CountStockDaily mock CountStockDaily implementation.
+func (cr *MockCountRepo) CountStockDaily(symbol string, since time.Time) ([]domain.DailyCount, error) {
+	cr.CountStockDailyArgSymbol = symbol
+	cr.CountStockDailyArgSince = since
+	cr.CountStockDailyInvocations++
+	return cr.CountStockDailyCounts, cr.CountStockDailyErr
 }
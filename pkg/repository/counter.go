@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// CountMode determines what is counted as a mention of a stock.
+type CountMode string
+
+// Valid count modes.
+const (
+	// CountAllMentions counts every mention of a stock.
+	CountAllMentions CountMode = "all"
+	// CountDistinctTweets counts each tweet once and treats near-duplicate texts as one tweet.
+	CountDistinctTweets CountMode = "distinct_tweets"
+	// CountDistinctAuthors counts each author once per stock.
+	CountDistinctAuthors CountMode = "distinct_authors"
+)
+
+// Names of the rules that mentions can be removed by.
+const (
+	RuleBlockedAuthor = "blocked_author"
+	RuleMinFollowers  = "min_followers"
+	RuleMaxDailyPosts = "max_daily_posts"
+	RuleMaxCashtags   = "max_cashtags"
+)
+
+// SpamFilter heuristics for excluding mentions made by spam and bot authors.
+// A zero value disables the corresponding rule.
+type SpamFilter struct {
+	MinFollowers  int64
+	MaxDailyPosts int64
+	MaxCashtags   int64
+}
+
+// CountOptions configuration of how stock mentions are counted.
+type CountOptions struct {
+	Mode   CountMode
+	Period time.Duration
+	Spam   SpamFilter
+}
+
+// DefaultCountOptions returns options that count all mentions regardless of age.
+func DefaultCountOptions() CountOptions {
+	return CountOptions{
+		Mode:   CountAllMentions,
+		Period: 0,
+		Spam:   SpamFilter{},
+	}
+}
+
+// ParseCountMode parses a count mode, returning false if the mode is unknown.
+func ParseCountMode(mode string) (CountMode, bool) {
+	switch CountMode(mode) {
+	case CountAllMentions, CountDistinctTweets, CountDistinctAuthors:
+		return CountMode(mode), true
+	default:
+		return "", false
+	}
+}
+
+// needsMentions returns true if mentions must be examined one by one to be deduplicated or filtered
+// as spam. Otherwise mentions are counted by grouping them in the database, only removing blocked authors.
+func (o CountOptions) needsMentions() bool {
+	dedup := o.Mode == CountDistinctTweets || o.Mode == CountDistinctAuthors
+	return dedup || o.Spam != (SpamFilter{})
+}
+
+// since returns the earliest point in time which mentions are counted from.
+// The zero time is returned if the period is unbounded.
+func (o CountOptions) since(now time.Time) time.Time {
+	if o.Period <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(-o.Period)
+}
+
+// mentionCounter aggregates mentions into stock counts according to count options.
+type mentionCounter struct {
+	mode   CountMode
+	spam   SpamFilter
+	stocks map[string]*domain.Stock
+	seen   map[string]bool
+	daily  map[string]map[time.Time]int64
+	report domain.FilterReport
+}
+
+func newMentionCounter(opts CountOptions) *mentionCounter {
+	return &mentionCounter{
+		mode:   opts.Mode,
+		spam:   opts.Spam,
+		stocks: make(map[string]*domain.Stock),
+		seen:   make(map[string]bool),
+		daily:  make(map[string]map[time.Time]int64),
+		report: domain.NewFilterReport(),
+	}
+}
+
+// addStock adds a stock which is counted even if it has no mentions.
+func (c *mentionCounter) addStock(symbol string) *domain.Stock {
+	s, ok := c.stocks[symbol]
+	if !ok {
+		s = &domain.Stock{
+			Symbol:         symbol,
+			LanguageCounts: make(map[string]int64),
+		}
+		c.stocks[symbol] = s
+	}
+
+	return s
+}
+
+func (c *mentionCounter) add(m domain.Mention) {
+	s := c.addStock(m.Symbol)
+	rule := c.violatedRule(m)
+	if rule != "" {
+		c.report.RemovedMentions[rule]++
+		return
+	}
+
+	key, ok := c.dedupKey(m)
+	if ok {
+		if c.seen[key] {
+			return
+		}
+		c.seen[key] = true
+	}
+
+	s.Count++
+	c.report.CountedMentions++
+	if m.Language != "" {
+		s.LanguageCounts[m.Language]++
+	}
+	c.addDaily(m)
+}
+
+// addCount adds a number of mentions of a stock in a language grouped in the database,
+// which are removed if their author is blocked.
+func (c *mentionCounter) addCount(symbol, language string, blocked bool, count int64) {
+	s := c.addStock(symbol)
+	if blocked {
+		c.report.RemovedMentions[RuleBlockedAuthor] += count
+		return
+	}
+
+	s.Count += count
+	c.report.CountedMentions += count
+	if language != "" {
+		s.LanguageCounts[language] += count
+	}
+}
+
+func (c *mentionCounter) addDaily(m domain.Mention) {
+	if m.CreatedAt.IsZero() {
+		return
+	}
+
+	days, ok := c.daily[m.Symbol]
+	if !ok {
+		days = make(map[time.Time]int64)
+		c.daily[m.Symbol] = days
+	}
+	days[truncateToDay(m.CreatedAt)]++
+}
+
+// violatedRule returns the name of the first filter rule that
+// a mention violates or an empty string if the mention should be counted.
+func (c *mentionCounter) violatedRule(m domain.Mention) string {
+	switch {
+	case m.AuthorBlocked:
+		return RuleBlockedAuthor
+	case c.spam.MinFollowers > 0 && m.AuthorFollowers >= 0 && m.AuthorFollowers < c.spam.MinFollowers:
+		return RuleMinFollowers
+	case c.spam.MaxDailyPosts > 0 && m.AuthorDailyPosts > c.spam.MaxDailyPosts:
+		return RuleMaxDailyPosts
+	case c.spam.MaxCashtags > 0 && m.CashtagCount > c.spam.MaxCashtags:
+		return RuleMaxCashtags
+	default:
+		return ""
+	}
+}
+
+// dedupKey returns the key under which a mention is considered a duplicate,
+// and false if mentions should not be deduplicated.
+func (c *mentionCounter) dedupKey(m domain.Mention) (string, bool) {
+	switch c.mode {
+	case CountDistinctTweets:
+		text := normalizeTweetText(m.Text)
+		if text == "" {
+			return m.Symbol + "|tweet|" + m.TweetID, true
+		}
+		return m.Symbol + "|text|" + text, true
+	case CountDistinctAuthors:
+		if m.AuthorID == "" {
+			return m.Symbol + "|tweet|" + m.TweetID, true
+		}
+		return m.Symbol + "|author|" + m.AuthorID, true
+	default:
+		return "", false
+	}
+}
+
+// result returns the counted stocks ordered by symbol.
+func (c *mentionCounter) result() []domain.Stock {
+	stocks := make([]domain.Stock, 0, len(c.stocks))
+	for _, s := range c.stocks {
+		stocks = append(stocks, *s)
+	}
+
+	sort.Slice(stocks, func(i, j int) bool {
+		return stocks[i].Symbol < stocks[j].Symbol
+	})
+
+	return stocks
+}
+
+// one returns the single stock counted by CountOne. ErrNoSuchStock is returned if the stock does
+// not exist and ErrNoMentions if it exists but no mentions of it were either counted or removed.
+func (c *mentionCounter) one() (domain.Stock, error) {
+	stocks := c.result()
+	if len(stocks) == 0 {
+		return domain.Stock{}, ErrNoSuchStock
+	}
+
+	removed := int64(0)
+	for _, count := range c.report.RemovedMentions {
+		removed += count
+	}
+	if c.report.CountedMentions == 0 && removed == 0 {
+		return domain.Stock{}, ErrNoMentions
+	}
+
+	return stocks[0], nil
+}
+
+// dailyCounts returns the counted mentions per stock and day ordered by symbol and day.
+func (c *mentionCounter) dailyCounts() []domain.DailyCount {
+	counts := make([]domain.DailyCount, 0)
+	for symbol, days := range c.daily {
+		for day, count := range days {
+			counts = append(counts, domain.DailyCount{
+				Symbol: symbol,
+				Day:    day,
+				Count:  count,
+			})
+		}
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Symbol != counts[j].Symbol {
+			return counts[i].Symbol < counts[j].Symbol
+		}
+		return counts[i].Day.Before(counts[j].Day)
+	})
+
+	return counts
+}
+
+// filterReport returns a summary of how many mentions were counted and removed.
+func (c *mentionCounter) filterReport() domain.FilterReport {
+	return c.report
+}
+
+func truncateToDay(t time.Time) time.Time {
+	utc := t.UTC()
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+var (
+	retweetPrefixPattern = regexp.MustCompile(`^rt\s+@\w+:?`)
+	urlPattern           = regexp.MustCompile(`https?://\S+`)
+	userMentionPattern   = regexp.MustCompile(`@\w+`)
+	nonWordPattern       = regexp.MustCompile(`[^\p{L}\p{N}$]+`)
+)
+
+// normalizeTweetText reduces a tweet text to a form where retweets and
+// texts that differ only in links, user mentions, casing or punctuation are equal.
+func normalizeTweetText(text string) string {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	normalized = retweetPrefixPattern.ReplaceAllString(normalized, "")
+	normalized = urlPattern.ReplaceAllString(normalized, " ")
+	normalized = userMentionPattern.ReplaceAllString(normalized, " ")
+	normalized = nonWordPattern.ReplaceAllString(normalized, " ")
+
+	return strings.Join(strings.Fields(normalized), " ")
+}
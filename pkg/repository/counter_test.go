@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMentionCounter(t *testing.T) {
+	assert := assert.New(t)
+
+	mentions := []domain.Mention{
+		domain.Mention{Symbol: "AAPL", TweetID: "1", AuthorID: "a", Language: "en", Text: "$AAPL to the moon https://t.co/1"},
+		domain.Mention{Symbol: "AAPL", TweetID: "2", AuthorID: "b", Language: "en", Text: "RT @a: $AAPL to the moon https://t.co/2"},
+		domain.Mention{Symbol: "AAPL", TweetID: "3", AuthorID: "a", Language: "sv", Text: "Köper $AAPL idag"},
+		domain.Mention{Symbol: "AAPL", TweetID: "3", AuthorID: "a", Language: "sv", Text: "Köper $AAPL idag"},
+		domain.Mention{Symbol: "AMD", TweetID: "4", AuthorID: "a", Language: "en", Text: "$AMD"},
+	}
+
+	counts := countTestMentions(CountAllMentions, mentions)
+	assert.Equal(2, len(counts))
+	assert.Equal("AAPL", counts[0].Symbol)
+	assert.Equal(int64(4), counts[0].Count)
+	assert.Equal(int64(2), counts[0].LanguageCounts["sv"])
+	assert.Equal("AMD", counts[1].Symbol)
+	assert.Equal(int64(1), counts[1].Count)
+
+	counts = countTestMentions(CountDistinctTweets, mentions)
+	assert.Equal(int64(2), counts[0].Count)
+	assert.Equal(int64(1), counts[0].LanguageCounts["en"])
+	assert.Equal(int64(1), counts[0].LanguageCounts["sv"])
+	assert.Equal(int64(1), counts[1].Count)
+
+	counts = countTestMentions(CountDistinctAuthors, mentions)
+	assert.Equal(int64(2), counts[0].Count)
+	assert.Equal(int64(1), counts[1].Count)
+}
+
+func TestMentionCounterSpamFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	mentions := []domain.Mention{
+		domain.Mention{Symbol: "AAPL", TweetID: "1", AuthorFollowers: 100, AuthorDailyPosts: 1, CashtagCount: 1},
+		domain.Mention{Symbol: "AAPL", TweetID: "2", AuthorFollowers: 100, AuthorBlocked: true},
+		domain.Mention{Symbol: "AAPL", TweetID: "3", AuthorFollowers: 0},
+		domain.Mention{Symbol: "AAPL", TweetID: "4", AuthorFollowers: -1, AuthorDailyPosts: 500},
+		domain.Mention{Symbol: "AAPL", TweetID: "5", AuthorFollowers: 100, CashtagCount: 12},
+		domain.Mention{Symbol: "AMD", TweetID: "5", AuthorFollowers: 100, CashtagCount: 12},
+	}
+
+	counter := newMentionCounter(CountOptions{
+		Mode: CountAllMentions,
+		Spam: SpamFilter{
+			MinFollowers:  1,
+			MaxDailyPosts: 100,
+			MaxCashtags:   5,
+		},
+	})
+	for _, m := range mentions {
+		counter.add(m)
+	}
+
+	counts := counter.result()
+	assert.Equal(2, len(counts))
+	assert.Equal(int64(1), counts[0].Count)
+	assert.Equal(int64(0), counts[1].Count)
+
+	report := counter.filterReport()
+	assert.Equal(int64(1), report.CountedMentions)
+	assert.Equal(int64(1), report.RemovedMentions[RuleBlockedAuthor])
+	assert.Equal(int64(1), report.RemovedMentions[RuleMinFollowers])
+	assert.Equal(int64(1), report.RemovedMentions[RuleMaxDailyPosts])
+	assert.Equal(int64(2), report.RemovedMentions[RuleMaxCashtags])
+}
+
+func TestMentionCounterOne(t *testing.T) {
+	assert := assert.New(t)
+
+	counter := newMentionCounter(CountOptions{Mode: CountAllMentions})
+	_, err := counter.one()
+	assert.Equal(ErrNoSuchStock, err)
+
+	counter.addStock("MSFT")
+	_, err = counter.one()
+	assert.Equal(ErrNoMentions, err)
+
+	// Stocks whose mentions were all removed are counted as zero.
+	counter.add(domain.Mention{Symbol: "MSFT", TweetID: "1", AuthorBlocked: true})
+	s, err := counter.one()
+	assert.NoError(err)
+	assert.Equal("MSFT", s.Symbol)
+	assert.Equal(int64(0), s.Count)
+}
+
+func TestNormalizeTweetText(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("$aapl to the moon", normalizeTweetText("RT @user: $AAPL to the moon!! https://t.co/abc"))
+	assert.Equal("$aapl to the moon", normalizeTweetText("$aapl   to the moon @someone"))
+	assert.Equal("", normalizeTweetText("https://t.co/abc"))
+}
+
+func TestCountOptionsSince(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	opts := DefaultCountOptions()
+	assert.True(opts.since(now).IsZero())
+
+	opts.Period = 24 * time.Hour
+	assert.Equal(now.Add(-24*time.Hour), opts.since(now))
+}
+
+func countTestMentions(mode CountMode, mentions []domain.Mention) []domain.Stock {
+	counter := newMentionCounter(CountOptions{Mode: mode})
+	for _, m := range mentions {
+		counter.add(m)
+	}
+
+	return counter.result()
+}
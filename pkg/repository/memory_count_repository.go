@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// NewMemoryCountRepo creates a CountRepo counting the tweets of a MemoryStore.
+func NewMemoryCountRepo(store *MemoryStore, opts CountOptions) CountRepo {
+	return &memoryCountRepo{
+		store: store,
+		opts:  opts,
+	}
+}
+
+// memoryCountRepo in-memory implementation of CountRepo.
+type memoryCountRepo struct {
+	store *MemoryStore
+	opts  CountOptions
+}
+
+// CountOne counts the total and per language tweet volume of a single stock.
+func (cr *memoryCountRepo) CountOne(symbol string) (domain.Stock, domain.FilterReport, error) {
+	counter := cr.countMentions(cr.opts.since(time.Now().UTC()), symbol)
+	s, err := counter.one()
+	return s, counter.filterReport(), err
+}
+
+// CountAll counts the total and per language tweet volume of all stocks in the system,
+// including stocks without mentions.
+func (cr *memoryCountRepo) CountAll() ([]domain.Stock, domain.FilterReport, error) {
+	counter := cr.countMentions(cr.opts.since(time.Now().UTC()), "")
+	return counter.result(), counter.filterReport(), nil
+}
+
+// CountDaily counts the mentions per stock and day from the given point in time.
+func (cr *memoryCountRepo) CountDaily(since time.Time) ([]domain.DailyCount, error) {
+	return cr.countMentions(since, "").dailyCounts(), nil
+}
+
+// CountStockDaily counts the mentions per day of a single stock from the given point in time.
+func (cr *memoryCountRepo) CountStockDaily(symbol string, since time.Time) ([]domain.DailyCount, error) {
+	return cr.countMentions(since, symbol).dailyCounts(), nil
+}
+
+// countMentions counts the mentions of stored stocks in tweets created from a point in time, in order
+// of creation. Tweets without a creation time are counted last and only if the period is unbounded.
+// If a symbol is given only the mentions of that stock are counted. Stocks without mentions are
+// counted with a zero count.
+func (cr *memoryCountRepo) countMentions(since time.Time, symbol string) *mentionCounter {
+	ms := cr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	tweets := make([]domain.Tweet, 0, len(ms.tweets))
+	dailyPosts := make(map[string]int64)
+	for _, t := range ms.tweets {
+		if !since.IsZero() && (t.CreatedAt.IsZero() || t.CreatedAt.Before(since)) {
+			continue
+		}
+		tweets = append(tweets, t)
+		if key, ok := dailyPostKey(t); ok {
+			dailyPosts[key]++
+		}
+	}
+
+	sort.SliceStable(tweets, func(i, j int) bool {
+		a, b := tweets[i].CreatedAt, tweets[j].CreatedAt
+		if a.IsZero() || b.IsZero() {
+			return b.IsZero() && !a.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	counter := newMentionCounter(cr.opts)
+	for _, s := range ms.stocks {
+		if symbol == "" || s.symbol == symbol {
+			counter.addStock(s.symbol)
+		}
+	}
+
+	for _, t := range tweets {
+		key, _ := dailyPostKey(t)
+		_, blocked := ms.blocklist[t.AuthorID]
+		for _, s := range t.Symbols {
+			if _, ok := ms.stocks[s]; !ok || (symbol != "" && s != symbol) {
+				continue
+			}
+			counter.add(domain.Mention{
+				Symbol:           s,
+				TweetID:          t.ID,
+				AuthorID:         t.AuthorID,
+				AuthorFollowers:  t.AuthorFollowers,
+				AuthorDailyPosts: dailyPosts[key],
+				AuthorBlocked:    blocked && t.AuthorID != "",
+				Language:         t.Language,
+				Text:             t.Text,
+				CashtagCount:     int64(len(t.Symbols)),
+				CreatedAt:        t.CreatedAt,
+			})
+		}
+	}
+
+	return counter
+}
+
+// dailyPostKey returns the key of the author and day of a tweet which posts
+// are counted by, and false if the author or creation time is unknown.
+func dailyPostKey(t domain.Tweet) (string, bool) {
+	if t.AuthorID == "" || t.CreatedAt.IsZero() {
+		return "", false
+	}
+
+	return t.AuthorID + "|" + truncateToDay(t.CreatedAt).Format("2006-01-02"), true
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// NewMemoryBlocklistRepo creates a BlocklistRepo storing blocked authors in a MemoryStore.
+// Blocked authors are excluded by the count repo of the same store.
+func NewMemoryBlocklistRepo(store *MemoryStore) BlocklistRepo {
+	return &memoryBlocklistRepo{store: store}
+}
+
+// memoryBlocklistRepo in-memory implementation of BlocklistRepo.
+type memoryBlocklistRepo struct {
+	store *MemoryStore
+}
+
+// Save adds an author to the blocklist or updates the reason if already blocked.
+func (mr *memoryBlocklistRepo) Save(author domain.BlockedAuthor) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if blocked, ok := ms.blocklist[author.AuthorID]; ok {
+		author.CreatedAt = blocked.CreatedAt
+	}
+	ms.blocklist[author.AuthorID] = author
+	return nil
+}
+
+// Delete removes an author from the blocklist.
+func (mr *memoryBlocklistRepo) Delete(authorID string) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.blocklist[authorID]; !ok {
+		return ErrNoSuchAuthor
+	}
+	delete(ms.blocklist, authorID)
+	return nil
+}
+
+// FindAll finds all blocked authors.
+func (mr *memoryBlocklistRepo) FindAll() ([]domain.BlockedAuthor, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	authors := make([]domain.BlockedAuthor, 0, len(ms.blocklist))
+	for _, a := range ms.blocklist {
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return authors[i].CreatedAt.After(authors[j].CreatedAt)
+	})
+
+	return authors, nil
+}
+
+// NewMemorySynonymRepo creates a SynonymRepo storing synonyms in a MemoryStore.
+// Synonyms are searched by the stock repo of the same store.
+func NewMemorySynonymRepo(store *MemoryStore) SynonymRepo {
+	return &memorySynonymRepo{store: store}
+}
+
+// memorySynonymRepo in-memory implementation of SynonymRepo.
+type memorySynonymRepo struct {
+	store *MemoryStore
+}
+
+// Save stores a synonym, replacing the symbols of the term if it already exists.
+func (mr *memorySynonymRepo) Save(synonym domain.Synonym) error {
+	return mr.Import([]domain.Synonym{synonym}, false)
+}
+
+// Delete removes a term from the synonym dictionary.
+func (mr *memorySynonymRepo) Delete(term string) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.synonyms[term]; !ok {
+		return ErrNoSuchSynonym
+	}
+	delete(ms.synonyms, term)
+	ms.bumpVersion(SynonymVersion)
+	return nil
+}
+
+// FindAll finds all synonyms ordered by term.
+func (mr *memorySynonymRepo) FindAll() ([]domain.Synonym, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	synonyms := make([]domain.Synonym, 0, len(ms.synonyms))
+	for _, s := range ms.synonyms {
+		synonyms = append(synonyms, s)
+	}
+	sort.Slice(synonyms, func(i, j int) bool {
+		return synonyms[i].Term < synonyms[j].Term
+	})
+
+	return synonyms, nil
+}
+
+// Import stores a list of synonyms, replacing the symbols of existing terms.
+// If replace is true all other terms are removed from the dictionary.
+func (mr *memorySynonymRepo) Import(synonyms []domain.Synonym, replace bool) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if replace {
+		ms.synonyms = make(map[string]domain.Synonym)
+	}
+
+	for _, s := range synonyms {
+		symbols := make([]string, len(s.Symbols))
+		copy(symbols, s.Symbols)
+		sort.Strings(symbols)
+		s.Symbols = symbols
+		ms.synonyms[s.Term] = s
+	}
+
+	ms.bumpVersion(SynonymVersion)
+	return nil
+}
+
+// NewMemoryVersionRepo creates a VersionRepo finding the versions of data in a MemoryStore.
+func NewMemoryVersionRepo(store *MemoryStore) VersionRepo {
+	return &memoryVersionRepo{store: store}
+}
+
+// memoryVersionRepo in-memory implementation of VersionRepo.
+type memoryVersionRepo struct {
+	store *MemoryStore
+}
+
+// FindVersions finds the versions of all data sets which have been written to.
+func (mr *memoryVersionRepo) FindVersions() (map[string]domain.DataVersion, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	versions := make(map[string]domain.DataVersion, len(ms.versions))
+	for name, version := range ms.versions {
+		versions[name] = version
+	}
+
+	return versions, nil
+}
+
+// NewMemoryAnomalyRepo creates an AnomalyRepo storing anomalies in a MemoryStore.
+func NewMemoryAnomalyRepo(store *MemoryStore) AnomalyRepo {
+	return &memoryAnomalyRepo{store: store}
+}
+
+// memoryAnomalyRepo in-memory implementation of AnomalyRepo.
+type memoryAnomalyRepo struct {
+	store *MemoryStore
+}
+
+// Save saves an anomaly, returning true if no anomaly of the same stock and day was stored before.
+// Otherwise the statistics of the earlier anomaly are replaced, keeping when it was first detected.
+func (mr *memoryAnomalyRepo) Save(a domain.Anomaly) (bool, error) {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := a.Symbol + "|" + truncateToDay(a.Day).Format("2006-01-02")
+	earlier, ok := ms.anomalies[key]
+	if ok {
+		a.DetectedAt = earlier.DetectedAt
+	}
+	ms.anomalies[key] = a
+	ms.bumpVersion(AnomalyVersion)
+
+	return !ok, nil
+}
+
+// FindRecent finds the most recent anomalies.
+func (mr *memoryAnomalyRepo) FindRecent(limit int) ([]domain.Anomaly, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	anomalies := make([]domain.Anomaly, 0, len(ms.anomalies))
+	for _, a := range ms.anomalies {
+		anomalies = append(anomalies, a)
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		if !anomalies[i].Day.Equal(anomalies[j].Day) {
+			return anomalies[i].Day.After(anomalies[j].Day)
+		}
+		return anomalies[i].Deviation > anomalies[j].Deviation
+	})
+
+	if len(anomalies) > limit {
+		anomalies = anomalies[:limit]
+	}
+	return anomalies, nil
+}
+
+// NewMemoryWebhookRepo creates a WebhookRepo storing subscriptions and deliveries in a MemoryStore.
+func NewMemoryWebhookRepo(store *MemoryStore) WebhookRepo {
+	return &memoryWebhookRepo{store: store}
+}
+
+// memoryWebhookRepo in-memory implementation of WebhookRepo.
+type memoryWebhookRepo struct {
+	store *MemoryStore
+}
+
+// SaveSubscription saves a new webhook subscription.
+func (mr *memoryWebhookRepo) SaveSubscription(s domain.Subscription) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.subscriptions[s.ID] = s
+	return nil
+}
+
+// DeleteSubscription deletes a webhook subscription along with its deliveries.
+func (mr *memoryWebhookRepo) DeleteSubscription(id string) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.subscriptions[id]; !ok {
+		return ErrNoSuchSubscription
+	}
+	delete(ms.subscriptions, id)
+
+	deliveries := make([]domain.Delivery, 0, len(ms.deliveries))
+	for _, d := range ms.deliveries {
+		if d.SubscriptionID != id {
+			deliveries = append(deliveries, d)
+		}
+	}
+	ms.deliveries = deliveries
+	return nil
+}
+
+// FindSubscriptions finds all webhook subscriptions.
+func (mr *memoryWebhookRepo) FindSubscriptions() ([]domain.Subscription, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	subscriptions := make([]domain.Subscription, 0, len(ms.subscriptions))
+	for _, s := range ms.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions, nil
+}
+
+// SaveDelivery saves a delivery attempt.
+func (mr *memoryWebhookRepo) SaveDelivery(d domain.Delivery) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.deliveries = append(ms.deliveries, d)
+	return nil
+}
+
+// FindDeliveries finds the most recent delivery attempts of a subscription.
+func (mr *memoryWebhookRepo) FindDeliveries(subscriptionID string, limit int) ([]domain.Delivery, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	deliveries := make([]domain.Delivery, 0)
+	for _, d := range ms.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// PruneDeliveries deletes all but the given number of the most recent delivery attempts of a subscription.
+func (mr *memoryWebhookRepo) PruneDeliveries(subscriptionID string, keep int) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	latest := make([]domain.Delivery, 0)
+	for _, d := range ms.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			latest = append(latest, d)
+		}
+	}
+	sort.SliceStable(latest, func(i, j int) bool {
+		return latest[i].CreatedAt.After(latest[j].CreatedAt)
+	})
+	if len(latest) <= keep {
+		return nil
+	}
+
+	pruned := make(map[string]bool, len(latest)-keep)
+	for _, d := range latest[keep:] {
+		pruned[d.ID] = true
+	}
+
+	deliveries := make([]domain.Delivery, 0, len(ms.deliveries)-len(pruned))
+	for _, d := range ms.deliveries {
+		if !pruned[d.ID] {
+			deliveries = append(deliveries, d)
+		}
+	}
+	ms.deliveries = deliveries
+	return nil
+}
+
+// NewMemoryRankingLock creates a RankingLock held within the process, for
+// backends which are not shared between replicas.
+func NewMemoryRankingLock() RankingLock {
+	return &memoryRankingLock{}
+}
+
+type memoryRankingLock struct {
+	locked int32
+}
+
+// TryLock takes the lock if it is free.
+func (ml *memoryRankingLock) TryLock() (bool, error) {
+	return atomic.CompareAndSwapInt32(&ml.locked, 0, 1), nil
+}
+
+// Unlock releases the lock.
+func (ml *memoryRankingLock) Unlock() error {
+	atomic.StoreInt32(&ml.locked, 0)
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStockRepoSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewMemoryStore()
+	store.AddStock(domain.Stock{Symbol: "SHB-A", Name: "Svenska Handelsbanken AB", Count: 10}, true)
+	store.AddStock(domain.Stock{Symbol: "HM-B", Name: "Hennes & Mauritz AB", Count: 30}, true)
+	store.AddStock(domain.Stock{Symbol: "BANK", Name: "Bank Corp", Count: 5}, true)
+	store.AddStock(domain.Stock{Symbol: "NOK", Name: "Nokia Oyj", Count: 20}, true)
+	store.AddStock(domain.Stock{Symbol: "OLD", Name: "Old Bank Holdings", Count: 100}, false)
+
+	repo := NewMemoryStockRepo(store, DefaultStockOptions())
+	stocks, err := repo.Search("bank", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"BANK", "SHB-A"}, stockSymbols(stocks))
+
+	stocks, err = repo.Search("AB", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"HM-B", "SHB-A"}, stockSymbols(stocks))
+
+	stocks, err = repo.Search("ab", "", 1)
+	assert.NoError(err)
+	assert.Equal([]string{"HM-B"}, stockSymbols(stocks))
+
+	stocks, err = repo.Search("nokeeya", "", 10)
+	assert.NoError(err)
+	assert.Equal(0, len(stocks))
+
+	phoneticRepo := NewMemoryStockRepo(store, StockOptions{PhoneticMatching: true})
+	stocks, err = phoneticRepo.Search("nokeeya", "", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"NOK"}, stockSymbols(stocks))
+
+	err = repo.Save(domain.Stock{Symbol: "BANK", Name: "Renamed", Count: 1, LanguageCounts: map[string]int64{"sv": 40}})
+	assert.NoError(err)
+	stocks, err = repo.Search("bank", "sv", 10)
+	assert.NoError(err)
+	assert.Equal([]string{"BANK", "SHB-A"}, stockSymbols(stocks))
+	assert.Equal("Bank Corp", stocks[0].Name)
+	assert.Equal(int64(40), stocks[0].Count)
+
+	stocks, err = repo.FindMostCommon([]string{"HM-B"}, "", 2)
+	assert.NoError(err)
+	assert.Equal([]string{"NOK", "SHB-A"}, stockSymbols(stocks))
+
+	_, err = repo.Find("MISSING")
+	assert.Equal(ErrNoSuchStock, err)
+	old, err := repo.Find("OLD")
+	assert.NoError(err)
+	assert.Equal("Old Bank Holdings", old.Name)
+}
+
+func TestMemoryCountRepo(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now().UTC()
+	store := NewMemoryStore()
+	store.AddStock(domain.Stock{Symbol: "AAPL", Name: "Apple Inc."}, true)
+	store.AddStock(domain.Stock{Symbol: "AMD", Name: "Advanced Micro Devices, Inc."}, true)
+	store.AddTweet(domain.Tweet{ID: "1", AuthorID: "a", Language: "en", Symbols: []string{"AAPL", "AMD", "MSFT"}, CreatedAt: now.Add(-time.Hour)})
+	store.AddTweet(domain.Tweet{ID: "2", AuthorID: "b", Language: "sv", Symbols: []string{"AAPL"}, CreatedAt: now.Add(-48 * time.Hour)})
+	store.AddTweet(domain.Tweet{ID: "3", AuthorID: "spammer", Language: "en", Symbols: []string{"AAPL"}, CreatedAt: now})
+
+	err := NewMemoryBlocklistRepo(store).Save(domain.BlockedAuthor{AuthorID: "spammer", CreatedAt: now})
+	assert.NoError(err)
+
+	repo := NewMemoryCountRepo(store, DefaultCountOptions())
+	stocks, report, err := repo.CountAll()
+	assert.NoError(err)
+	assert.Equal([]string{"AAPL", "AMD"}, stockSymbols(stocks))
+	assert.Equal(int64(2), stocks[0].Count)
+	assert.Equal(int64(1), stocks[0].LanguageCounts["sv"])
+	assert.Equal(int64(3), report.CountedMentions)
+	assert.Equal(int64(1), report.RemovedMentions[RuleBlockedAuthor])
+
+	repo = NewMemoryCountRepo(store, CountOptions{Mode: CountAllMentions, Period: 24 * time.Hour})
+	stock, _, err := repo.CountOne("AAPL")
+	assert.NoError(err)
+	assert.Equal(int64(1), stock.Count)
+
+	_, _, err = repo.CountOne("MSFT")
+	assert.Equal(ErrNoSuchStock, err)
+
+	counts, err := repo.CountStockDaily("AAPL", now.Add(-72*time.Hour))
+	assert.NoError(err)
+	assert.Equal(2, len(counts))
+}
+
+func stockSymbols(stocks []domain.Stock) []string {
+	symbols := make([]string, 0, len(stocks))
+	for _, s := range stocks {
+		symbols = append(symbols, s.Symbol)
+	}
+
+	return symbols
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+// NewMemoryStockRepo creates a StockRepo storing stocks in a MemoryStore.
+func NewMemoryStockRepo(store *MemoryStore, opts StockOptions) StockRepo {
+	return &memoryStockRepo{
+		store: store,
+		opts:  opts,
+	}
+}
+
+// memoryStockRepo in-memory implementation of StockRepo.
+type memoryStockRepo struct {
+	store *MemoryStore
+	opts  StockOptions
+}
+
+// Save saves a stock along with its per language counts. Stocks which are
+// already stored keep their name and active state.
+func (mr *memoryStockRepo) Save(s domain.Stock) error {
+	ms := mr.store
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now().UTC()
+	stored, ok := ms.stocks[s.Symbol]
+	if !ok {
+		stored = newMemoryStock(s.Symbol, s.Name, now)
+		ms.stocks[s.Symbol] = stored
+	}
+
+	stored.count = s.Count
+	stored.updatedAt = now
+	stored.languageCounts = make(map[string]int64, len(s.LanguageCounts))
+	for language, count := range s.LanguageCounts {
+		stored.languageCounts[language] = count
+	}
+
+	return nil
+}
+
+// Find finds a stock by its symbol.
+func (mr *memoryStockRepo) Find(symbol string) (domain.Stock, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	s, ok := ms.stocks[symbol]
+	if !ok {
+		return domain.Stock{}, ErrNoSuchStock
+	}
+
+	return s.toDomain(), nil
+}
+
+// rankedStock a stock along with the values it is ordered by.
+type rankedStock struct {
+	stock *memoryStock
+	rank  int
+	count int64
+}
+
+// Search finds stocks matching a given query with the same matching and ranking as the postgres implementation.
+func (mr *memoryStockRepo) Search(query, language string, limit int) ([]domain.Stock, error) {
+	terms := textutil.Words(query)
+	fullQuery := strings.Join(terms, " ")
+	codes := mr.phoneticCodes(terms)
+
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ranked := make([]rankedStock, 0)
+	for _, s := range ms.stocks {
+		if !s.active || !matchesAllTerms(s, terms, codes) {
+			continue
+		}
+		ranked = append(ranked, rankedStock{
+			stock: s,
+			rank:  searchRank(s, terms, fullQuery),
+			count: s.mentionCount(language),
+		})
+	}
+
+	return sortRankedStocks(ranked, limit), nil
+}
+
+// phoneticCodes returns the phonetic code of each term, codes are
+// left empty if the term is too short or phonetic matching is disabled.
+func (mr *memoryStockRepo) phoneticCodes(terms []string) []string {
+	codes := make([]string, len(terms))
+	if !mr.opts.PhoneticMatching {
+		return codes
+	}
+
+	for i, term := range terms {
+		codes[i] = textutil.PhoneticCode(term)
+	}
+
+	return codes
+}
+
+// matchesAllTerms checks that every term is a prefix of the symbol, the name or any
+// token of the name, or sounds like a word of the name by its phonetic code.
+func matchesAllTerms(s *memoryStock, terms, codes []string) bool {
+	for i, term := range terms {
+		if strings.HasPrefix(s.lowerSymbol(), term) || strings.HasPrefix(s.searchName, term) {
+			continue
+		}
+
+		matched := false
+		for _, t := range s.tokens {
+			if strings.HasPrefix(t.Text, term) || (codes[i] != "" && t.Phonetic == codes[i]) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// searchRank ranks prefix matches of the full query first, followed by stocks where
+// every term matches the start of a word, matches inside of words and lastly phonetic matches.
+func searchRank(s *memoryStock, terms []string, fullQuery string) int {
+	if strings.HasPrefix(s.lowerSymbol(), fullQuery) || strings.HasPrefix(s.searchName, fullQuery) {
+		return 0
+	}
+
+	if everyTerm(terms, func(term string) bool {
+		return strings.HasPrefix(s.lowerSymbol(), term) || hasTokenPrefix(s.tokens, term, true)
+	}) {
+		return 1
+	}
+
+	if everyTerm(terms, func(term string) bool {
+		return strings.HasPrefix(s.lowerSymbol(), term) || strings.HasPrefix(s.searchName, term) ||
+			hasTokenPrefix(s.tokens, term, false)
+	}) {
+		return 2
+	}
+
+	return 3
+}
+
+func everyTerm(terms []string, matches func(term string) bool) bool {
+	for _, term := range terms {
+		if !matches(term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasTokenPrefix checks if a term is the prefix of any token, or only of word start tokens.
+func hasTokenPrefix(tokens []textutil.Token, term string, wordStartOnly bool) bool {
+	for _, t := range tokens {
+		if (t.WordStart || !wordStartOnly) && strings.HasPrefix(t.Text, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortRankedStocks orders stocks by rank and then by their mentions in the
+// searched language and in total, returning at most limit stocks.
+func sortRankedStocks(ranked []rankedStock, limit int) []domain.Stock {
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		if a.stock.count != b.stock.count {
+			return a.stock.count > b.stock.count
+		}
+		return a.stock.symbol < b.stock.symbol
+	})
+
+	stocks := make([]domain.Stock, 0, len(ranked))
+	for _, r := range ranked {
+		if len(stocks) == limit {
+			break
+		}
+		s := r.stock.toDomain()
+		s.Count = r.count
+		stocks = append(stocks, s)
+	}
+
+	return stocks
+}
+
+// FindMostCommon finds the most common active stocks except the ones with the given symbols.
+// If a language is specified the stocks are ranked by their mentions in that language.
+func (mr *memoryStockRepo) FindMostCommon(excluded []string, language string, limit int) ([]domain.Stock, error) {
+	skip := make(map[string]bool, len(excluded))
+	for _, symbol := range excluded {
+		skip[symbol] = true
+	}
+
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ranked := make([]rankedStock, 0, len(ms.stocks))
+	for _, s := range ms.stocks {
+		if s.active && !skip[s.symbol] {
+			ranked = append(ranked, rankedStock{stock: s, count: s.mentionCount(language)})
+		}
+	}
+
+	return sortRankedStocks(ranked, limit), nil
+}
+
+// RefreshSearchIndex does nothing as stocks are indexed when they are stored.
+func (mr *memoryStockRepo) RefreshSearchIndex() (int, error) {
+	return 0, nil
+}
+
+// FindVocabulary finds the symbols and name words of active stocks with a length within the
+// given bounds, along with the total mentions of the stocks they belong to.
+func (mr *memoryStockRepo) FindVocabulary(minLength, maxLength int) ([]domain.Term, error) {
+	inBounds := func(text string) bool {
+		length := utf8.RuneCountInString(text)
+		return length >= minLength && length <= maxLength
+	}
+
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	vocabulary := make(map[string]*domain.Term)
+	add := func(text string, count int64, isSymbol bool) {
+		t, ok := vocabulary[text]
+		if !ok {
+			t = &domain.Term{Text: text}
+			vocabulary[text] = t
+		}
+		t.Count += count
+		t.IsSymbol = t.IsSymbol || isSymbol
+	}
+
+	for _, s := range ms.stocks {
+		if !s.active {
+			continue
+		}
+		if inBounds(s.symbol) {
+			add(s.lowerSymbol(), s.count, true)
+		}
+		for _, t := range s.tokens {
+			if t.WordStart && inBounds(t.Text) {
+				add(t.Text, s.count, false)
+			}
+		}
+	}
+
+	terms := make([]domain.Term, 0, len(vocabulary))
+	for _, t := range vocabulary {
+		terms = append(terms, *t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		return terms[i].Text < terms[j].Text
+	})
+
+	return terms, nil
+}
+
+// FindBySynonyms finds the active stocks which the given synonym terms map to, keyed by term.
+// If a language is specified the stocks are ranked by their mentions in that language.
+func (mr *memoryStockRepo) FindBySynonyms(terms []string, language string) (map[string][]domain.Stock, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	stocks := make(map[string][]domain.Stock)
+	for _, term := range terms {
+		synonym, ok := ms.synonyms[term]
+		if !ok {
+			continue
+		}
+
+		ranked := make([]rankedStock, 0, len(synonym.Symbols))
+		for _, symbol := range synonym.Symbols {
+			s, ok := ms.stocks[symbol]
+			if ok && s.active {
+				ranked = append(ranked, rankedStock{stock: s, count: s.mentionCount(language)})
+			}
+		}
+		if len(ranked) > 0 {
+			stocks[term] = sortRankedStocks(ranked, len(ranked))
+		}
+	}
+
+	return stocks, nil
+}
+
+// LastUpdated finds when a stock was last saved, which is the zero time if no stocks are stored.
+func (mr *memoryStockRepo) LastUpdated() (time.Time, error) {
+	ms := mr.store
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var lastUpdated time.Time
+	for _, s := range ms.stocks {
+		if s.updatedAt.After(lastUpdated) {
+			lastUpdated = s.updatedAt
+		}
+	}
+
+	return lastUpdated, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+// MemoryStore in-process storage shared by the in-memory repositories, holding the same
+// data as the postgres schema. It is safe for concurrent use and its contents are lost on exit.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	stocks        map[string]*memoryStock
+	tweets        []domain.Tweet
+	blocklist     map[string]domain.BlockedAuthor
+	synonyms      map[string]domain.Synonym
+	anomalies     map[string]domain.Anomaly
+	subscriptions map[string]domain.Subscription
+	deliveries    []domain.Delivery
+	versions      map[string]domain.DataVersion
+}
+
+// memoryStock a stored stock along with its search index.
+type memoryStock struct {
+	symbol         string
+	name           string
+	active         bool
+	count          int64
+	languageCounts map[string]int64
+	updatedAt      time.Time
+	searchName     string
+	tokens         []textutil.Token
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		stocks:        make(map[string]*memoryStock),
+		tweets:        make([]domain.Tweet, 0),
+		blocklist:     make(map[string]domain.BlockedAuthor),
+		synonyms:      make(map[string]domain.Synonym),
+		anomalies:     make(map[string]domain.Anomaly),
+		subscriptions: make(map[string]domain.Subscription),
+		deliveries:    make([]domain.Delivery, 0),
+		versions:      make(map[string]domain.DataVersion),
+	}
+}
+
+// AddStock stores a stock the way it is stored by the services collecting stocks,
+// replacing any stock with the same symbol. Inactive stocks are neither searched nor suggested.
+func (ms *MemoryStore) AddStock(s domain.Stock, active bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored := newMemoryStock(s.Symbol, s.Name, time.Now().UTC())
+	stored.active = active
+	stored.count = s.Count
+	for language, count := range s.LanguageCounts {
+		stored.languageCounts[language] = count
+	}
+	ms.stocks[s.Symbol] = stored
+}
+
+// AddTweet stores a tweet the way it is stored by the services collecting tweets.
+func (ms *MemoryStore) AddTweet(t domain.Tweet) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.tweets = append(ms.tweets, t)
+}
+
+// memorySeed contents of a seed file.
+type memorySeed struct {
+	Stocks []seedStock `json:"stocks"`
+	Tweets []seedTweet `json:"tweets"`
+}
+
+type seedStock struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	Active *bool  `json:"active"`
+	Count  int64  `json:"count"`
+}
+
+type seedTweet struct {
+	ID              string    `json:"id"`
+	Text            string    `json:"text"`
+	Language        string    `json:"language"`
+	AuthorID        string    `json:"authorId"`
+	AuthorFollowers *int64    `json:"authorFollowers"`
+	Symbols         []string  `json:"symbols"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Load adds the stocks and tweets of a JSON seed file to the store. Stocks are active
+// and the follower counts of tweet authors are unknown unless specified.
+func (ms *MemoryStore) Load(r io.Reader) error {
+	var seed memorySeed
+	err := json.NewDecoder(r).Decode(&seed)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range seed.Stocks {
+		active := s.Active == nil || *s.Active
+		ms.AddStock(domain.Stock{Symbol: s.Symbol, Name: s.Name, Count: s.Count}, active)
+	}
+
+	for _, t := range seed.Tweets {
+		followers := int64(-1)
+		if t.AuthorFollowers != nil {
+			followers = *t.AuthorFollowers
+		}
+
+		ms.AddTweet(domain.Tweet{
+			ID:              t.ID,
+			Text:            t.Text,
+			Language:        t.Language,
+			AuthorID:        t.AuthorID,
+			AuthorFollowers: followers,
+			Symbols:         t.Symbols,
+			CreatedAt:       t.CreatedAt,
+		})
+	}
+
+	return nil
+}
+
+func newMemoryStock(symbol, name string, updatedAt time.Time) *memoryStock {
+	return &memoryStock{
+		symbol:         symbol,
+		name:           name,
+		active:         true,
+		languageCounts: make(map[string]int64),
+		updatedAt:      updatedAt,
+		searchName:     textutil.Fold(name),
+		tokens:         textutil.Tokens(name),
+	}
+}
+
+func (s *memoryStock) toDomain() domain.Stock {
+	return domain.Stock{
+		Symbol: s.symbol,
+		Name:   s.name,
+		Count:  s.count,
+	}
+}
+
+// mentionCount returns the mentions of the stock in a language,
+// or its total mentions if no language is specified.
+func (s *memoryStock) mentionCount(language string) int64 {
+	if language == "" {
+		return s.count
+	}
+
+	return s.languageCounts[language]
+}
+
+// lowerSymbol returns the symbol in lower case as matched by search terms.
+func (s *memoryStock) lowerSymbol() string {
+	return strings.ToLower(s.symbol)
+}
+
+// bumpVersion increments the version of a data set. Must be called while holding the write lock.
+func (ms *MemoryStore) bumpVersion(name string) {
+	v := ms.versions[name]
+	ms.versions[name] = domain.DataVersion{Version: v.Version + 1, UpdatedAt: time.Now().UTC()}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// rankingLockKey key of the postgres advisory lock held while ranking all stocks.
+const rankingLockKey = 7267359
+
+// RankingLock serializes rankings of all stocks. TryLock returns false
+// without waiting if the lock is held, Unlock releases a held lock.
+type RankingLock interface {
+	TryLock() (bool, error)
+	Unlock() error
+}
+
+// NewRankingLock creates a RankingLock using the default implementation, which is held
+// across all replicas sharing the database. The lock is taken with a transaction level
+// advisory lock in a transaction kept open while ranking, which also works through a
+// transaction pooler.
+func NewRankingLock(db *sql.DB) RankingLock {
+	return &pgRankingLock{
+		db: db,
+	}
+}
+
+// pgRankingLock postgres implementation of RankingLock.
+type pgRankingLock struct {
+	db *sql.DB
+	mu sync.Mutex
+	tx *sql.Tx
+}
+
+const tryRankingLockQuery = `SELECT pg_try_advisory_xact_lock($1)`
+
+// TryLock takes the lock if no replica holds it.
+func (pg *pgRankingLock) TryLock() (bool, error) {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	var locked bool
+	err = tx.QueryRow(tryRankingLockQuery, rankingLockKey).Scan(&locked)
+	if err != nil || !locked {
+		tx.Rollback()
+		return false, err
+	}
+
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	pg.tx = tx
+	return true, nil
+}
+
+// Unlock releases the lock by ending the transaction holding it.
+func (pg *pgRankingLock) Unlock() error {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	if pg.tx == nil {
+		return nil
+	}
+
+	err := pg.tx.Rollback()
+	pg.tx = nil
+	return err
+}
+
+// MockRankingLock mock implementation of RankingLock.
+type MockRankingLock struct {
+	TryLockResult      bool
+	TryLockErr         error
+	TryLockInvocations int
+	UnlockErr          error
+	UnlockInvocations  int
+}
+
+// UnsetArgs sets all lock arguments to their default value.
+func (rl *MockRankingLock) UnsetArgs() {
+	rl.TryLockInvocations = 0
+	rl.UnlockInvocations = 0
+}
+
+// TryLock mock implementation of taking the lock.
+func (rl *MockRankingLock) TryLock() (bool, error) {
+	rl.TryLockInvocations++
+	return rl.TryLockResult, rl.TryLockErr
+}
+
+// Unlock mock implementation of releasing the lock.
+func (rl *MockRankingLock) Unlock() error {
+	rl.UnlockInvocations++
+	return rl.UnlockErr
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	// SQLite driver registered as sqlite3.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// OpenSQLite opens a SQLite database file, whose schema is created by the SQLite migrations. The file
+// ":memory:" opens a database which only lives as long as the returned handle. SQLite serializes writes,
+// so the database is limited to a single connection and transactions take the write lock when they begin.
+func OpenSQLite(filename string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+filename+"?_foreign_keys=1&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// sqliteQuery a SQLite query built from parts, along with the arguments
+// of their placeholders in the order they appear.
+type sqliteQuery struct {
+	text strings.Builder
+	args []interface{}
+}
+
+func (q *sqliteQuery) add(text string, args ...interface{}) {
+	q.text.WriteString(text)
+	q.args = append(q.args, args...)
+}
+
+// addList adds a parenthesized list of placeholders, one for each value.
+func (q *sqliteQuery) addList(values []string) {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = "?"
+		q.args = append(q.args, value)
+	}
+	q.text.WriteString("(" + strings.Join(placeholders, ", ") + ")")
+}
+
+func (q *sqliteQuery) String() string {
+	return q.text.String()
+}
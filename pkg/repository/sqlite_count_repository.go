@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// NewSQLiteCountRepo creates a CountRepo counting the tweets of a database opened with OpenSQLite.
+func NewSQLiteCountRepo(db *sql.DB, opts CountOptions) CountRepo {
+	return &sqliteCountRepo{
+		db:   db,
+		opts: opts,
+	}
+}
+
+// sqliteCountRepo SQLite implementation of CountRepo.
+type sqliteCountRepo struct {
+	db   *sql.DB
+	opts CountOptions
+}
+
+// sqliteCountMentionsQuery counts mentions like countMentionsQuery.
+const sqliteCountMentionsQuery = `
+	WITH mentions AS (
+		SELECT ts.symbol, t.language, b.author_id IS NOT NULL AS blocked FROM tweet_symbol ts
+		LEFT JOIN tweet t ON t.id = ts.tweet_id
+		LEFT JOIN author_blocklist b ON b.author_id = t.author_id
+		WHERE (?1 IS NULL OR t.created_at >= ?1)
+		AND (?2 IS NULL OR ts.symbol = ?2)
+	)
+	SELECT s.symbol, COALESCE(m.language, ''), COALESCE(m.blocked, FALSE), COUNT(m.symbol)
+	FROM stock s
+	LEFT JOIN mentions m ON m.symbol = s.symbol
+	WHERE (?2 IS NULL OR s.symbol = ?2)
+	GROUP BY s.symbol, m.language, m.blocked`
+
+// sqliteFindMentionsQuery selects mentions like findMentionsQuery. Mentions are ordered by
+// creation time with unknown times last, as SQLite otherwise sorts nulls first unlike postgres.
+const sqliteFindMentionsQuery = `
+	WITH cashtags AS (
+		SELECT tweet_id, COUNT(*) AS cashtag_count FROM tweet_symbol
+		GROUP BY tweet_id
+	), daily_posts AS (
+		SELECT author_id, DATE(created_at) AS day, COUNT(*) AS post_count FROM tweet
+		WHERE (?1 IS NULL OR created_at >= ?1)
+		GROUP BY author_id, DATE(created_at)
+	), mentions AS (
+		SELECT ts.symbol, ts.tweet_id, t.author_id, t.author_followers, d.post_count,
+			b.author_id IS NOT NULL AS blocked, t.language, t.text, c.cashtag_count, t.created_at
+		FROM tweet_symbol ts
+		LEFT JOIN tweet t ON t.id = ts.tweet_id
+		LEFT JOIN cashtags c ON c.tweet_id = ts.tweet_id
+		LEFT JOIN daily_posts d ON d.author_id = t.author_id AND d.day = DATE(t.created_at)
+		LEFT JOIN author_blocklist b ON b.author_id = t.author_id
+		WHERE (?1 IS NULL OR t.created_at >= ?1)
+		AND (?2 IS NULL OR ts.symbol = ?2)
+	)
+	SELECT s.symbol, m.tweet_id, COALESCE(m.author_id, ''), COALESCE(m.author_followers, -1),
+		COALESCE(m.post_count, 0), COALESCE(m.blocked, FALSE), COALESCE(m.language, ''),
+		COALESCE(m.text, ''), COALESCE(m.cashtag_count, 0), m.created_at
+	FROM stock s
+	LEFT JOIN mentions m ON m.symbol = s.symbol
+	WHERE (?2 IS NULL OR s.symbol = ?2)
+	ORDER BY m.created_at IS NULL, m.created_at`
+
+// CountOne counts the total and per language tweet volume of a single stock.
+func (cr *sqliteCountRepo) CountOne(symbol string) (domain.Stock, domain.FilterReport, error) {
+	counter, err := cr.countStocks(symbolArg(symbol))
+	if err != nil {
+		return domain.Stock{}, domain.NewFilterReport(), err
+	}
+
+	s, err := counter.one()
+	return s, counter.filterReport(), err
+}
+
+// CountAll counts the total and per language tweet volume of all stocks in the system,
+// including stocks without mentions.
+func (cr *sqliteCountRepo) CountAll() ([]domain.Stock, domain.FilterReport, error) {
+	counter, err := cr.countStocks(sql.NullString{})
+	if err != nil {
+		return nil, domain.NewFilterReport(), err
+	}
+
+	return counter.result(), counter.filterReport(), nil
+}
+
+// CountDaily counts the mentions per stock and day from the given point in time.
+func (cr *sqliteCountRepo) CountDaily(since time.Time) ([]domain.DailyCount, error) {
+	counter, err := cr.countMentions(pq.NullTime{Time: since.UTC(), Valid: true}, sql.NullString{})
+	if err != nil {
+		return nil, err
+	}
+
+	return counter.dailyCounts(), nil
+}
+
+// CountStockDaily counts the mentions per day of a single stock from the given point in time.
+func (cr *sqliteCountRepo) CountStockDaily(symbol string, since time.Time) ([]domain.DailyCount, error) {
+	counter, err := cr.countMentions(pq.NullTime{Time: since.UTC(), Valid: true}, symbolArg(symbol))
+	if err != nil {
+		return nil, err
+	}
+
+	return counter.dailyCounts(), nil
+}
+
+// countStocks counts mentions during the counting period, only selecting every
+// mention if they must be deduplicated or filtered as spam.
+func (cr *sqliteCountRepo) countStocks(symbol sql.NullString) (*mentionCounter, error) {
+	if cr.opts.needsMentions() {
+		return cr.countMentions(cr.sinceArg(), symbol)
+	}
+
+	return groupMentions(cr.db, sqliteCountMentionsQuery, cr.opts, cr.sinceArg(), symbol)
+}
+
+func (cr *sqliteCountRepo) countMentions(since pq.NullTime, symbol sql.NullString) (*mentionCounter, error) {
+	return selectMentions(cr.db, sqliteFindMentionsQuery, cr.opts, since, symbol)
+}
+
+// sinceArg returns the lower time bound of the counting period as a query argument.
+// Timestamps are stored as text in SQLite, so bounds must be in UTC to compare correctly.
+func (cr *sqliteCountRepo) sinceArg() pq.NullTime {
+	since := cr.opts.since(time.Now().UTC())
+	return pq.NullTime{
+		Time:  since,
+		Valid: !since.IsZero(),
+	}
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/migration"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteCountRepoCountsMentionsWithoutTweet(t *testing.T) {
+	assert := assert.New(t)
+	db := openSQLite(t)
+	defer db.Close()
+
+	// Mentions may be stored before their tweets by the services collecting them.
+	for _, statement := range []string{
+		"PRAGMA foreign_keys = OFF",
+		"INSERT INTO stock(symbol, name, is_active, total_count) VALUES('AAPL', 'Apple Inc.', TRUE, 0)",
+		"INSERT INTO tweet(id, text, language) VALUES('1', '$AAPL', 'en')",
+		"INSERT INTO tweet_symbol(id, symbol, tweet_id) VALUES(1, 'AAPL', '1')",
+		"INSERT INTO tweet_symbol(id, symbol, tweet_id) VALUES(2, 'AAPL', '2')",
+	} {
+		_, err := db.Exec(statement)
+		assert.NoError(err)
+	}
+
+	counts := repository.NewSQLiteCountRepo(db, repository.DefaultCountOptions())
+	aapl, report, err := counts.CountOne("AAPL")
+	assert.NoError(err)
+	assert.Equal(int64(2), aapl.Count)
+	assert.Equal(map[string]int64{"en": 1}, aapl.LanguageCounts)
+	assert.Equal(int64(2), report.CountedMentions)
+}
+
+func TestSQLiteAnomalyRepoSave(t *testing.T) {
+	assert := assert.New(t)
+	db := openSQLite(t)
+	defer db.Close()
+
+	_, err := db.Exec("INSERT INTO stock(symbol, name, is_active, total_count) VALUES('AAPL', 'Apple Inc.', TRUE, 0)")
+	assert.NoError(err)
+
+	repo := repository.NewAnomalyRepo(db)
+	day := time.Date(2019, 1, 29, 0, 0, 0, 0, time.UTC)
+	detectedAt := day.Add(25 * time.Hour)
+	isNew, err := repo.Save(domain.Anomaly{Symbol: "AAPL", Day: day, Count: 50, Deviation: 4, DetectedAt: detectedAt})
+	assert.NoError(err)
+	assert.True(isNew)
+
+	isNew, err = repo.Save(domain.Anomaly{Symbol: "AAPL", Day: day, Count: 60, Deviation: 5, DetectedAt: detectedAt.Add(time.Hour)})
+	assert.NoError(err)
+	assert.False(isNew)
+
+	anomalies, err := repo.FindRecent(10)
+	assert.NoError(err)
+	assert.Equal(1, len(anomalies))
+	assert.Equal(int64(60), anomalies[0].Count)
+	assert.True(detectedAt.Equal(anomalies[0].DetectedAt))
+
+	versions, err := repository.NewVersionRepo(db).FindVersions()
+	assert.NoError(err)
+	assert.Equal(int64(2), versions[repository.AnomalyVersion].Version)
+}
+
+func TestSQLiteWebhookRepoPruneDeliveries(t *testing.T) {
+	assert := assert.New(t)
+	db := openSQLite(t)
+	defer db.Close()
+
+	repo := repository.NewWebhookRepo(db)
+	for _, id := range []string{"s1", "s2"} {
+		err := repo.SaveSubscription(domain.Subscription{ID: id, URL: "http://" + id, Secret: "s", Events: domain.WebhookEvents})
+		assert.NoError(err)
+	}
+
+	createdAt := time.Date(2019, 1, 29, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"d1", "d2", "d3", "d4"} {
+		subscriptionID := "s1"
+		if i == 3 {
+			subscriptionID = "s2"
+		}
+		err := repo.SaveDelivery(domain.Delivery{ID: id, SubscriptionID: subscriptionID, EventID: "e", Event: domain.EventRankingCompleted,
+			Attempt: 1, Success: true, CreatedAt: createdAt.Add(time.Duration(i) * time.Minute)})
+		assert.NoError(err)
+	}
+
+	err := repo.PruneDeliveries("s1", 2)
+	assert.NoError(err)
+
+	deliveries, err := repo.FindDeliveries("s1", 10)
+	assert.NoError(err)
+	assert.Equal(2, len(deliveries))
+	assert.Equal("d3", deliveries[0].ID)
+	assert.Equal("d2", deliveries[1].ID)
+
+	deliveries, err = repo.FindDeliveries("s2", 10)
+	assert.NoError(err)
+	assert.Equal(1, len(deliveries))
+}
+
+func TestSQLiteSynonymRepoBumpsVersion(t *testing.T) {
+	assert := assert.New(t)
+	db := openSQLite(t)
+	defer db.Close()
+
+	synonyms := repository.NewSQLiteSynonymRepo(db)
+	versions := repository.NewVersionRepo(db)
+	found, err := versions.FindVersions()
+	assert.NoError(err)
+	assert.Equal(int64(0), found[repository.SynonymVersion].Version)
+
+	err = synonyms.Save(domain.Synonym{Term: "google", Symbols: []string{"GOOG"}, UpdatedAt: time.Now().UTC()})
+	assert.NoError(err)
+	err = synonyms.Delete("google")
+	assert.NoError(err)
+	err = synonyms.Delete("google")
+	assert.Equal(repository.ErrNoSuchSynonym, err)
+
+	found, err = versions.FindVersions()
+	assert.NoError(err)
+	assert.Equal(int64(2), found[repository.SynonymVersion].Version)
+	assert.False(found[repository.SynonymVersion].UpdatedAt.IsZero())
+}
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := repository.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = migration.NewSQLiteMigrator(db).Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,403 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+// NewSQLiteStockRepo creates a StockRepo storing stocks in a database opened with OpenSQLite.
+func NewSQLiteStockRepo(db *sql.DB, opts StockOptions) StockRepo {
+	return &sqliteStockRepo{
+		db:   db,
+		opts: opts,
+	}
+}
+
+// sqliteStockRepo SQLite implementation of StockRepo, searching the same index as the
+// postgres implementation. SQLite has no arrays, so queries are built for the number of terms.
+type sqliteStockRepo struct {
+	db   *sql.DB
+	opts StockOptions
+}
+
+const sqliteSaveStockQuery = `
+	INSERT INTO stock(symbol, name, is_active, total_count, updated_at)
+	VALUES(?1, ?2, TRUE, ?3, ?4) ON CONFLICT (symbol)
+	DO UPDATE SET total_count = ?3, updated_at = ?4`
+
+const sqliteFindOutdatedQuery = `
+	SELECT name, COALESCE(search_version, 0) < ?2 FROM stock WHERE symbol = ?1`
+
+const sqliteDeleteLanguageCountsQuery = `
+	DELETE FROM stock_language_count WHERE symbol = ?1`
+
+const sqliteSaveLanguageCountQuery = `
+	INSERT INTO stock_language_count(symbol, language, mention_count, updated_at)
+	VALUES(?1, ?2, ?3, ?4)`
+
+// Save saves a stock along with its per language counts.
+func (sr *sqliteStockRepo) Save(s domain.Stock) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = sqliteSaveStock(tx, s, time.Now().UTC())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func sqliteSaveStock(tx *sql.Tx, s domain.Stock, updatedAt time.Time) error {
+	_, err := tx.Exec(sqliteSaveStockQuery, s.Symbol, s.Name, s.Count, updatedAt)
+	if err != nil {
+		return errInsertStockFailed
+	}
+
+	var name string
+	var outdated bool
+	err = tx.QueryRow(sqliteFindOutdatedQuery, s.Symbol, searchIndexVersion).Scan(&name, &outdated)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(sqliteDeleteLanguageCountsQuery, s.Symbol)
+	if err != nil {
+		return err
+	}
+
+	for language, count := range s.LanguageCounts {
+		_, err = tx.Exec(sqliteSaveLanguageCountQuery, s.Symbol, language, count, updatedAt)
+		if err != nil {
+			return errInsertStockFailed
+		}
+	}
+
+	if outdated && name != "" {
+		return sqliteIndexStock(tx, s.Symbol, name)
+	}
+
+	return nil
+}
+
+const sqliteDeleteTokensQuery = `
+	DELETE FROM stock_token WHERE symbol = ?1`
+
+const sqliteSaveTokenQuery = `
+	INSERT INTO stock_token(symbol, token, word_start, phonetic) VALUES(?1, ?2, ?3, NULLIF(?4, ''))`
+
+const sqliteUpdateSearchNameQuery = `
+	UPDATE stock SET search_name = ?2, search_version = ?3 WHERE symbol = ?1`
+
+// sqliteIndexStock stores the folded search name and name tokens of a stock along with their phonetic codes.
+func sqliteIndexStock(tx *sql.Tx, symbol, name string) error {
+	_, err := tx.Exec(sqliteDeleteTokensQuery, symbol)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range textutil.Tokens(name) {
+		_, err = tx.Exec(sqliteSaveTokenQuery, symbol, token.Text, token.WordStart, token.Phonetic)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(sqliteUpdateSearchNameQuery, symbol, textutil.Fold(name), searchIndexVersion)
+	return err
+}
+
+const sqliteFindStockQuery = `
+	SELECT symbol, name, total_count FROM stock
+	WHERE symbol = ?1`
+
+// Find finds a stock by its symbol.
+func (sr *sqliteStockRepo) Find(symbol string) (domain.Stock, error) {
+	var s domain.Stock
+	err := sr.db.QueryRow(sqliteFindStockQuery, symbol).Scan(&s.Symbol, &s.Name, &s.Count)
+	if err == sql.ErrNoRows {
+		return domain.Stock{}, ErrNoSuchStock
+	} else if err != nil {
+		return domain.Stock{}, err
+	}
+
+	return s, nil
+}
+
+// Search finds stocks matching a given query with the same matching and ranking as the postgres implementation.
+func (sr *sqliteStockRepo) Search(query, language string, limit int) ([]domain.Stock, error) {
+	terms := textutil.Words(query)
+	codes := sr.phoneticCodes(terms)
+	patterns := likePatterns(terms)
+	fullQuery := escapeLike(strings.Join(terms, " "))
+
+	var q sqliteQuery
+	if language != "" {
+		q.add(`
+	SELECT s.symbol, s.name, COALESCE(lc.mention_count, 0) AS mention_count FROM stock s
+	LEFT JOIN stock_language_count lc ON lc.symbol = s.symbol AND lc.language = ?`, language)
+	} else {
+		q.add(`
+	SELECT s.symbol, s.name, s.total_count FROM stock s`)
+	}
+
+	q.add(`
+	WHERE s.is_active = TRUE`)
+	for i, term := range patterns {
+		q.add(` AND (
+		LOWER(s.symbol) LIKE ? || '%' ESCAPE '\' OR
+		COALESCE(s.search_name, LOWER(s.name)) LIKE ? || '%' ESCAPE '\' OR
+		EXISTS (
+			SELECT 1 FROM stock_token t
+			WHERE t.symbol = s.symbol AND (t.token LIKE ? || '%' ESCAPE '\' OR t.phonetic = ?)
+		)
+	)`, term, term, term, codes[i])
+	}
+
+	q.add(`
+	ORDER BY CASE
+		WHEN LOWER(s.symbol) LIKE ? || '%' ESCAPE '\' THEN 0
+		WHEN COALESCE(s.search_name, LOWER(s.name)) LIKE ? || '%' ESCAPE '\' THEN 0`, fullQuery, fullQuery)
+	q.add(`
+		WHEN TRUE`)
+	for _, term := range patterns {
+		q.add(` AND (
+			LOWER(s.symbol) LIKE ? || '%' ESCAPE '\' OR
+			EXISTS (
+				SELECT 1 FROM stock_token t
+				WHERE t.symbol = s.symbol AND t.word_start AND t.token LIKE ? || '%' ESCAPE '\'
+			)
+		)`, term, term)
+	}
+	q.add(` THEN 1
+		WHEN TRUE`)
+	for _, term := range patterns {
+		q.add(` AND (
+			LOWER(s.symbol) LIKE ? || '%' ESCAPE '\' OR
+			COALESCE(s.search_name, LOWER(s.name)) LIKE ? || '%' ESCAPE '\' OR
+			EXISTS (
+				SELECT 1 FROM stock_token t
+				WHERE t.symbol = s.symbol AND t.token LIKE ? || '%' ESCAPE '\'
+			)
+		)`, term, term, term)
+	}
+	q.add(` THEN 2
+		ELSE 3
+	END`)
+
+	if language != "" {
+		q.add(`, mention_count DESC, s.total_count DESC`)
+	} else {
+		q.add(`, s.total_count DESC`)
+	}
+	q.add(`
+	LIMIT ?`, limit)
+
+	return sr.findStocks(q.String(), q.args...)
+}
+
+// phoneticCodes returns the phonetic code of each term, codes are
+// left empty if the term is too short or phonetic matching is disabled.
+func (sr *sqliteStockRepo) phoneticCodes(terms []string) []string {
+	codes := make([]string, len(terms))
+	if !sr.opts.PhoneticMatching {
+		return codes
+	}
+
+	for i, term := range terms {
+		codes[i] = textutil.PhoneticCode(term)
+	}
+
+	return codes
+}
+
+const sqliteFindUnindexedStocksQuery = `
+	SELECT symbol, name FROM stock WHERE COALESCE(search_version, 0) < ?1 AND name <> ''`
+
+// RefreshSearchIndex indexes the names of stocks which were stored by other
+// services or by an earlier version of the index, returning the number of indexed stocks.
+func (sr *sqliteStockRepo) RefreshSearchIndex() (int, error) {
+	rows, err := sr.db.Query(sqliteFindUnindexedStocksQuery, searchIndexVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	stocks := make([]domain.Stock, 0)
+	for rows.Next() {
+		var s domain.Stock
+		err = rows.Scan(&s.Symbol, &s.Name)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stocks = append(stocks, s)
+	}
+	rows.Close()
+
+	indexed := 0
+	for _, s := range stocks {
+		err = sr.index(s)
+		if err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+
+	return indexed, nil
+}
+
+func (sr *sqliteStockRepo) index(s domain.Stock) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = sqliteIndexStock(tx, s.Symbol, s.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const sqliteFindVocabularyQuery = `
+	SELECT v.term, SUM(v.total_count) AS volume, MAX(v.is_symbol) FROM (
+		SELECT LOWER(symbol) AS term, total_count, TRUE AS is_symbol FROM stock
+		WHERE is_active = TRUE AND LENGTH(symbol) BETWEEN ?1 AND ?2
+		UNION ALL
+		SELECT t.token AS term, s.total_count, FALSE AS is_symbol FROM stock_token t
+		INNER JOIN stock s ON s.symbol = t.symbol
+		WHERE s.is_active = TRUE AND t.word_start = TRUE AND LENGTH(t.token) BETWEEN ?1 AND ?2
+	) v
+	GROUP BY v.term`
+
+// FindVocabulary finds the symbols and name words of active stocks with a length within the
+// given bounds, along with the total mentions of the stocks they belong to.
+func (sr *sqliteStockRepo) FindVocabulary(minLength, maxLength int) ([]domain.Term, error) {
+	rows, err := sr.db.Query(sqliteFindVocabularyQuery, minLength, maxLength)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := make([]domain.Term, 0)
+	for rows.Next() {
+		var t domain.Term
+		err = rows.Scan(&t.Text, &t.Count, &t.IsSymbol)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+
+	return terms, rows.Err()
+}
+
+// FindBySynonyms finds the active stocks which the given synonym terms map to, keyed by term.
+// If a language is specified the stocks are ranked by their mentions in that language.
+func (sr *sqliteStockRepo) FindBySynonyms(terms []string, language string) (map[string][]domain.Stock, error) {
+	var q sqliteQuery
+	if language != "" {
+		q.add(`
+	SELECT ss.term, s.symbol, s.name, COALESCE(lc.mention_count, 0) AS mention_count FROM stock_synonym ss
+	INNER JOIN stock s ON s.symbol = ss.symbol
+	LEFT JOIN stock_language_count lc ON lc.symbol = s.symbol AND lc.language = ?
+	WHERE s.is_active = TRUE AND ss.term IN `, language)
+		q.addList(terms)
+		q.add(`
+	ORDER BY mention_count DESC, s.total_count DESC`)
+	} else {
+		q.add(`
+	SELECT ss.term, s.symbol, s.name, s.total_count FROM stock_synonym ss
+	INNER JOIN stock s ON s.symbol = ss.symbol
+	WHERE s.is_active = TRUE AND ss.term IN `)
+		q.addList(terms)
+		q.add(`
+	ORDER BY s.total_count DESC`)
+	}
+
+	rows, err := sr.db.Query(q.String(), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make(map[string][]domain.Stock)
+	for rows.Next() {
+		var term string
+		var s domain.Stock
+		err = rows.Scan(&term, &s.Symbol, &s.Name, &s.Count)
+		if err != nil {
+			return nil, err
+		}
+		stocks[term] = append(stocks[term], s)
+	}
+
+	return stocks, rows.Err()
+}
+
+// sqliteLastUpdatedQuery selects the latest timestamp as a column rather than
+// with MAX, which would lose the column type and return the timestamp as text.
+const sqliteLastUpdatedQuery = `
+	SELECT updated_at FROM stock WHERE updated_at IS NOT NULL
+	ORDER BY updated_at DESC
+	LIMIT 1`
+
+// LastUpdated finds when a stock was last saved, which is the zero time if no stocks are stored.
+func (sr *sqliteStockRepo) LastUpdated() (time.Time, error) {
+	var updatedAt pq.NullTime
+	err := sr.db.QueryRow(sqliteLastUpdatedQuery).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	return updatedAt.Time, nil
+}
+
+// FindMostCommon finds the most common stocks
+// except the ones that contains the symbols provided.
+// If a language is specified the stocks are ranked by their mentions in that language.
+func (sr *sqliteStockRepo) FindMostCommon(excluded []string, language string, limit int) ([]domain.Stock, error) {
+	var q sqliteQuery
+	if language != "" {
+		q.add(`
+	SELECT s.symbol, s.name, COALESCE(lc.mention_count, 0) AS mention_count FROM stock s
+	LEFT JOIN stock_language_count lc ON lc.symbol = s.symbol AND lc.language = ?
+	WHERE s.is_active = TRUE AND s.symbol NOT IN `, language)
+		q.addList(excluded)
+		q.add(`
+	ORDER BY mention_count DESC, s.total_count DESC
+	LIMIT ?`, limit)
+	} else {
+		q.add(`
+	SELECT symbol, name, total_count FROM stock
+	WHERE is_active = TRUE AND symbol NOT IN `)
+		q.addList(excluded)
+		q.add(`
+	ORDER BY total_count DESC
+	LIMIT ?`, limit)
+	}
+
+	return sr.findStocks(q.String(), q.args...)
+}
+
+func (sr *sqliteStockRepo) findStocks(query string, args ...interface{}) ([]domain.Stock, error) {
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRowsToStocks(rows)
+}
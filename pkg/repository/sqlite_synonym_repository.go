@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// NewSQLiteSynonymRepo creates a SynonymRepo storing synonyms in a database opened with OpenSQLite.
+func NewSQLiteSynonymRepo(db *sql.DB) SynonymRepo {
+	return &sqliteSynonymRepo{
+		pgSynonymRepo: pgSynonymRepo{db: db},
+	}
+}
+
+// sqliteSynonymRepo SQLite implementation of SynonymRepo. Synonyms are saved and deleted
+// with the statements of the postgres implementation, which SQLite also understands.
+type sqliteSynonymRepo struct {
+	pgSynonymRepo
+}
+
+const sqliteFindSynonymsQuery = `
+	SELECT term, symbol, updated_at FROM stock_synonym
+	ORDER BY term, symbol`
+
+// FindAll finds all synonyms ordered by term.
+func (sr *sqliteSynonymRepo) FindAll() ([]domain.Synonym, error) {
+	rows, err := sr.db.Query(sqliteFindSynonymsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := make([]domain.Synonym, 0)
+	for rows.Next() {
+		var term, symbol string
+		var updatedAt time.Time
+		err := rows.Scan(&term, &symbol, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		last := len(synonyms) - 1
+		if last < 0 || synonyms[last].Term != term {
+			synonyms = append(synonyms, domain.Synonym{Term: term, Symbols: []string{}})
+			last++
+		}
+		synonyms[last].Symbols = append(synonyms[last].Symbols, symbol)
+		if updatedAt.After(synonyms[last].UpdatedAt) {
+			synonyms[last].UpdatedAt = updatedAt
+		}
+	}
+
+	return synonyms, rows.Err()
+}
@@ -8,8 +8,8 @@ import (
 
 	"github.com/lib/pq"
 
-	"github.com/mimir-news/pkg/dbutil"
 	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
 )
 
 var (
@@ -19,68 +19,436 @@ var (
 // StockRepo handles storing and retrival of stocks.
 type StockRepo interface {
 	Save(s domain.Stock) error
-	Search(query string, limit int) ([]domain.Stock, error)
-	FindMostCommon(excluded []string, limit int) ([]domain.Stock, error)
+	Find(symbol string) (domain.Stock, error)
+	Search(query, language string, limit int) ([]domain.Stock, error)
+	FindMostCommon(excluded []string, language string, limit int) ([]domain.Stock, error)
+	RefreshSearchIndex() (int, error)
+	FindVocabulary(minLength, maxLength int) ([]domain.Term, error)
+	FindBySynonyms(terms []string, language string) (map[string][]domain.Stock, error)
+	LastUpdated() (time.Time, error)
+}
+
+// StockOptions options for searching stocks.
+// With phonetic matching enabled terms which sound like a word in the name
+// of a stock are matched as a last resort, after exact and prefix matches.
+type StockOptions struct {
+	PhoneticMatching bool
+}
+
+// DefaultStockOptions returns options that only match terms by prefix.
+func DefaultStockOptions() StockOptions {
+	return StockOptions{
+		PhoneticMatching: false,
+	}
 }
 
 // NewStockRepo created a StockRepo using the default implementation.
-func NewStockRepo(db *sql.DB) StockRepo {
+func NewStockRepo(db *sql.DB, opts StockOptions) StockRepo {
 	return &pgStockRepo{
-		db: db,
+		db:   db,
+		opts: opts,
 	}
 }
 
 // pgStockRepo postgres implementation of StockRepo.
 type pgStockRepo struct {
-	db *sql.DB
+	db   *sql.DB
+	opts StockOptions
 }
 
+// searchIndexVersion version of the search index, stocks indexed
+// by an earlier version are reindexed when saved or on startup.
+const searchIndexVersion = 2
+
 const saveStockQuery = `
 	INSERT INTO stock(symbol, name, is_active, total_count, updated_at)
 	VALUES($1, $2, TRUE, $3, $4) ON CONFLICT ON CONSTRAINT stock_pkey 
-	DO UPDATE SET total_count = $3, updated_at = $4`
+	DO UPDATE SET total_count = $3, updated_at = $4
+	RETURNING name, COALESCE(search_version, 0) < $5`
+
+const deleteLanguageCountsQuery = `
+	DELETE FROM stock_language_count WHERE symbol = $1`
+
+const saveLanguageCountQuery = `
+	INSERT INTO stock_language_count(symbol, language, mention_count, updated_at)
+	VALUES($1, $2, $3, $4)`
 
-// Save saves a stock.
+// Save saves a stock along with its per language counts.
 func (pg *pgStockRepo) Save(s domain.Stock) error {
-	res, err := pg.db.Exec(saveStockQuery, s.Symbol, s.Name, s.Count, time.Now().UTC())
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = saveStock(tx, s, time.Now().UTC())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func saveStock(tx *sql.Tx, s domain.Stock, updatedAt time.Time) error {
+	var name string
+	var outdated bool
+	err := tx.QueryRow(saveStockQuery, s.Symbol, s.Name, s.Count, updatedAt, searchIndexVersion).Scan(&name, &outdated)
 	if err != nil {
 		return errInsertStockFailed
 	}
 
-	return dbutil.AssertRowsAffected(res, 1, errInsertStockFailed)
+	_, err = tx.Exec(deleteLanguageCountsQuery, s.Symbol)
+	if err != nil {
+		return err
+	}
+
+	for language, count := range s.LanguageCounts {
+		_, err = tx.Exec(saveLanguageCountQuery, s.Symbol, language, count, updatedAt)
+		if err != nil {
+			return errInsertStockFailed
+		}
+	}
+
+	if outdated && name != "" {
+		return indexStock(tx, s.Symbol, name)
+	}
+
+	return nil
 }
 
-const searchStockQuery = `
+const deleteTokensQuery = `
+	DELETE FROM stock_token WHERE symbol = $1`
+
+const saveTokenQuery = `
+	INSERT INTO stock_token(symbol, token, word_start, phonetic) VALUES($1, $2, $3, NULLIF($4, ''))`
+
+const updateSearchNameQuery = `
+	UPDATE stock SET search_name = $2, search_version = $3 WHERE symbol = $1`
+
+// indexStock stores the folded search name and name tokens of a stock along with their phonetic codes.
+func indexStock(tx *sql.Tx, symbol, name string) error {
+	_, err := tx.Exec(deleteTokensQuery, symbol)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range textutil.Tokens(name) {
+		_, err = tx.Exec(saveTokenQuery, symbol, token.Text, token.WordStart, token.Phonetic)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(updateSearchNameQuery, symbol, textutil.Fold(name), searchIndexVersion)
+	return err
+}
+
+const findStockQuery = `
 	SELECT symbol, name, total_count FROM stock 
-	WHERE is_active = TRUE 
-	AND (
-		LOWER(symbol) LIKE $1 || '%' OR
-		LOWER(name) LIKE $1 || '%'
-	)
-	ORDER BY total_count DESC
-	LIMIT $2`
+	WHERE symbol = $1`
 
-// Search finds stocks mathing a given query.
-func (pg *pgStockRepo) Search(query string, limit int) ([]domain.Stock, error) {
-	lowerQuery := strings.ToLower(query)
-	rows, err := pg.db.Query(searchStockQuery, lowerQuery, limit)
+// Find finds a stock by its symbol.
+func (pg *pgStockRepo) Find(symbol string) (domain.Stock, error) {
+	var s domain.Stock
+	err := pg.db.QueryRow(findStockQuery, symbol).Scan(&s.Symbol, &s.Name, &s.Count)
+	if err == sql.ErrNoRows {
+		return domain.Stock{}, ErrNoSuchStock
+	} else if err != nil {
+		return domain.Stock{}, err
+	}
+
+	return s, nil
+}
+
+// searchStockCondition requires every term in $1 to be a prefix of the symbol,
+// the name or any token of the name, or to sound like a word of the name by its phonetic code in $2.
+const searchStockCondition = `
+	s.is_active = TRUE 
+	AND NOT EXISTS (
+		SELECT 1 FROM UNNEST($1::TEXT[], $2::TEXT[]) AS q(term, code) 
+		WHERE NOT (
+			LOWER(s.symbol) LIKE q.term || '%' ESCAPE '\' OR
+			COALESCE(s.search_name, LOWER(s.name)) LIKE q.term || '%' ESCAPE '\' OR
+			EXISTS (
+				SELECT 1 FROM stock_token t 
+				WHERE t.symbol = s.symbol AND (t.token LIKE q.term || '%' ESCAPE '\' OR t.phonetic = q.code)
+			)
+		)
+	)`
+
+// searchStockRank ranks prefix matches of the full query $3 first, followed by stocks
+// where every term matches the start of a word, matches inside of words and lastly phonetic matches.
+const searchStockRank = `
+	CASE 
+		WHEN LOWER(s.symbol) LIKE $3 || '%' ESCAPE '\' THEN 0 
+		WHEN COALESCE(s.search_name, LOWER(s.name)) LIKE $3 || '%' ESCAPE '\' THEN 0 
+		WHEN NOT EXISTS (
+			SELECT 1 FROM UNNEST($1::TEXT[]) AS term 
+			WHERE NOT (
+				LOWER(s.symbol) LIKE term || '%' ESCAPE '\' OR
+				EXISTS (
+					SELECT 1 FROM stock_token t 
+					WHERE t.symbol = s.symbol AND t.word_start AND t.token LIKE term || '%' ESCAPE '\'
+				)
+			)
+		) THEN 1 
+		WHEN NOT EXISTS (
+			SELECT 1 FROM UNNEST($1::TEXT[]) AS term 
+			WHERE NOT (
+				LOWER(s.symbol) LIKE term || '%' ESCAPE '\' OR
+				COALESCE(s.search_name, LOWER(s.name)) LIKE term || '%' ESCAPE '\' OR
+				EXISTS (
+					SELECT 1 FROM stock_token t 
+					WHERE t.symbol = s.symbol AND t.token LIKE term || '%' ESCAPE '\'
+				)
+			)
+		) THEN 2 
+		ELSE 3 
+	END`
+
+const searchStockQuery = `
+	SELECT s.symbol, s.name, s.total_count FROM stock s
+	WHERE ` + searchStockCondition + `
+	ORDER BY ` + searchStockRank + `, s.total_count DESC
+	LIMIT $4`
+
+const searchStockByLanguageQuery = `
+	SELECT s.symbol, s.name, COALESCE(lc.mention_count, 0) AS mention_count FROM stock s
+	LEFT JOIN stock_language_count lc ON lc.symbol = s.symbol AND lc.language = $4
+	WHERE ` + searchStockCondition + `
+	ORDER BY ` + searchStockRank + `, mention_count DESC, s.total_count DESC
+	LIMIT $5`
+
+// Search finds stocks mathing a given query. Every word of the
+// query must be a prefix of the symbol, the name or any word in the name of a stock,
+// where words are also matched from inside to find parts of compound words.
+// Stocks where the query is a prefix of the symbol or name are ranked first, followed by
+// matches at the start of words. Names are matched accent and case insensitively.
+// With phonetic matching enabled terms may also sound like a word in the name, such
+// matches are ranked last. If a language is specified the stocks are then ranked by
+// their mentions in that language.
+func (pg *pgStockRepo) Search(query, language string, limit int) ([]domain.Stock, error) {
+	terms := textutil.Words(query)
+	codes := pg.phoneticCodes(terms)
+	patterns := likePatterns(terms)
+	fullQuery := escapeLike(strings.Join(terms, " "))
+	if language != "" {
+		return pg.findStocks(searchStockByLanguageQuery, pq.Array(patterns), pq.Array(codes), fullQuery, language, limit)
+	}
+
+	return pg.findStocks(searchStockQuery, pq.Array(patterns), pq.Array(codes), fullQuery, limit)
+}
+
+// likeEscaper escapes the metacharacters of LIKE patterns using backslash as the escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike escapes a search term for use as a LIKE pattern with ESCAPE '\'.
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
+// likePatterns escapes each search term for use as a LIKE pattern.
+func likePatterns(terms []string) []string {
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = escapeLike(term)
+	}
+
+	return patterns
+}
+
+// phoneticCodes returns the phonetic code of each term, codes are
+// left empty if the term is too short or phonetic matching is disabled.
+func (pg *pgStockRepo) phoneticCodes(terms []string) []string {
+	codes := make([]string, len(terms))
+	if !pg.opts.PhoneticMatching {
+		return codes
+	}
+
+	for i, term := range terms {
+		codes[i] = textutil.PhoneticCode(term)
+	}
+
+	return codes
+}
+
+const findUnindexedStocksQuery = `
+	SELECT symbol, name FROM stock WHERE COALESCE(search_version, 0) < $1 AND name <> ''`
+
+// RefreshSearchIndex indexes the names of stocks which were stored by other
+// services or by an earlier version of the index, returning the number of indexed stocks.
+func (pg *pgStockRepo) RefreshSearchIndex() (int, error) {
+	stocks, err := pg.findUnindexedStocks()
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for _, s := range stocks {
+		err = pg.index(s)
+		if err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+
+	return indexed, nil
+}
+
+func (pg *pgStockRepo) findUnindexedStocks() ([]domain.Stock, error) {
+	rows, err := pg.db.Query(findUnindexedStocksQuery, searchIndexVersion)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return mapRowsToStocks(rows)
+	stocks := make([]domain.Stock, 0)
+	for rows.Next() {
+		var s domain.Stock
+		err = rows.Scan(&s.Symbol, &s.Name)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, s)
+	}
+
+	return stocks, nil
+}
+
+func (pg *pgStockRepo) index(s domain.Stock) error {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = indexStock(tx, s.Symbol, s.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const findVocabularyQuery = `
+	SELECT v.term, SUM(v.total_count) AS volume, BOOL_OR(v.is_symbol) FROM (
+		SELECT LOWER(symbol) AS term, total_count, TRUE AS is_symbol FROM stock 
+		WHERE is_active = TRUE AND LENGTH(symbol) BETWEEN $1 AND $2
+		UNION ALL
+		SELECT t.token AS term, s.total_count, FALSE AS is_symbol FROM stock_token t
+		INNER JOIN stock s ON s.symbol = t.symbol
+		WHERE s.is_active = TRUE AND t.word_start = TRUE AND LENGTH(t.token) BETWEEN $1 AND $2
+	) v
+	GROUP BY v.term`
+
+// FindVocabulary finds the symbols and name words of active stocks with a length within the
+// given bounds, along with the total mentions of the stocks they belong to.
+func (pg *pgStockRepo) FindVocabulary(minLength, maxLength int) ([]domain.Term, error) {
+	rows, err := pg.db.Query(findVocabularyQuery, minLength, maxLength)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := make([]domain.Term, 0)
+	for rows.Next() {
+		var t domain.Term
+		err = rows.Scan(&t.Text, &t.Count, &t.IsSymbol)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+
+	return terms, nil
+}
+
+const findSynonymStocksQuery = `
+	SELECT ss.term, s.symbol, s.name, s.total_count FROM stock_synonym ss
+	INNER JOIN stock s ON s.symbol = ss.symbol
+	WHERE s.is_active = TRUE AND ss.term = ANY($1)
+	ORDER BY s.total_count DESC`
+
+const findSynonymStocksByLanguageQuery = `
+	SELECT ss.term, s.symbol, s.name, COALESCE(lc.mention_count, 0) AS mention_count FROM stock_synonym ss
+	INNER JOIN stock s ON s.symbol = ss.symbol
+	LEFT JOIN stock_language_count lc ON lc.symbol = s.symbol AND lc.language = $2
+	WHERE s.is_active = TRUE AND ss.term = ANY($1)
+	ORDER BY mention_count DESC, s.total_count DESC`
+
+// FindBySynonyms finds the active stocks which the given synonym terms map to, keyed by term.
+// If a language is specified the stocks are ranked by their mentions in that language.
+func (pg *pgStockRepo) FindBySynonyms(terms []string, language string) (map[string][]domain.Stock, error) {
+	var rows *sql.Rows
+	var err error
+	if language != "" {
+		rows, err = pg.db.Query(findSynonymStocksByLanguageQuery, pq.Array(terms), language)
+	} else {
+		rows, err = pg.db.Query(findSynonymStocksQuery, pq.Array(terms))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make(map[string][]domain.Stock)
+	for rows.Next() {
+		var term string
+		var s domain.Stock
+		err = rows.Scan(&term, &s.Symbol, &s.Name, &s.Count)
+		if err != nil {
+			return nil, err
+		}
+		stocks[term] = append(stocks[term], s)
+	}
+
+	return stocks, rows.Err()
+}
+
+const lastUpdatedQuery = `
+	SELECT MAX(updated_at) FROM stock`
+
+// LastUpdated finds when a stock was last saved, which is the zero time if no stocks are stored.
+func (pg *pgStockRepo) LastUpdated() (time.Time, error) {
+	var updatedAt pq.NullTime
+	err := pg.db.QueryRow(lastUpdatedQuery).Scan(&updatedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return updatedAt.Time, nil
 }
 
 const suggestStocksQuery = `
 	SELECT symbol, name, total_count FROM stock 
-	WHERE is_active = TRUE AND symbol != ANY($1)
+	WHERE is_active = TRUE AND NOT (symbol = ANY($1))
 	ORDER BY total_count DESC
 	LIMIT $2`
 
+const suggestStocksByLanguageQuery = `
+	SELECT s.symbol, s.name, COALESCE(lc.mention_count, 0) AS mention_count FROM stock s
+	LEFT JOIN stock_language_count lc ON lc.symbol = s.symbol AND lc.language = $2
+	WHERE s.is_active = TRUE AND NOT (s.symbol = ANY($1))
+	ORDER BY mention_count DESC, s.total_count DESC
+	LIMIT $3`
+
 // FindMostCommon finds the most common stocks
 // except the ones that contains the symbols provided.
-func (pg *pgStockRepo) FindMostCommon(excluded []string, limit int) ([]domain.Stock, error) {
-	rows, err := pg.db.Query(suggestStocksQuery, pq.Array(excluded), limit)
+// If a language is specified the stocks are ranked by their mentions in that language.
+func (pg *pgStockRepo) FindMostCommon(excluded []string, language string, limit int) ([]domain.Stock, error) {
+	if excluded == nil {
+		excluded = []string{}
+	}
+
+	if language != "" {
+		return pg.findStocks(suggestStocksByLanguageQuery, pq.Array(excluded), language, limit)
+	}
+
+	return pg.findStocks(suggestStocksQuery, pq.Array(excluded), limit)
+}
+
+func (pg *pgStockRepo) findStocks(query string, args ...interface{}) ([]domain.Stock, error) {
+	rows, err := pg.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +457,7 @@ func (pg *pgStockRepo) FindMostCommon(excluded []string, limit int) ([]domain.St
 }
 
 func mapRowsToStocks(rows *sql.Rows) ([]domain.Stock, error) {
+	defer rows.Close()
 	stocks := make([]domain.Stock, 0)
 
 	for rows.Next() {
@@ -109,17 +478,44 @@ type MockStockRepo struct {
 	SaveErr         error
 	SaveInvocations int
 
+	FindArgSymbol   string
+	FindStock       domain.Stock
+	FindErr         error
+	FindInvocations int
+
 	SearchArgQuery    string
+	SearchArgLanguage string
 	SearchArgLimit    int
 	SearchStocks      []domain.Stock
 	SearchErr         error
 	SearchInvocations int
 
 	FindMostCommonArgExcluded []string
+	FindMostCommonArgLanguage string
 	FindMostCommonArgLimit    int
 	FindMostCommonStocks      []domain.Stock
 	FindMostCommonErr         error
 	FindMostCommonInvocations int
+
+	RefreshSearchIndexIndexed     int
+	RefreshSearchIndexErr         error
+	RefreshSearchIndexInvocations int
+
+	FindVocabularyArgMinLength int
+	FindVocabularyArgMaxLength int
+	FindVocabularyTerms        []domain.Term
+	FindVocabularyErr          error
+	FindVocabularyInvocations  int
+
+	FindBySynonymsArgTerms    []string
+	FindBySynonymsArgLanguage string
+	FindBySynonymsStocks      map[string][]domain.Stock
+	FindBySynonymsErr         error
+	FindBySynonymsInvocations int
+
+	LastUpdatedTime        time.Time
+	LastUpdatedErr         error
+	LastUpdatedInvocations int
 }
 
 // UnsetArgs sets all repo arguments to their default value.
@@ -127,13 +523,30 @@ func (sr *MockStockRepo) UnsetArgs() {
 	sr.SaveArg = domain.Stock{}
 	sr.SaveInvocations = 0
 
+	sr.FindArgSymbol = ""
+	sr.FindInvocations = 0
+
 	sr.SearchArgQuery = ""
+	sr.SearchArgLanguage = ""
 	sr.SearchArgLimit = 0
 	sr.SearchInvocations = 0
 
 	sr.FindMostCommonArgExcluded = nil
+	sr.FindMostCommonArgLanguage = ""
 	sr.FindMostCommonArgLimit = 0
 	sr.FindMostCommonInvocations = 0
+
+	sr.RefreshSearchIndexInvocations = 0
+
+	sr.FindVocabularyArgMinLength = 0
+	sr.FindVocabularyArgMaxLength = 0
+	sr.FindVocabularyInvocations = 0
+
+	sr.FindBySynonymsArgTerms = nil
+	sr.FindBySynonymsArgLanguage = ""
+	sr.FindBySynonymsInvocations = 0
+
+	sr.LastUpdatedInvocations = 0
 }
 
 // Save mock implementation of saving a stock.
@@ -143,18 +556,55 @@ func (sr *MockStockRepo) Save(s domain.Stock) error {
 	return sr.SaveErr
 }
 
+// Find mock implementation of finding a stock.
+func (sr *MockStockRepo) Find(symbol string) (domain.Stock, error) {
+	sr.FindArgSymbol = symbol
+	sr.FindInvocations++
+	return sr.FindStock, sr.FindErr
+}
+
 // Search mock implemntation of searching for stocks.
-func (sr *MockStockRepo) Search(query string, limit int) ([]domain.Stock, error) {
+func (sr *MockStockRepo) Search(query, language string, limit int) ([]domain.Stock, error) {
 	sr.SearchArgQuery = query
+	sr.SearchArgLanguage = language
 	sr.SearchArgLimit = limit
 	sr.SearchInvocations++
 	return sr.SearchStocks, sr.SearchErr
 }
 
 // FindMostCommon mock implementation of finding common stocks.
-func (sr *MockStockRepo) FindMostCommon(excluded []string, limit int) ([]domain.Stock, error) {
+func (sr *MockStockRepo) FindMostCommon(excluded []string, language string, limit int) ([]domain.Stock, error) {
 	sr.FindMostCommonArgExcluded = excluded
+	sr.FindMostCommonArgLanguage = language
 	sr.FindMostCommonArgLimit = limit
 	sr.FindMostCommonInvocations++
 	return sr.FindMostCommonStocks, sr.FindMostCommonErr
 }
+
+// RefreshSearchIndex mock implementation of refreshing the search index.
+func (sr *MockStockRepo) RefreshSearchIndex() (int, error) {
+	sr.RefreshSearchIndexInvocations++
+	return sr.RefreshSearchIndexIndexed, sr.RefreshSearchIndexErr
+}
+
+// FindVocabulary mock implementation of finding the search vocabulary.
+func (sr *MockStockRepo) FindVocabulary(minLength, maxLength int) ([]domain.Term, error) {
+	sr.FindVocabularyArgMinLength = minLength
+	sr.FindVocabularyArgMaxLength = maxLength
+	sr.FindVocabularyInvocations++
+	return sr.FindVocabularyTerms, sr.FindVocabularyErr
+}
+
+// FindBySynonyms mock implementation of finding stocks by synonyms.
+func (sr *MockStockRepo) FindBySynonyms(terms []string, language string) (map[string][]domain.Stock, error) {
+	sr.FindBySynonymsArgTerms = terms
+	sr.FindBySynonymsArgLanguage = language
+	sr.FindBySynonymsInvocations++
+	return sr.FindBySynonymsStocks, sr.FindBySynonymsErr
+}
+
+// LastUpdated mock implementation of finding when a stock was last saved.
+func (sr *MockStockRepo) LastUpdated() (time.Time, error) {
+	sr.LastUpdatedInvocations++
+	return sr.LastUpdatedTime, sr.LastUpdatedErr
+}
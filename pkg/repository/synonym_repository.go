@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// Synonym errors.
+var (
+	ErrNoSuchSynonym = errors.New("no such synonym")
+)
+
+// SynonymRepo handles storing and retrival of the search synonym dictionary.
+type SynonymRepo interface {
+	Save(synonym domain.Synonym) error
+	Delete(term string) error
+	FindAll() ([]domain.Synonym, error)
+	Import(synonyms []domain.Synonym, replace bool) error
+}
+
+// NewSynonymRepo creates a SynonymRepo using the default implementation.
+func NewSynonymRepo(db *sql.DB) SynonymRepo {
+	return &pgSynonymRepo{
+		db: db,
+	}
+}
+
+// pgSynonymRepo postgres implementation of SynonymRepo.
+type pgSynonymRepo struct {
+	db *sql.DB
+}
+
+// Save stores a synonym, replacing the symbols of the term if it already exists.
+func (pg *pgSynonymRepo) Save(synonym domain.Synonym) error {
+	return pg.Import([]domain.Synonym{synonym}, false)
+}
+
+const deleteSynonymQuery = `
+	DELETE FROM stock_synonym WHERE term = $1`
+
+// Delete removes a term from the synonym dictionary.
+func (pg *pgSynonymRepo) Delete(term string) error {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = deleteSynonym(tx, term)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func deleteSynonym(tx *sql.Tx, term string) error {
+	res, err := tx.Exec(deleteSynonymQuery, term)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNoSuchSynonym
+	}
+
+	return bumpVersion(tx, SynonymVersion)
+}
+
+const findSynonymsQuery = `
+	SELECT term, ARRAY_AGG(symbol ORDER BY symbol), MAX(updated_at) FROM stock_synonym
+	GROUP BY term
+	ORDER BY term`
+
+// FindAll finds all synonyms ordered by term.
+func (pg *pgSynonymRepo) FindAll() ([]domain.Synonym, error) {
+	rows, err := pg.db.Query(findSynonymsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	synonyms := make([]domain.Synonym, 0)
+	for rows.Next() {
+		var s domain.Synonym
+		err := rows.Scan(&s.Term, pq.Array(&s.Symbols), &s.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		synonyms = append(synonyms, s)
+	}
+
+	return synonyms, rows.Err()
+}
+
+const deleteAllSynonymsQuery = `
+	DELETE FROM stock_synonym`
+
+const saveSynonymQuery = `
+	INSERT INTO stock_synonym(term, symbol, updated_at) VALUES($1, $2, $3)`
+
+// Import stores a list of synonyms in a single transaction, replacing the symbols of existing
+// terms. If replace is true all other terms are removed from the dictionary.
+func (pg *pgSynonymRepo) Import(synonyms []domain.Synonym, replace bool) error {
+	tx, err := pg.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = importSynonyms(tx, synonyms, replace)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func importSynonyms(tx *sql.Tx, synonyms []domain.Synonym, replace bool) error {
+	if replace {
+		_, err := tx.Exec(deleteAllSynonymsQuery)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range synonyms {
+		_, err := tx.Exec(deleteSynonymQuery, s.Term)
+		if err != nil {
+			return err
+		}
+
+		for _, symbol := range s.Symbols {
+			_, err = tx.Exec(saveSynonymQuery, s.Term, symbol, s.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return bumpVersion(tx, SynonymVersion)
+}
+
+// MockSynonymRepo mock implementation of SynonymRepo.
+type MockSynonymRepo struct {
+	SaveArg         domain.Synonym
+	SaveErr         error
+	SaveInvocations int
+
+	DeleteArg         string
+	DeleteErr         error
+	DeleteInvocations int
+
+	FindAllSynonyms    []domain.Synonym
+	FindAllErr         error
+	FindAllInvocations int
+
+	ImportArgSynonyms []domain.Synonym
+	ImportArgReplace  bool
+	ImportErr         error
+	ImportInvocations int
+}
+
+// UnsetArgs sets all repo arguments to their default value.
+func (sr *MockSynonymRepo) UnsetArgs() {
+	sr.SaveArg = domain.Synonym{}
+	sr.SaveInvocations = 0
+
+	sr.DeleteArg = ""
+	sr.DeleteInvocations = 0
+
+	sr.FindAllInvocations = 0
+
+	sr.ImportArgSynonyms = nil
+	sr.ImportArgReplace = false
+	sr.ImportInvocations = 0
+}
+
+// Save mock implementation of saving a synonym.
+func (sr *MockSynonymRepo) Save(synonym domain.Synonym) error {
+	sr.SaveArg = synonym
+	sr.SaveInvocations++
+	return sr.SaveErr
+}
+
+// Delete mock implementation of deleting a synonym.
+func (sr *MockSynonymRepo) Delete(term string) error {
+	sr.DeleteArg = term
+	sr.DeleteInvocations++
+	return sr.DeleteErr
+}
+
+// FindAll mock implementation of finding all synonyms.
+func (sr *MockSynonymRepo) FindAll() ([]domain.Synonym, error) {
+	sr.FindAllInvocations++
+	return sr.FindAllSynonyms, sr.FindAllErr
+}
+
+// Import mock implementation of importing synonyms.
+func (sr *MockSynonymRepo) Import(synonyms []domain.Synonym, replace bool) error {
+	sr.ImportArgSynonyms = synonyms
+	sr.ImportArgReplace = replace
+	sr.ImportInvocations++
+	return sr.ImportErr
+}
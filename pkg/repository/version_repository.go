@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// Names of versioned data sets.
+const (
+	SynonymVersion = "synonym"
+	AnomalyVersion = "anomaly"
+)
+
+// VersionRepo finds the versions of data sets which cached results depend on. The version
+// of a data set is bumped by each write to it, data sets never written to are missing.
+type VersionRepo interface {
+	FindVersions() (map[string]domain.DataVersion, error)
+}
+
+// NewVersionRepo creates a VersionRepo using the default implementation.
+func NewVersionRepo(db *sql.DB) VersionRepo {
+	return &pgVersionRepo{
+		db: db,
+	}
+}
+
+// pgVersionRepo postgres implementation of VersionRepo, also used with SQLite.
+type pgVersionRepo struct {
+	db *sql.DB
+}
+
+const findVersionsQuery = `
+	SELECT name, version, updated_at FROM data_version`
+
+// FindVersions finds the versions of all data sets which have been written to.
+func (pg *pgVersionRepo) FindVersions() (map[string]domain.DataVersion, error) {
+	rows, err := pg.db.Query(findVersionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[string]domain.DataVersion)
+	for rows.Next() {
+		var name string
+		var v domain.DataVersion
+		err := rows.Scan(&name, &v.Version, &v.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions[name] = v
+	}
+
+	return versions, rows.Err()
+}
+
+const bumpVersionQuery = `
+	INSERT INTO data_version(name, version, updated_at) VALUES($1, 1, $2)
+	ON CONFLICT (name) DO UPDATE SET version = data_version.version + 1, updated_at = $2`
+
+// bumpVersion increments the version of a data set within the transaction writing to it.
+func bumpVersion(tx *sql.Tx, name string) error {
+	_, err := tx.Exec(bumpVersionQuery, name, time.Now().UTC())
+	return err
+}
+
+// MockVersionRepo mock implementation of VersionRepo.
+type MockVersionRepo struct {
+	FindVersionsResult      map[string]domain.DataVersion
+	FindVersionsErr         error
+	FindVersionsInvocations int
+}
+
+// UnsetArgs sets all repo arguments to their default value.
+func (vr *MockVersionRepo) UnsetArgs() {
+	vr.FindVersionsInvocations = 0
+}
+
+// FindVersions mock implementation of finding data set versions.
+func (vr *MockVersionRepo) FindVersions() (map[string]domain.DataVersion, error) {
+	vr.FindVersionsInvocations++
+	return vr.FindVersionsResult, vr.FindVersionsErr
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// Webhook errors.
+var (
+	ErrNoSuchSubscription = errors.New("no such subscription")
+)
+
+// WebhookRepo handles storing and retrival of webhook subscriptions and deliveries.
+type WebhookRepo interface {
+	SaveSubscription(s domain.Subscription) error
+	DeleteSubscription(id string) error
+	FindSubscriptions() ([]domain.Subscription, error)
+	SaveDelivery(d domain.Delivery) error
+	FindDeliveries(subscriptionID string, limit int) ([]domain.Delivery, error)
+	PruneDeliveries(subscriptionID string, keep int) error
+}
+
+// NewWebhookRepo creates a WebhookRepo using the default implementation.
+func NewWebhookRepo(db *sql.DB) WebhookRepo {
+	return &pgWebhookRepo{
+		db: db,
+	}
+}
+
+// pgWebhookRepo postgres implementation of WebhookRepo.
+type pgWebhookRepo struct {
+	db *sql.DB
+}
+
+const saveSubscriptionQuery = `
+	INSERT INTO webhook_subscription(id, url, secret, events, created_at)
+	VALUES($1, $2, $3, $4, $5)`
+
+// SaveSubscription saves a new webhook subscription.
+func (pg *pgWebhookRepo) SaveSubscription(s domain.Subscription) error {
+	events := strings.Join(s.Events, ",")
+	_, err := pg.db.Exec(saveSubscriptionQuery, s.ID, s.URL, s.Secret, events, s.CreatedAt)
+	return err
+}
+
+const deleteSubscriptionQuery = `
+	DELETE FROM webhook_subscription WHERE id = $1`
+
+// DeleteSubscription deletes a webhook subscription.
+func (pg *pgWebhookRepo) DeleteSubscription(id string) error {
+	res, err := pg.db.Exec(deleteSubscriptionQuery, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNoSuchSubscription
+	}
+
+	return nil
+}
+
+const findSubscriptionsQuery = `
+	SELECT id, url, secret, events, created_at FROM webhook_subscription
+	ORDER BY created_at`
+
+// FindSubscriptions finds all webhook subscriptions.
+func (pg *pgWebhookRepo) FindSubscriptions() ([]domain.Subscription, error) {
+	rows, err := pg.db.Query(findSubscriptionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]domain.Subscription, 0)
+	for rows.Next() {
+		var s domain.Subscription
+		var events string
+		err := rows.Scan(&s.ID, &s.URL, &s.Secret, &events, &s.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		s.Events = strings.Split(events, ",")
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+const saveDeliveryQuery = `
+	INSERT INTO webhook_delivery(
+		id, subscription_id, event_id, event, attempt, status_code, error, success, created_at)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+// SaveDelivery saves a delivery attempt.
+func (pg *pgWebhookRepo) SaveDelivery(d domain.Delivery) error {
+	_, err := pg.db.Exec(saveDeliveryQuery, d.ID, d.SubscriptionID, d.EventID,
+		d.Event, d.Attempt, d.StatusCode, d.Error, d.Success, d.CreatedAt)
+	return err
+}
+
+const findDeliveriesQuery = `
+	SELECT id, subscription_id, event_id, event, attempt, status_code, error, success, created_at 
+	FROM webhook_delivery
+	WHERE subscription_id = $1
+	ORDER BY created_at DESC
+	LIMIT $2`
+
+// FindDeliveries finds the most recent delivery attempts of a subscription.
+func (pg *pgWebhookRepo) FindDeliveries(subscriptionID string, limit int) ([]domain.Delivery, error) {
+	rows, err := pg.db.Query(findDeliveriesQuery, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.Delivery, 0)
+	for rows.Next() {
+		var d domain.Delivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.Event,
+			&d.Attempt, &d.StatusCode, &d.Error, &d.Success, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+const pruneDeliveriesQuery = `
+	DELETE FROM webhook_delivery
+	WHERE subscription_id = $1
+	AND id NOT IN (
+		SELECT id FROM webhook_delivery
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2)`
+
+// PruneDeliveries deletes all but the given number of the most recent delivery attempts of a subscription.
+func (pg *pgWebhookRepo) PruneDeliveries(subscriptionID string, keep int) error {
+	_, err := pg.db.Exec(pruneDeliveriesQuery, subscriptionID, keep)
+	return err
+}
+
+// MockWebhookRepo mock implementation of WebhookRepo.
+type MockWebhookRepo struct {
+	mu sync.Mutex
+
+	SaveSubscriptionArg         domain.Subscription
+	SaveSubscriptionErr         error
+	SaveSubscriptionInvocations int
+
+	DeleteSubscriptionArg         string
+	DeleteSubscriptionErr         error
+	DeleteSubscriptionInvocations int
+
+	FindSubscriptionsResult      []domain.Subscription
+	FindSubscriptionsErr         error
+	FindSubscriptionsInvocations int
+
+	SaveDeliveryArgs        []domain.Delivery
+	SaveDeliveryErr         error
+	SaveDeliveryInvocations int
+
+	FindDeliveriesArgID       string
+	FindDeliveriesArgLimit    int
+	FindDeliveriesResult      []domain.Delivery
+	FindDeliveriesErr         error
+	FindDeliveriesInvocations int
+
+	PruneDeliveriesArgID       string
+	PruneDeliveriesArgKeep     int
+	PruneDeliveriesErr         error
+	PruneDeliveriesInvocations int
+}
+
+// UnsetArgs sets all repo arguments to their default value.
+func (wr *MockWebhookRepo) UnsetArgs() {
+	wr.SaveSubscriptionArg = domain.Subscription{}
+	wr.SaveSubscriptionInvocations = 0
+
+	wr.DeleteSubscriptionArg = ""
+	wr.DeleteSubscriptionInvocations = 0
+
+	wr.FindSubscriptionsInvocations = 0
+
+	wr.SaveDeliveryArgs = nil
+	wr.SaveDeliveryInvocations = 0
+
+	wr.FindDeliveriesArgID = ""
+	wr.FindDeliveriesArgLimit = 0
+	wr.FindDeliveriesInvocations = 0
+
+	wr.PruneDeliveriesArgID = ""
+	wr.PruneDeliveriesArgKeep = 0
+	wr.PruneDeliveriesInvocations = 0
+}
+
+// SaveSubscription mock implementation of saving a subscription.
+func (wr *MockWebhookRepo) SaveSubscription(s domain.Subscription) error {
+	wr.SaveSubscriptionArg = s
+	wr.SaveSubscriptionInvocations++
+	return wr.SaveSubscriptionErr
+}
+
+// DeleteSubscription mock implementation of deleting a subscription.
+func (wr *MockWebhookRepo) DeleteSubscription(id string) error {
+	wr.DeleteSubscriptionArg = id
+	wr.DeleteSubscriptionInvocations++
+	return wr.DeleteSubscriptionErr
+}
+
+// FindSubscriptions mock implementation of finding subscriptions.
+func (wr *MockWebhookRepo) FindSubscriptions() ([]domain.Subscription, error) {
+	wr.FindSubscriptionsInvocations++
+	return wr.FindSubscriptionsResult, wr.FindSubscriptionsErr
+}
+
+// SaveDelivery mock implementation of saving a delivery.
+func (wr *MockWebhookRepo) SaveDelivery(d domain.Delivery) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.SaveDeliveryArgs = append(wr.SaveDeliveryArgs, d)
+	wr.SaveDeliveryInvocations++
+	return wr.SaveDeliveryErr
+}
+
+// FindDeliveries mock implementation of finding deliveries.
+func (wr *MockWebhookRepo) FindDeliveries(subscriptionID string, limit int) ([]domain.Delivery, error) {
+	wr.FindDeliveriesArgID = subscriptionID
+	wr.FindDeliveriesArgLimit = limit
+	wr.FindDeliveriesInvocations++
+	return wr.FindDeliveriesResult, wr.FindDeliveriesErr
+}
+
+// PruneDeliveries mock implementation of pruning deliveries.
+func (wr *MockWebhookRepo) PruneDeliveries(subscriptionID string, keep int) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.PruneDeliveriesArgID = subscriptionID
+	wr.PruneDeliveriesArgKeep = keep
+	wr.PruneDeliveriesInvocations++
+	return wr.PruneDeliveriesErr
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/webhook"
+)
+
+// AnomalyEventName name of the webhook event sent when anomalies are detected.
+const AnomalyEventName = "stock.anomalies"
+
+// AnomalyOptions configuration of anomaly detection.
+type AnomalyOptions struct {
+	Enabled     bool
+	WindowDays  int
+	Threshold   float64
+	MinMentions int64
+	WebhookURLs []string
+}
+
+// DefaultAnomalyOptions returns options that flag days deviating more
+// than three standard deviations from the preceding four weeks.
+func DefaultAnomalyOptions() AnomalyOptions {
+	return AnomalyOptions{
+		Enabled:     false,
+		WindowDays:  28,
+		Threshold:   3,
+		MinMentions: 10,
+		WebhookURLs: []string{},
+	}
+}
+
+// AnomalyService service for detecting and retrieving mention anomalies.
+type AnomalyService interface {
+	RankingListener
+	DetectAnomalies() ([]domain.Anomaly, error)
+	GetAnomalies(limit int) ([]domain.Anomaly, error)
+	Wait(timeout time.Duration) bool
+}
+
+// NewAnomalyService creates an AnomalyService using the default implementation.
+func NewAnomalyService(anomalyRepo repository.AnomalyRepo, countRepo repository.CountRepo,
+	sender webhook.Sender, opts AnomalyOptions) AnomalyService {
+	return &anomalySvc{
+		anomalyRepo: anomalyRepo,
+		countRepo:   countRepo,
+		sender:      sender,
+		opts:        opts,
+	}
+}
+
+type anomalySvc struct {
+	anomalyRepo repository.AnomalyRepo
+	countRepo   repository.CountRepo
+	sender      webhook.Sender
+	opts        AnomalyOptions
+	detecting   int32
+	detections  sync.WaitGroup
+}
+
+type anomalyNotification struct {
+	Event     string           `json:"event"`
+	Anomalies []domain.Anomaly `json:"anomalies"`
+}
+
+// BeforeRanking does nothing as anomalies are detected after ranking.
+func (svc *anomalySvc) BeforeRanking(symbol string) {}
+
+// OnRanking starts detecting anomalies in the background after all stocks have been ranked.
+// Only the last complete day is examined, so rankings finishing while anomalies are
+// being detected do not start another detection.
+func (svc *anomalySvc) OnRanking(event domain.RankingEvent) {
+	if !svc.opts.Enabled || event.Symbol != "" {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&svc.detecting, 0, 1) {
+		return
+	}
+
+	svc.detections.Add(1)
+	go func() {
+		defer svc.detections.Done()
+		defer atomic.StoreInt32(&svc.detecting, 0)
+
+		_, err := svc.DetectAnomalies()
+		if err != nil {
+			log.Println("Anomaly detection failed:", err)
+		}
+	}()
+}
+
+// Wait waits for anomalies being detected in the background, returning false if the timeout expires first.
+func (svc *anomalySvc) Wait(timeout time.Duration) bool {
+	return waitTimeout(&svc.detections, timeout)
+}
+
+// DetectAnomalies compares the mentions of each stock during the last complete day with its
+// rolling history and stores deviating stocks. Only anomalies which were not stored before
+// are notified about, so each anomaly is notified once however often it is detected.
+func (svc *anomalySvc) DetectAnomalies() ([]domain.Anomaly, error) {
+	now := time.Now().UTC()
+	day := startOfDay(now).AddDate(0, 0, -1)
+	since := day.AddDate(0, 0, -svc.opts.WindowDays)
+
+	counts, err := svc.countRepo.CountDaily(since)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := findAnomalies(counts, day, svc.opts)
+	newAnomalies := make([]domain.Anomaly, 0, len(anomalies))
+	for i := range anomalies {
+		anomalies[i].DetectedAt = now
+		isNew, err := svc.anomalyRepo.Save(anomalies[i])
+		if err != nil {
+			svc.notify(newAnomalies)
+			return nil, err
+		}
+
+		if isNew {
+			newAnomalies = append(newAnomalies, anomalies[i])
+		}
+	}
+
+	svc.notify(newAnomalies)
+	return anomalies, nil
+}
+
+// GetAnomalies gets the most recently detected anomalies.
+func (svc *anomalySvc) GetAnomalies(limit int) ([]domain.Anomaly, error) {
+	return svc.anomalyRepo.FindRecent(limit)
+}
+
+func (svc *anomalySvc) notify(anomalies []domain.Anomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+
+	payload := anomalyNotification{
+		Event:     AnomalyEventName,
+		Anomalies: anomalies,
+	}
+
+	for _, url := range svc.opts.WebhookURLs {
+		err := svc.sender.Send(url, payload)
+		if err != nil {
+			log.Println("Anomaly notification failed:", err)
+		}
+	}
+}
+
+// findAnomalies finds the stocks whose count on the given day deviates more than the
+// threshold number of standard deviations from the mean of the preceding window.
+// Days without counts in the window are treated as days without mentions.
+func findAnomalies(counts []domain.DailyCount, day time.Time, opts AnomalyOptions) []domain.Anomaly {
+	history := make(map[string][]int64)
+	for _, c := range counts {
+		offset := int(day.Sub(c.Day).Hours() / 24)
+		if offset < 0 || offset > opts.WindowDays {
+			continue
+		}
+
+		days, ok := history[c.Symbol]
+		if !ok {
+			days = make([]int64, opts.WindowDays+1)
+			history[c.Symbol] = days
+		}
+		days[offset] += c.Count
+	}
+
+	anomalies := make([]domain.Anomaly, 0)
+	for symbol, days := range history {
+		current := days[0]
+		mean, stdDev := meanAndStdDev(days[1:])
+		if current < opts.MinMentions && mean < float64(opts.MinMentions) {
+			continue
+		}
+
+		deviation := (float64(current) - mean) / math.Max(stdDev, 1)
+		if math.Abs(deviation) < opts.Threshold {
+			continue
+		}
+
+		anomalies = append(anomalies, domain.Anomaly{
+			Symbol:    symbol,
+			Day:       day,
+			Count:     current,
+			Mean:      mean,
+			StdDev:    stdDev,
+			Deviation: deviation,
+		})
+	}
+
+	return anomalies
+}
+
+func meanAndStdDev(values []int64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+
+	var squaredDiffs float64
+	for _, v := range values {
+		diff := float64(v) - mean
+		squaredDiffs += diff * diff
+	}
+
+	return mean, math.Sqrt(squaredDiffs / float64(len(values)))
+}
+
+func startOfDay(t time.Time) time.Time {
+	utc := t.UTC()
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAnomalies(t *testing.T) {
+	assert := assert.New(t)
+
+	day := time.Date(2019, 1, 29, 0, 0, 0, 0, time.UTC)
+	opts := AnomalyOptions{WindowDays: 5, Threshold: 3, MinMentions: 10}
+
+	counts := []domain.DailyCount{
+		domain.DailyCount{Symbol: "AAPL", Day: day.AddDate(0, 0, -5), Count: 10},
+		domain.DailyCount{Symbol: "AAPL", Day: day.AddDate(0, 0, -4), Count: 12},
+		domain.DailyCount{Symbol: "AAPL", Day: day.AddDate(0, 0, -3), Count: 8},
+		domain.DailyCount{Symbol: "AAPL", Day: day.AddDate(0, 0, -2), Count: 10},
+		domain.DailyCount{Symbol: "AAPL", Day: day.AddDate(0, 0, -1), Count: 10},
+		domain.DailyCount{Symbol: "AAPL", Day: day, Count: 100},
+		domain.DailyCount{Symbol: "AMD", Day: day.AddDate(0, 0, -1), Count: 20},
+		domain.DailyCount{Symbol: "AMD", Day: day, Count: 22},
+		domain.DailyCount{Symbol: "TSLA", Day: day, Count: 5},
+		domain.DailyCount{Symbol: "TSLA", Day: day.AddDate(0, 0, -10), Count: 500},
+	}
+
+	anomalies := findAnomalies(counts, day, opts)
+	assert.Equal(1, len(anomalies))
+	assert.Equal("AAPL", anomalies[0].Symbol)
+	assert.Equal(int64(100), anomalies[0].Count)
+	assert.Equal(10.0, anomalies[0].Mean)
+	assert.True(anomalies[0].Deviation > 3)
+	assert.Equal(day, anomalies[0].Day)
+}
+
+func TestDetectAnomaliesOnRanking(t *testing.T) {
+	assert := assert.New(t)
+
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+	countRepo := &repository.MockCountRepo{
+		CountDailyCounts: []domain.DailyCount{
+			domain.DailyCount{Symbol: "AAPL", Day: yesterday, Count: 50},
+		},
+	}
+	anomalyRepo := &repository.MockAnomalyRepo{SaveNew: true}
+	sender := &webhook.MockSender{}
+
+	opts := DefaultAnomalyOptions()
+	opts.WebhookURLs = []string{"http://desk-1", "http://desk-2"}
+	svc := NewAnomalyService(anomalyRepo, countRepo, sender, opts)
+
+	svc.OnRanking(domain.RankingEvent{})
+	assert.True(svc.Wait(time.Second))
+	assert.Equal(0, countRepo.CountDailyInvocations)
+
+	opts.Enabled = true
+	svc = NewAnomalyService(anomalyRepo, countRepo, sender, opts)
+
+	svc.OnRanking(domain.RankingEvent{Symbol: "AAPL"})
+	assert.True(svc.Wait(time.Second))
+	assert.Equal(0, countRepo.CountDailyInvocations)
+
+	svc.OnRanking(domain.RankingEvent{})
+	assert.True(svc.Wait(time.Second))
+	assert.Equal(1, countRepo.CountDailyInvocations)
+	assert.Equal(yesterday.AddDate(0, 0, -opts.WindowDays), countRepo.CountDailyArgSince)
+	assert.Equal(1, anomalyRepo.SaveInvocations)
+	assert.Equal("AAPL", anomalyRepo.SaveArgs[0].Symbol)
+	assert.Equal([]string{"http://desk-1", "http://desk-2"}, sender.SendArgURLs)
+
+	anomalyRepo.SaveNew = false
+	svc.OnRanking(domain.RankingEvent{})
+	assert.True(svc.Wait(time.Second))
+	assert.Equal(2, countRepo.CountDailyInvocations)
+	assert.Equal(2, anomalyRepo.SaveInvocations)
+	assert.Equal([]string{"http://desk-1", "http://desk-2"}, sender.SendArgURLs)
+}
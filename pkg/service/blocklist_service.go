@@ -0,0 +1,58 @@
+package service
+
+import (
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+)
+
+// BlocklistService service for managing authors excluded from ranking.
+type BlocklistService interface {
+	Block(authorID, reason string) (domain.BlockedAuthor, error)
+	Unblock(authorID string) error
+	GetBlocked() ([]domain.BlockedAuthor, error)
+}
+
+// NewBlocklistService creates a BlocklistService using the default implementation.
+func NewBlocklistService(blocklistRepo repository.BlocklistRepo) BlocklistService {
+	return &blocklistSvc{
+		blocklistRepo: blocklistRepo,
+	}
+}
+
+type blocklistSvc struct {
+	blocklistRepo repository.BlocklistRepo
+}
+
+// Block adds an author to the blocklist.
+func (svc *blocklistSvc) Block(authorID, reason string) (domain.BlockedAuthor, error) {
+	author := domain.BlockedAuthor{
+		AuthorID:  authorID,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err := svc.blocklistRepo.Save(author)
+	if err != nil {
+		return domain.BlockedAuthor{}, err
+	}
+
+	return author, nil
+}
+
+// Unblock removes an author from the blocklist.
+func (svc *blocklistSvc) Unblock(authorID string) error {
+	err := svc.blocklistRepo.Delete(authorID)
+	if err == repository.ErrNoSuchAuthor {
+		return apierror.NotFound("No such author: " + authorID)
+	}
+
+	return err
+}
+
+// GetBlocked lists all blocked authors.
+func (svc *blocklistSvc) GetBlocked() ([]domain.BlockedAuthor, error) {
+	return svc.blocklistRepo.FindAll()
+}
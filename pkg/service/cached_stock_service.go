@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/cache"
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// CacheOptions configuration of the search and suggestion cache. VersionInterval is
+// the longest time the data versions cached results are validated against are reused.
+type CacheOptions struct {
+	Enabled         bool
+	Size            int
+	TTL             time.Duration
+	VersionInterval time.Duration
+}
+
+// DefaultCacheOptions returns options caching up to 1000 results for five minutes,
+// reading the data versions at most every five seconds.
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		Enabled:         true,
+		Size:            1000,
+		TTL:             5 * time.Minute,
+		VersionInterval: 5 * time.Second,
+	}
+}
+
+// CacheInvalidator is notified when data which cached results depend on has changed.
+type CacheInvalidator interface {
+	Invalidate()
+}
+
+// CachedStockService StockService caching search results and suggestions.
+type CachedStockService interface {
+	StockService
+	CacheInvalidator
+	CacheStats() cache.Stats
+}
+
+// StockCache cache of search results and suggestions, which is purged when a ranking completes.
+// It must be registered as the first ranking listener of the stock service it caches, so
+// no listener is served results cached before the ranking.
+type StockCache struct {
+	lru *cache.LRU
+}
+
+// NewStockCache creates an empty StockCache.
+func NewStockCache(opts CacheOptions) *StockCache {
+	return &StockCache{
+		lru: cache.New(opts.Size, opts.TTL),
+	}
+}
+
+// BeforeRanking does nothing as results only change once the ranking has completed.
+func (c *StockCache) BeforeRanking(symbol string) {}
+
+// OnRanking purges the cache as the ranking changes the order of results.
+func (c *StockCache) OnRanking(event domain.RankingEvent) {
+	c.lru.Purge()
+}
+
+// NewCachedStockService wraps a StockService with a read-through cache of search results and
+// suggestions, keyed on the normalized parameters. Since other replicas may rank stocks and edit
+// synonyms, the cache is also purged whenever the tracked last ranking time or synonym version has
+// changed since results were cached, as well as when Invalidate is called.
+func NewCachedStockService(stockSvc StockService, stockCache *StockCache, tracker *VersionTracker) CachedStockService {
+	return &cachedStockSvc{
+		StockService: stockSvc,
+		cache:        stockCache.lru,
+		tracker:      tracker,
+	}
+}
+
+type cachedStockSvc struct {
+	StockService
+	cache   *cache.LRU
+	tracker *VersionTracker
+
+	mu      sync.Mutex
+	version cacheVersion
+}
+
+// cacheVersion the versions of the data cached results were computed from.
+type cacheVersion struct {
+	lastUpdated time.Time
+	synonyms    int64
+}
+
+func (v cacheVersion) equal(other cacheVersion) bool {
+	return v.lastUpdated.Equal(other.lastUpdated) && v.synonyms == other.synonyms
+}
+
+// Search returns cached results of an equivalent query. Queries are equivalent if they
+// only differ in case and whitespace, the raw query of the result is the one given.
+func (svc *cachedStockSvc) Search(query, language string, limit int, highlight bool) (domain.SearchResult, error) {
+	svc.validate()
+	normalized := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	key := fmt.Sprintf("search|%s|%s|%d|%t", normalized, language, limit, highlight)
+	value, err := svc.cache.Load(key, func() (interface{}, error) {
+		return svc.StockService.Search(query, language, limit, highlight)
+	})
+	if err != nil {
+		return domain.SearchResult{}, err
+	}
+
+	result := value.(domain.SearchResult)
+	result.Query.Raw = query
+	return result, nil
+}
+
+// GetSuggestions returns cached suggestions for the same excluded symbols in any order.
+func (svc *cachedStockSvc) GetSuggestions(excluded []string, language string, limit int) ([]stock.Stock, error) {
+	symbols := make([]string, 0, len(excluded))
+	for _, symbol := range excluded {
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+	sort.Strings(symbols)
+
+	svc.validate()
+	key := fmt.Sprintf("suggestions|%s|%s|%d", strings.Join(symbols, ","), language, limit)
+	value, err := svc.cache.Load(key, func() (interface{}, error) {
+		return svc.StockService.GetSuggestions(excluded, language, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]stock.Stock), nil
+}
+
+// validate purges the cache if stocks have been ranked or synonyms have been edited by any replica
+// since results were cached. If the versions cannot be found the cache is purged to be safe.
+func (svc *cachedStockSvc) validate() {
+	versions, err := svc.tracker.Versions()
+	if err != nil {
+		log.Println("Failed to get cache version:", err)
+	}
+	version := cacheVersion{lastUpdated: versions.LastRanking, synonyms: versions.Synonyms.Version}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if err != nil || !version.equal(svc.version) {
+		svc.cache.Purge()
+		svc.version = version
+	}
+}
+
+// Invalidate removes all cached results.
+func (svc *cachedStockSvc) Invalidate() {
+	svc.cache.Purge()
+}
+
+// CacheStats returns the hit and miss counts of the cache.
+func (svc *cachedStockSvc) CacheStats() cache.Stats {
+	return svc.cache.Stats()
+}
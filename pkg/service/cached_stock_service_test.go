@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedStockServiceSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		SearchStocks: []domain.Stock{domain.Stock{Symbol: "AAPL", Name: "Apple Inc."}},
+	}
+	countRepo := &repository.MockCountRepo{}
+	stockCache := NewStockCache(DefaultCacheOptions())
+	svc := NewCachedStockService(NewStockService(stockRepo, countRepo, repository.NewMemoryRankingLock(), stockCache),
+		stockCache, NewVersionTracker(stockRepo, &repository.MockVersionRepo{}, 0))
+
+	result, err := svc.Search("apple", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(1, stockRepo.SearchInvocations)
+	assert.Equal(1, len(result.Stocks(10)))
+
+	result, err = svc.Search("  Apple ", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(1, stockRepo.SearchInvocations)
+	assert.Equal("  Apple ", result.Query.Raw)
+
+	svc.Search("apple", "sv", 10, false)
+	svc.Search("apple", "", 5, false)
+	svc.Search("apple", "", 10, true)
+	assert.Equal(4, stockRepo.SearchInvocations)
+
+	stats := svc.CacheStats()
+	assert.Equal(int64(1), stats.Hits)
+	assert.Equal(int64(4), stats.Misses)
+	assert.Equal(4, stats.Entries)
+
+	_, err = svc.RankStocks()
+	assert.NoError(err)
+	assert.Equal(0, svc.CacheStats().Entries)
+	svc.Search("apple", "", 10, false)
+	assert.Equal(5, stockRepo.SearchInvocations)
+
+	stockRepo.SearchErr = errors.New("search failed")
+	_, err = svc.Search("microsoft", "", 10, false)
+	assert.Error(err)
+	_, err = svc.Search("microsoft", "", 10, false)
+	assert.Error(err)
+	assert.Equal(7, stockRepo.SearchInvocations)
+}
+
+func TestCachedStockServiceSuggestions(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{domain.Stock{Symbol: "TSLA"}},
+	}
+	stockCache := NewStockCache(CacheOptions{Enabled: true, Size: 10, TTL: time.Minute})
+	svc := NewCachedStockService(NewStockService(stockRepo, nil, repository.NewMemoryRankingLock(), stockCache),
+		stockCache, NewVersionTracker(stockRepo, &repository.MockVersionRepo{}, 0))
+
+	stocks, err := svc.GetSuggestions([]string{"AAPL", "msft"}, "", 5)
+	assert.NoError(err)
+	assert.Equal(1, len(stocks))
+	svc.GetSuggestions([]string{"MSFT", "AAPL"}, "", 5)
+	assert.Equal(1, stockRepo.FindMostCommonInvocations)
+
+	synonymSvc := NewSynonymService(&repository.MockSynonymRepo{}, svc)
+	_, err = synonymSvc.Save("tesla", []string{"TSLA"})
+	assert.NoError(err)
+	svc.GetSuggestions([]string{"MSFT", "AAPL"}, "", 5)
+	assert.Equal(2, stockRepo.FindMostCommonInvocations)
+}
+
+func TestCachedStockServiceVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		SearchStocks:    []domain.Stock{domain.Stock{Symbol: "AAPL", Name: "Apple Inc."}},
+		LastUpdatedTime: time.Date(2019, 1, 29, 6, 0, 0, 0, time.UTC),
+	}
+	versionRepo := &repository.MockVersionRepo{
+		FindVersionsResult: map[string]domain.DataVersion{repository.SynonymVersion: domain.DataVersion{Version: 1}},
+	}
+	stockCache := NewStockCache(DefaultCacheOptions())
+	svc := NewCachedStockService(NewStockService(stockRepo, nil, repository.NewMemoryRankingLock(), stockCache),
+		stockCache, NewVersionTracker(stockRepo, versionRepo, 0))
+
+	svc.Search("apple", "", 10, false)
+	svc.Search("apple", "", 10, false)
+	assert.Equal(1, stockRepo.SearchInvocations)
+
+	// Ranked by another replica.
+	stockRepo.LastUpdatedTime = stockRepo.LastUpdatedTime.Add(time.Hour)
+	svc.Search("apple", "", 10, false)
+	svc.Search("apple", "", 10, false)
+	assert.Equal(2, stockRepo.SearchInvocations)
+
+	// Synonyms edited by another replica.
+	versionRepo.FindVersionsResult = map[string]domain.DataVersion{repository.SynonymVersion: domain.DataVersion{Version: 2}}
+	svc.Search("apple", "", 10, false)
+	assert.Equal(3, stockRepo.SearchInvocations)
+
+	versionRepo.FindVersionsErr = errors.New("connection refused")
+	svc.Search("apple", "", 10, false)
+	assert.Equal(4, stockRepo.SearchInvocations)
+}
+
+func TestStockCachePurgesBeforeOtherListeners(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{domain.Stock{Symbol: "TSLA"}},
+	}
+	stockCache := NewStockCache(DefaultCacheOptions())
+	listener := &suggestingListener{}
+	svc := NewCachedStockService(NewStockService(stockRepo, &repository.MockCountRepo{}, repository.NewMemoryRankingLock(), stockCache, listener),
+		stockCache, NewVersionTracker(stockRepo, &repository.MockVersionRepo{}, 0))
+	listener.svc = svc
+
+	svc.GetSuggestions(nil, "", 5)
+	stockRepo.FindMostCommonStocks = []domain.Stock{domain.Stock{Symbol: "AMD"}}
+	_, err := svc.RankStocks()
+	assert.NoError(err)
+	assert.Equal(1, len(listener.suggestions))
+	assert.Equal("AMD", listener.suggestions[0].Symbol)
+}
+
+// suggestingListener gets suggestions when a ranking completes, like a listener notifying clients would.
+type suggestingListener struct {
+	svc         StockService
+	suggestions []stock.Stock
+}
+
+func (l *suggestingListener) BeforeRanking(symbol string) {}
+
+func (l *suggestingListener) OnRanking(event domain.RankingEvent) {
+	l.suggestions, _ = l.svc.GetSuggestions(nil, "", 5)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+// highlightGroup describes how each stock of a group matched the query it was searched with.
+func highlightGroup(group domain.SearchGroup, query string) []domain.SearchHit {
+	hits := make([]domain.SearchHit, 0, len(group.Stocks))
+	for _, s := range group.Stocks {
+		if group.Synonym != "" {
+			hits = append(hits, domain.SearchHit{
+				Symbol:     s.Symbol,
+				Tier:       domain.MatchSynonym,
+				Highlights: []domain.Highlight{},
+			})
+			continue
+		}
+		hits = append(hits, highlightStock(s, query))
+	}
+
+	return hits
+}
+
+// highlightStock finds the tier a stock matched a query in and the spans of the symbol and name
+// which matched, following the same rules as the repository uses when searching. The whole query
+// matching the start of the symbol or name is a prefix match. Otherwise terms matching the start
+// of the symbol or a word, or the inside of a word, are token matches and if any term only matches
+// by sounding like a word of the name it is a fuzzy match.
+func highlightStock(s stock.Stock, query string) domain.SearchHit {
+	terms := textutil.Words(query)
+	fullQuery := []rune(strings.Join(terms, " "))
+	symbol := []rune(textutil.Fold(s.Symbol))
+	name, offsets := textutil.FoldOffsets(s.Name)
+
+	hit := domain.SearchHit{Symbol: s.Symbol, Tier: domain.MatchPrefix}
+	if hasRunePrefix(symbol, fullQuery) {
+		hit.Highlights = append(hit.Highlights, symbolHighlight(0, len(fullQuery)))
+	}
+	if hasRunePrefix(name, fullQuery) {
+		hit.Highlights = append(hit.Highlights, nameHighlight(offsets, 0, len(fullQuery)))
+	}
+	if len(hit.Highlights) > 0 {
+		return hit
+	}
+
+	hit.Tier = domain.MatchToken
+	hit.Highlights = make([]domain.Highlight, 0, len(terms))
+	for _, term := range terms {
+		t := []rune(term)
+		if hasRunePrefix(symbol, t) {
+			hit.Highlights = append(hit.Highlights, symbolHighlight(0, len(t)))
+			continue
+		}
+
+		if start, ok := findTokenMatch(name, t); ok {
+			hit.Highlights = append(hit.Highlights, nameHighlight(offsets, start, start+len(t)))
+			continue
+		}
+
+		if start, end, ok := findPhoneticMatch(name, term); ok {
+			hit.Tier = domain.MatchFuzzy
+			hit.Highlights = append(hit.Highlights, nameHighlight(offsets, start, end))
+		}
+	}
+
+	hit.Highlights = mergeHighlights(hit.Highlights)
+	return hit
+}
+
+// findTokenMatch finds the first match of a term at the start of the name or a word,
+// or otherwise inside a word where at least textutil.MinInnerTokenLength characters remain.
+func findTokenMatch(name, term []rune) (int, bool) {
+	if hasRunePrefix(name, term) {
+		return 0, true
+	}
+
+	words := wordSpans(name)
+	for _, w := range words {
+		if hasRunePrefix(name[w[0]:w[1]], term) {
+			return w[0], true
+		}
+	}
+
+	for _, w := range words {
+		for i := w[0] + 1; w[1]-i >= textutil.MinInnerTokenLength; i++ {
+			if hasRunePrefix(name[i:w[1]], term) {
+				return i, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// findPhoneticMatch finds the first word of the name which sounds like the term.
+func findPhoneticMatch(name []rune, term string) (int, int, bool) {
+	code := textutil.PhoneticCode(term)
+	if code == "" {
+		return 0, 0, false
+	}
+
+	for _, w := range wordSpans(name) {
+		if textutil.PhoneticCode(string(name[w[0]:w[1]])) == code {
+			return w[0], w[1], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// wordSpans returns the start and end of each run of letters and digits.
+func wordSpans(text []rune) [][2]int {
+	spans := make([][2]int, 0)
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+
+	return spans
+}
+
+func hasRunePrefix(text, prefix []rune) bool {
+	if len(prefix) == 0 || len(prefix) > len(text) {
+		return false
+	}
+
+	for i, r := range prefix {
+		if text[i] != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+func symbolHighlight(start, end int) domain.Highlight {
+	return domain.Highlight{Field: domain.FieldSymbol, Start: start, End: end}
+}
+
+// nameHighlight maps a span of the folded name to the characters of the original name.
+func nameHighlight(offsets []int, start, end int) domain.Highlight {
+	return domain.Highlight{Field: domain.FieldName, Start: offsets[start], End: offsets[end-1] + 1}
+}
+
+// mergeHighlights orders highlights by field and start, merging overlapping spans.
+func mergeHighlights(highlights []domain.Highlight) []domain.Highlight {
+	sort.SliceStable(highlights, func(i, j int) bool {
+		if highlights[i].Field != highlights[j].Field {
+			return highlights[i].Field == domain.FieldSymbol
+		}
+		return highlights[i].Start < highlights[j].Start
+	})
+
+	merged := make([]domain.Highlight, 0, len(highlights))
+	for _, h := range highlights {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Field == h.Field && h.Start <= merged[last].End {
+			if h.End > merged[last].End {
+				merged[last].End = h.End
+			}
+			continue
+		}
+		merged = append(merged, h)
+	}
+
+	return merged
+}
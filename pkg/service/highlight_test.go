@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightStock(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		stock      stock.Stock
+		query      string
+		tier       string
+		highlights []domain.Highlight
+	}{
+		{
+			stock: stock.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+			query: "aap",
+			tier:  domain.MatchPrefix,
+			highlights: []domain.Highlight{
+				domain.Highlight{Field: domain.FieldSymbol, Start: 0, End: 3},
+			},
+		},
+		{
+			stock: stock.Stock{Symbol: "NESN", Name: "Nestlé S.A."},
+			query: "nestle",
+			tier:  domain.MatchPrefix,
+			highlights: []domain.Highlight{
+				domain.Highlight{Field: domain.FieldName, Start: 0, End: 6},
+			},
+		},
+		{
+			stock: stock.Stock{Symbol: "GM", Name: "General Motors Company"},
+			query: "motors gm",
+			tier:  domain.MatchToken,
+			highlights: []domain.Highlight{
+				domain.Highlight{Field: domain.FieldSymbol, Start: 0, End: 2},
+				domain.Highlight{Field: domain.FieldName, Start: 8, End: 14},
+			},
+		},
+		{
+			stock: stock.Stock{Symbol: "SHB-A", Name: "Svenska Handelsbanken"},
+			query: "bank",
+			tier:  domain.MatchToken,
+			highlights: []domain.Highlight{
+				domain.Highlight{Field: domain.FieldName, Start: 15, End: 19},
+			},
+		},
+		{
+			stock: stock.Stock{Symbol: "AMKBY", Name: "A.P. Møller - Mærsk"},
+			query: "maersk",
+			tier:  domain.MatchToken,
+			highlights: []domain.Highlight{
+				domain.Highlight{Field: domain.FieldName, Start: 14, End: 19},
+			},
+		},
+		{
+			stock: stock.Stock{Symbol: "NOKIA", Name: "Nokia Oyj"},
+			query: "nokeeya oyj",
+			tier:  domain.MatchFuzzy,
+			highlights: []domain.Highlight{
+				domain.Highlight{Field: domain.FieldName, Start: 0, End: 5},
+				domain.Highlight{Field: domain.FieldName, Start: 6, End: 9},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		hit := highlightStock(test.stock, test.query)
+		assert.Equal(test.stock.Symbol, hit.Symbol, test.query)
+		assert.Equal(test.tier, hit.Tier, test.query)
+		assert.Equal(test.highlights, hit.Highlights, test.query)
+	}
+}
+
+func TestHighlightGroup(t *testing.T) {
+	assert := assert.New(t)
+
+	group := domain.SearchGroup{
+		Synonym: "google",
+		Stocks:  []stock.Stock{stock.Stock{Symbol: "GOOGL", Name: "Alphabet Inc."}},
+	}
+	hits := highlightGroup(group, "google")
+	assert.Equal(1, len(hits))
+	assert.Equal(domain.MatchSynonym, hits[0].Tier)
+	assert.Equal(0, len(hits[0].Highlights))
+
+	group = domain.SearchGroup{
+		Ticker: "AAPL",
+		Stocks: []stock.Stock{
+			stock.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+			stock.Stock{Symbol: "AAPLX", Name: "Aapl Fund"},
+		},
+	}
+	hits = highlightGroup(group, groupQuery(group))
+	assert.Equal(2, len(hits))
+	assert.Equal("AAPLX", hits[1].Symbol)
+	assert.Equal([]domain.Highlight{
+		domain.Highlight{Field: domain.FieldSymbol, Start: 0, End: 4},
+		domain.Highlight{Field: domain.FieldName, Start: 0, End: 4},
+	}, hits[1].Highlights)
+}
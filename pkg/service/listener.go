@@ -0,0 +1,31 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// RankingListener is notified before and after stocks are ranked.
+// The symbol passed to BeforeRanking is empty if all stocks are ranked.
+type RankingListener interface {
+	BeforeRanking(symbol string)
+	OnRanking(event domain.RankingEvent)
+}
+
+// waitTimeout waits for a wait group, returning false if the timeout expires first.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
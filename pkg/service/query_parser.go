@@ -0,0 +1,106 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+var cashtagPattern = regexp.MustCompile(`\$([A-Za-z][A-Za-z0-9]*(?:[.\-][A-Za-z0-9]+)*)`)
+
+// ignoredTerms corporate suffixes and connecting words
+// which do not help identifying a stock.
+var ignoredTerms = map[string]bool{
+	"inc":          true,
+	"incorporated": true,
+	"corp":         true,
+	"corporation":  true,
+	"co":           true,
+	"ltd":          true,
+	"limited":      true,
+	"llc":          true,
+	"plc":          true,
+	"ab":           true,
+	"publ":         true,
+	"asa":          true,
+	"oyj":          true,
+	"ag":           true,
+	"sa":           true,
+	"nv":           true,
+	"vs":           true,
+	"versus":       true,
+	"and":          true,
+	"or":           true,
+}
+
+// ParseQuery extracts cashtags and free text terms from a search query.
+// Punctuation is stripped from the terms, except inside words like BRK.B
+// or Coca-Cola, and corporate suffixes such as Inc. and AB are dropped
+// unless nothing else remains.
+func ParseQuery(query string) domain.SearchQuery {
+	parsed := domain.SearchQuery{
+		Raw:      query,
+		Cashtags: make([]string, 0),
+		Terms:    make([]string, 0),
+	}
+
+	seen := make(map[string]bool)
+	for _, match := range cashtagPattern.FindAllStringSubmatch(query, -1) {
+		ticker := strings.ToUpper(match[1])
+		if !seen[ticker] {
+			seen[ticker] = true
+			parsed.Cashtags = append(parsed.Cashtags, ticker)
+		}
+	}
+
+	words := splitWords(cashtagPattern.ReplaceAllString(query, " "))
+	for _, word := range words {
+		if !ignoredTerms[textutil.Fold(strings.Replace(word, ".", "", -1))] {
+			parsed.Terms = append(parsed.Terms, word)
+		}
+	}
+
+	if len(parsed.Terms) == 0 && len(parsed.Cashtags) == 0 {
+		parsed.Terms = words
+	}
+
+	return parsed
+}
+
+// splitWords splits text into words of letters and digits. Dots, dashes,
+// ampersands and apostrophes are kept when surrounded by letters or digits.
+func splitWords(text string) []string {
+	runes := []rune(text)
+	words := make([]string, 0)
+	start := -1
+	for i, r := range runes {
+		if isWordRune(r) || (start != -1 && isJoiner(r) && i+1 < len(runes) && isWordRune(runes[i+1])) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+
+		if start != -1 {
+			words = append(words, string(runes[start:i]))
+			start = -1
+		}
+	}
+
+	if start != -1 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isJoiner(r rune) bool {
+	return r == '.' || r == '-' || r == '&' || r == '\''
+}
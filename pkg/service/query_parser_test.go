@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		query    string
+		cashtags []string
+		terms    []string
+	}{
+		{query: "$AAPL vs $MSFT", cashtags: []string{"AAPL", "MSFT"}, terms: []string{}},
+		{query: "apple inc.", cashtags: []string{}, terms: []string{"apple"}},
+		{query: "Volvo AB (publ)", cashtags: []string{}, terms: []string{"Volvo"}},
+		{query: "Vodafone Group Plc", cashtags: []string{}, terms: []string{"Vodafone", "Group"}},
+		{query: "$brk.b, $aapl and $BRK.B!", cashtags: []string{"BRK.B", "AAPL"}, terms: []string{}},
+		{query: "$TSLA earnings?", cashtags: []string{"TSLA"}, terms: []string{"earnings"}},
+		{query: "Coca-Cola Co.", cashtags: []string{}, terms: []string{"Coca-Cola"}},
+		{query: "AT&T", cashtags: []string{}, terms: []string{"AT&T"}},
+		{query: "costs $5 - nokia", cashtags: []string{}, terms: []string{"costs", "5", "nokia"}},
+		{query: "Nestlé S.A.", cashtags: []string{}, terms: []string{"Nestlé"}},
+		{query: "AB", cashtags: []string{}, terms: []string{"AB"}},
+		{query: "?!", cashtags: []string{}, terms: []string{}},
+	}
+
+	for _, test := range tests {
+		parsed := ParseQuery(test.query)
+		assert.Equal(test.query, parsed.Raw)
+		assert.Equal(test.cashtags, parsed.Cashtags, test.query)
+		assert.Equal(test.terms, parsed.Terms, test.query)
+	}
+
+	assert.True(ParseQuery("...").IsEmpty())
+	assert.False(ParseQuery("$A").IsEmpty())
+}
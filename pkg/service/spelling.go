@@ -0,0 +1,135 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+// MaxQuerySuggestions most alternative queries suggested for a search without results.
+const MaxQuerySuggestions = 3
+
+// queryWord a cashtag or term of a parsed query with its spelling candidates.
+type queryWord struct {
+	text       string
+	cashtag    bool
+	candidates []string
+}
+
+// suggestQueries suggests alternative queries where misspelled cashtags and terms are replaced by
+// vocabulary terms within typo distance. Candidates are ordered by their mention volume,
+// the first suggestion uses the most mentioned candidate of every misspelled word.
+func suggestQueries(query domain.SearchQuery, vocabulary []domain.Term) []string {
+	words := make([]*queryWord, 0, len(query.Cashtags)+len(query.Terms))
+	for _, cashtag := range query.Cashtags {
+		words = append(words, &queryWord{text: textutil.Fold(cashtag), cashtag: true})
+	}
+	for _, term := range query.Terms {
+		words = append(words, &queryWord{text: textutil.Fold(term)})
+	}
+
+	misspelled := false
+	for _, word := range words {
+		word.candidates = findCandidates(word, vocabulary)
+		if len(word.candidates) > 0 && word.candidates[0] != word.text {
+			misspelled = true
+		}
+	}
+
+	suggestions := make([]string, 0)
+	if !misspelled {
+		return suggestions
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < MaxQuerySuggestions; i++ {
+		parts := make([]string, 0, len(words))
+		for _, word := range words {
+			parts = append(parts, word.format(i))
+		}
+
+		suggestion := strings.Join(parts, " ")
+		if !seen[suggestion] {
+			seen[suggestion] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	return suggestions
+}
+
+// findCandidates finds vocabulary terms within typo distance of a word, ordered by mention volume.
+// A word found in the vocabulary is correctly spelled and its only candidate is itself.
+func findCandidates(word *queryWord, vocabulary []domain.Term) []string {
+	maxTypos := textutil.MaxTypos(word.text)
+	matches := make([]domain.Term, 0)
+	for _, term := range vocabulary {
+		if word.cashtag && !term.IsSymbol {
+			continue
+		}
+
+		text := textutil.Fold(term.Text)
+		if text == word.text {
+			return []string{text}
+		}
+
+		if textutil.TypoDistance(word.text, text) <= maxTypos {
+			term.Text = text
+			matches = append(matches, term)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Count != matches[j].Count {
+			return matches[i].Count > matches[j].Count
+		}
+		return matches[i].Text < matches[j].Text
+	})
+
+	candidates := make([]string, 0, len(matches))
+	for _, match := range matches {
+		candidates = append(candidates, match.Text)
+	}
+
+	return candidates
+}
+
+// format returns the i:th candidate of the word, or the last one if there are fewer.
+func (w *queryWord) format(i int) string {
+	text := w.text
+	if len(w.candidates) > 0 {
+		if i >= len(w.candidates) {
+			i = len(w.candidates) - 1
+		}
+		text = w.candidates[i]
+	}
+
+	if w.cashtag {
+		return "$" + strings.ToUpper(text)
+	}
+
+	return text
+}
+
+// vocabularyBounds returns the range of word lengths which can be within typo distance of the query.
+func vocabularyBounds(query domain.SearchQuery) (int, int) {
+	minLength, maxLength := -1, 0
+	for _, word := range append(append([]string{}, query.Cashtags...), query.Terms...) {
+		length := len([]rune(word))
+		typos := int(textutil.MaxTypos(word))
+		if minLength == -1 || length-typos < minLength {
+			minLength = length - typos
+		}
+		if length+typos > maxLength {
+			maxLength = length + typos
+		}
+	}
+
+	if minLength < 1 {
+		minLength = 1
+	}
+
+	return minLength, maxLength
+}
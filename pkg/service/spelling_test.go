@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var testVocabulary = []domain.Term{
+	domain.Term{Text: "aapl", Count: 500, IsSymbol: true},
+	domain.Term{Text: "apple", Count: 500},
+	domain.Term{Text: "appian", Count: 20},
+	domain.Term{Text: "ample", Count: 5},
+	domain.Term{Text: "tsla", Count: 300, IsSymbol: true},
+	domain.Term{Text: "tesla", Count: 300},
+	domain.Term{Text: "ericsson", Count: 80},
+	domain.Term{Text: "eric-b", Count: 80, IsSymbol: true},
+	domain.Term{Text: "motors", Count: 40},
+}
+
+func TestSuggestQueries(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "appke", expected: []string{"apple", "ample"}},
+		{query: "$AAPK", expected: []string{"$AAPL"}},
+		{query: "$TSAL vs $AAPL", expected: []string{"$TSLA $AAPL"}},
+		{query: "erocsson", expected: []string{"ericsson"}},
+		{query: "tesla motprs", expected: []string{"tesla motors"}},
+		{query: "apple", expected: []string{}},
+		{query: "xyzzy", expected: []string{}},
+	}
+
+	for _, test := range tests {
+		suggestions := suggestQueries(ParseQuery(test.query), testVocabulary)
+		assert.Equal(test.expected, suggestions, test.query)
+	}
+}
+
+func TestVocabularyBounds(t *testing.T) {
+	assert := assert.New(t)
+
+	minLength, maxLength := vocabularyBounds(ParseQuery("$A ericsson"))
+	assert.Equal(1, minLength)
+	assert.Equal(10, maxLength)
+}
@@ -1,77 +1,282 @@
 package service
 
 import (
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/mimir-news/pkg/httputil"
 	"github.com/mimir-news/pkg/schema/stock"
+	"github.com/mimir-news/stock-search/pkg/apierror"
 	"github.com/mimir-news/stock-search/pkg/domain"
 	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/textutil"
 )
 
+// ErrRankingInProgress is returned when a ranking is requested while another one is running.
+var ErrRankingInProgress = apierror.New(http.StatusConflict, apierror.CodeRankingInProgress, "Ranking already in progress")
+
 // StockService service for interacting with stocks.
 type StockService interface {
-	RankStocks() error
-	RankStock(symbol string) error
-	Search(query string, limit int) ([]stock.Stock, error)
-	GetSuggestions(excluded []string, limit int) ([]stock.Stock, error)
+	RankStocks() (domain.RankingReport, error)
+	RankStock(symbol string) (domain.RankingReport, error)
+	GetStock(symbol string) (stock.Stock, error)
+	GetHistory(symbol string, days int) ([]domain.DailyCount, error)
+	Search(query, language string, limit int, highlight bool) (domain.SearchResult, error)
+	GetSuggestions(excluded []string, language string, limit int) ([]stock.Stock, error)
+	LastUpdated() (time.Time, error)
 }
 
-// NewStockService creates a StockService using the default implementation.
-func NewStockService(stockRepo repository.StockRepo, countRepo repository.CountRepo) StockService {
+// NewStockService creates a StockService using the default implementation. Rankings of all
+// stocks are serialized by the ranking lock and the listeners are notified after each completed ranking.
+func NewStockService(stockRepo repository.StockRepo, countRepo repository.CountRepo,
+	rankingLock repository.RankingLock, listeners ...RankingListener) StockService {
 	return &stockSvc{
-		stockRepo: stockRepo,
-		countRepo: countRepo,
+		stockRepo:   stockRepo,
+		countRepo:   countRepo,
+		rankingLock: rankingLock,
+		listeners:   listeners,
 	}
 }
 
 type stockSvc struct {
-	stockRepo repository.StockRepo
-	countRepo repository.CountRepo
+	stockRepo   repository.StockRepo
+	countRepo   repository.CountRepo
+	rankingLock repository.RankingLock
+	listeners   []RankingListener
 }
 
 // Search attempts to match a query against the stored list of stocks.
-func (svc *stockSvc) Search(query string, limit int) ([]stock.Stock, error) {
-	stocks, err := svc.stockRepo.Search(query, limit)
+// Each cashtag in the query is searched for separately and all remaining terms
+// must match either the symbol or the name of a stock. Words of the terms found in
+// the synonym dictionary are grouped separately before the terms, preferring the longest
+// synonyms. Groups contain at most limit stocks.
+// If nothing is found alternative spellings of the query are suggested.
+// If a language is specified matches are ranked by mentions in that language.
+// If highlight is true each group describes how its stocks matched.
+func (svc *stockSvc) Search(query, language string, limit int, highlight bool) (domain.SearchResult, error) {
+	parsed := ParseQuery(query)
+	result := domain.SearchResult{
+		Query:  parsed,
+		Groups: make([]domain.SearchGroup, 0, len(parsed.Cashtags)+1),
+	}
+
+	for _, ticker := range parsed.Cashtags {
+		stocks, err := svc.stockRepo.Search(ticker, language, limit)
+		if err != nil {
+			return domain.SearchResult{}, err
+		}
+
+		result.Groups = append(result.Groups, domain.SearchGroup{
+			Ticker: ticker,
+			Stocks: mapStocksToDTOs(exactSymbolFirst(ticker, stocks)),
+		})
+	}
+
+	if len(parsed.Terms) > 0 {
+		groups, err := svc.synonymGroups(parsed.Terms, language, limit)
+		if err != nil {
+			return domain.SearchResult{}, err
+		}
+		result.Groups = append(result.Groups, groups...)
+
+		stocks, err := svc.stockRepo.Search(strings.Join(parsed.Terms, " "), language, limit)
+		if err != nil {
+			return domain.SearchResult{}, err
+		}
+
+		result.Groups = append(result.Groups, domain.SearchGroup{
+			Terms:  parsed.Terms,
+			Stocks: mapStocksToDTOs(stocks),
+		})
+	}
+
+	if !parsed.IsEmpty() && len(result.Stocks(1)) == 0 {
+		result.Suggestions = svc.suggestQueries(parsed)
+	}
+
+	if highlight {
+		for i, group := range result.Groups {
+			result.Groups[i].Hits = highlightGroup(group, groupQuery(group))
+		}
+	}
+
+	return result, nil
+}
+
+// groupQuery returns the query a group was searched with.
+func groupQuery(group domain.SearchGroup) string {
+	if group.Ticker != "" {
+		return group.Ticker
+	}
+
+	return strings.Join(group.Terms, " ")
+}
+
+// synonymGroups finds the stocks which synonyms among the words of the terms map to.
+// Synonyms are matched from left to right, where the longest synonym starting at a word is used.
+func (svc *stockSvc) synonymGroups(terms []string, language string, limit int) ([]domain.SearchGroup, error) {
+	words := textutil.Words(strings.Join(terms, " "))
+	matches, err := svc.stockRepo.FindBySynonyms(synonymPhrases(words), language)
 	if err != nil {
 		return nil, err
 	}
 
-	return mapStocksToDTOs(stocks), nil
+	groups := make([]domain.SearchGroup, 0)
+	for i := 0; i < len(words); {
+		n := MaxSynonymWords
+		if len(words)-i < n {
+			n = len(words) - i
+		}
+		for ; n > 0; n-- {
+			phrase := strings.Join(words[i:i+n], " ")
+			if stocks := matches[phrase]; len(stocks) > 0 {
+				if len(stocks) > limit {
+					stocks = stocks[:limit]
+				}
+				groups = append(groups, domain.SearchGroup{
+					Synonym: phrase,
+					Stocks:  mapStocksToDTOs(stocks),
+				})
+				break
+			}
+		}
+
+		if n == 0 {
+			n = 1
+		}
+		i += n
+	}
+
+	return groups, nil
 }
 
-// RankStocks counts stock mentions and updates all stocks accordingly.
-func (svc *stockSvc) RankStocks() error {
-	countedStocks, err := svc.countRepo.CountAll()
+// synonymPhrases returns every run of up to MaxSynonymWords consecutive words.
+func synonymPhrases(words []string) []string {
+	phrases := make([]string, 0)
+	for i := range words {
+		for n := 1; n <= MaxSynonymWords && i+n <= len(words); n++ {
+			phrases = append(phrases, strings.Join(words[i:i+n], " "))
+		}
+	}
+
+	return phrases
+}
+
+// suggestQueries suggests alternative spellings of a query without results.
+// Suggestions are optional, so failing to find them does not fail the search.
+func (svc *stockSvc) suggestQueries(query domain.SearchQuery) []string {
+	vocabulary, err := svc.stockRepo.FindVocabulary(vocabularyBounds(query))
 	if err != nil {
-		return err
+		log.Println("Failed to find search vocabulary:", err)
+		return nil
+	}
+
+	return suggestQueries(query, vocabulary)
+}
+
+// RankStocks counts stock mentions and updates all stocks accordingly. Only one ranking
+// of all stocks runs at a time across replicas, ErrRankingInProgress is returned otherwise.
+func (svc *stockSvc) RankStocks() (domain.RankingReport, error) {
+	report := domain.RankingReport{StartedAt: time.Now().UTC()}
+	locked, err := svc.rankingLock.TryLock()
+	if err != nil {
+		return report, err
+	} else if !locked {
+		return report, ErrRankingInProgress
+	}
+	defer svc.finishRanking()
+
+	svc.prepareListeners("")
+	countedStocks, filterReport, err := svc.countRepo.CountAll()
+	if err != nil {
+		return report, err
 	}
 
 	for _, s := range countedStocks {
 		err := svc.stockRepo.Save(s)
 		if err != nil {
-			return err
+			return report, err
 		}
 	}
 
-	return nil
+	report = finishReport(report, len(countedStocks), filterReport)
+	svc.notifyListeners(domain.RankingEvent{Stocks: countedStocks, Report: report})
+	return report, nil
+}
+
+// RankStock counts a single stocks mentions and updates it accordingly. Single stocks
+// are ranked independently of each other and of rankings of all stocks.
+func (svc *stockSvc) RankStock(symbol string) (domain.RankingReport, error) {
+	report := domain.RankingReport{StartedAt: time.Now().UTC()}
+
+	svc.prepareListeners(symbol)
+	s, filterReport, err := svc.countRepo.CountOne(symbol)
+	if err == repository.ErrNoSuchStock {
+		return report, apierror.StockNotFound(symbol)
+	} else if err == repository.ErrNoMentions {
+		return report, apierror.NotFound("No mentions of stock: " + symbol)
+	} else if err != nil {
+		return report, err
+	}
+
+	err = svc.stockRepo.Save(s)
+	if err != nil {
+		return report, err
+	}
+
+	report = finishReport(report, 1, filterReport)
+	svc.notifyListeners(domain.RankingEvent{Symbol: symbol, Stocks: []domain.Stock{s}, Report: report})
+	return report, nil
 }
 
-// RankStocks counts a single stocks mentions and updates all it accordingly.
-func (svc *stockSvc) RankStock(symbol string) error {
-	s, err := svc.countRepo.CountOne(symbol)
+// GetStock gets a stock by its symbol.
+func (svc *stockSvc) GetStock(symbol string) (stock.Stock, error) {
+	s, err := svc.stockRepo.Find(symbol)
 	if err == repository.ErrNoSuchStock {
-		return httputil.NewError(err.Error(), http.StatusNotFound)
+		return stock.Stock{}, apierror.StockNotFound(symbol)
 	} else if err != nil {
-		return err
+		return stock.Stock{}, err
+	}
+
+	return s.ToDTO(), nil
+}
+
+// GetHistory gets the daily mentions of a stock during the given number of days
+// up to and including today. Days without mentions are included with a zero count.
+func (svc *stockSvc) GetHistory(symbol string, days int) ([]domain.DailyCount, error) {
+	if days < 1 {
+		return []domain.DailyCount{}, nil
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, 1-days)
+	counts, err := svc.countRepo.CountStockDaily(symbol, since)
+	if err != nil {
+		return nil, err
+	}
+
+	countByDay := make(map[time.Time]int64)
+	for _, c := range counts {
+		countByDay[c.Day.UTC().Truncate(24*time.Hour)] = c.Count
 	}
 
-	return svc.stockRepo.Save(s)
+	history := make([]domain.DailyCount, 0, days)
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		history = append(history, domain.DailyCount{
+			Symbol: symbol,
+			Day:    day,
+			Count:  countByDay[day],
+		})
+	}
+
+	return history, nil
 }
 
 // GetSuggestions gets most common stocks except the specified excluded.
-func (svc *stockSvc) GetSuggestions(excluded []string, limit int) ([]stock.Stock, error) {
-	stocks, err := svc.stockRepo.FindMostCommon(excluded, limit)
+// If a language is specified stocks are ranked by mentions in that language.
+func (svc *stockSvc) GetSuggestions(excluded []string, language string, limit int) ([]stock.Stock, error) {
+	stocks, err := svc.stockRepo.FindMostCommon(excluded, language, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +284,52 @@ func (svc *stockSvc) GetSuggestions(excluded []string, limit int) ([]stock.Stock
 	return mapStocksToDTOs(stocks), nil
 }
 
+// LastUpdated gets when stocks were last ranked, which is the zero time if they never were.
+func (svc *stockSvc) LastUpdated() (time.Time, error) {
+	return svc.stockRepo.LastUpdated()
+}
+
+func (svc *stockSvc) finishRanking() {
+	err := svc.rankingLock.Unlock()
+	if err != nil {
+		log.Println("Failed to release ranking lock:", err)
+	}
+}
+
+func (svc *stockSvc) prepareListeners(symbol string) {
+	for _, listener := range svc.listeners {
+		listener.BeforeRanking(symbol)
+	}
+}
+
+func (svc *stockSvc) notifyListeners(event domain.RankingEvent) {
+	for _, listener := range svc.listeners {
+		listener.OnRanking(event)
+	}
+}
+
+func finishReport(report domain.RankingReport, rankedStocks int, filter domain.FilterReport) domain.RankingReport {
+	report.FinishedAt = time.Now().UTC()
+	report.RankedStocks = rankedStocks
+	report.Filter = filter
+	log.Printf("Ranked %d stocks. Counted mentions: %d. Removed mentions: %v\n",
+		rankedStocks, filter.CountedMentions, filter.RemovedMentions)
+
+	return report
+}
+
+// exactSymbolFirst moves a stock with exactly the given symbol to the front.
+func exactSymbolFirst(symbol string, stocks []domain.Stock) []domain.Stock {
+	for i, s := range stocks {
+		if i > 0 && strings.EqualFold(s.Symbol, symbol) {
+			sorted := append([]domain.Stock{s}, stocks[:i]...)
+			return append(sorted, stocks[i+1:]...)
+		}
+	}
+
+	return stocks
+}
+
 func mapStocksToDTOs(stocks []domain.Stock) []stock.Stock {
 	dtos := make([]stock.Stock, 0, len(stocks))
 	for _, s := range stocks {
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingCountRepo blocks CountAll until released.
+type blockingCountRepo struct {
+	repository.MockCountRepo
+	started chan struct{}
+	release chan struct{}
+}
+
+func (cr *blockingCountRepo) CountAll() ([]domain.Stock, domain.FilterReport, error) {
+	close(cr.started)
+	<-cr.release
+	return cr.MockCountRepo.CountAll()
+}
+
+func TestRankingInProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	countRepo := &blockingCountRepo{
+		MockCountRepo: repository.MockCountRepo{
+			CountOneStock: domain.Stock{Symbol: "AAPL"},
+		},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	stockRepo := &repository.MockStockRepo{}
+	svc := NewStockService(stockRepo, countRepo, repository.NewMemoryRankingLock())
+
+	done := make(chan error)
+	go func() {
+		_, err := svc.RankStocks()
+		done <- err
+	}()
+	<-countRepo.started
+
+	_, err := svc.RankStocks()
+	assert.Equal(ErrRankingInProgress, err)
+	assert.True(apierror.HasCode(err, apierror.CodeRankingInProgress))
+
+	// Single stocks are ranked while all stocks are.
+	_, err = svc.RankStock("AAPL")
+	assert.NoError(err)
+	assert.Equal(1, countRepo.CountOneInvocations)
+
+	close(countRepo.release)
+	assert.NoError(<-done)
+
+	countRepo.CountOneErr = repository.ErrNoSuchStock
+	_, err = svc.RankStock("MISSING")
+	assert.True(apierror.HasCode(err, apierror.CodeStockNotFound))
+
+	// Stocks without mentions keep their stored counts.
+	stockRepo.UnsetArgs()
+	countRepo.CountOneErr = repository.ErrNoMentions
+	_, err = svc.RankStock("MSFT")
+	assert.True(apierror.HasCode(err, apierror.CodeNotFound))
+	assert.Equal(0, stockRepo.SaveInvocations)
+}
+
+func TestRankingLockedByOtherReplica(t *testing.T) {
+	assert := assert.New(t)
+
+	rankingLock := &repository.MockRankingLock{}
+	countRepo := &repository.MockCountRepo{}
+	svc := NewStockService(&repository.MockStockRepo{}, countRepo, rankingLock)
+
+	_, err := svc.RankStocks()
+	assert.Equal(ErrRankingInProgress, err)
+	assert.Equal(0, countRepo.CountAllInvocations)
+	assert.Equal(0, rankingLock.UnlockInvocations)
+
+	rankingLock.TryLockErr = errors.New("connection refused")
+	_, err = svc.RankStocks()
+	assert.Equal(rankingLock.TryLockErr, err)
+	assert.Equal(0, countRepo.CountAllInvocations)
+
+	rankingLock.TryLockErr = nil
+	rankingLock.TryLockResult = true
+	_, err = svc.RankStocks()
+	assert.NoError(err)
+	assert.Equal(1, countRepo.CountAllInvocations)
+	assert.Equal(1, rankingLock.UnlockInvocations)
+}
+
+// searchStockRepo returns stocks by search query.
+type searchStockRepo struct {
+	repository.MockStockRepo
+	results map[string][]domain.Stock
+	queries []string
+}
+
+func (sr *searchStockRepo) Search(query, language string, limit int) ([]domain.Stock, error) {
+	sr.queries = append(sr.queries, query)
+	return sr.results[query], nil
+}
+
+func TestSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &searchStockRepo{
+		results: map[string][]domain.Stock{
+			"AAPL": []domain.Stock{
+				domain.Stock{Symbol: "AAPLX", Name: "Aapl Fund"},
+				domain.Stock{Symbol: "AAPL", Name: "Apple Inc."},
+			},
+			"MSFT":  []domain.Stock{domain.Stock{Symbol: "MSFT", Name: "Microsoft Corp."}},
+			"apple": []domain.Stock{domain.Stock{Symbol: "AAPL", Name: "Apple Inc."}},
+		},
+	}
+	svc := NewStockService(stockRepo, nil, repository.NewMemoryRankingLock())
+
+	result, err := svc.Search("$AAPL vs $MSFT", "", 10, false)
+	assert.NoError(err)
+	assert.Equal([]string{"AAPL", "MSFT"}, stockRepo.queries)
+	assert.Equal(2, len(result.Groups))
+	assert.Equal("AAPL", result.Groups[0].Ticker)
+	assert.Equal("AAPL", result.Groups[0].Stocks[0].Symbol)
+	assert.Equal("AAPLX", result.Groups[0].Stocks[1].Symbol)
+	assert.Equal("MSFT", result.Groups[1].Ticker)
+
+	stocks := result.Stocks(2)
+	assert.Equal(2, len(stocks))
+	assert.Equal("AAPL", stocks[0].Symbol)
+	assert.Equal("AAPLX", stocks[1].Symbol)
+
+	stockRepo.queries = nil
+	result, err = svc.Search("$AAPL apple inc.", "", 10, false)
+	assert.NoError(err)
+	assert.Equal([]string{"AAPL", "apple"}, stockRepo.queries)
+	assert.Equal(2, len(result.Groups))
+	assert.Equal("", result.Groups[1].Ticker)
+	assert.Equal([]string{"apple"}, result.Groups[1].Terms)
+	assert.Equal(2, len(result.Stocks(10)))
+
+	stockRepo.queries = nil
+	result, err = svc.Search("!?", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(0, len(stockRepo.queries))
+	assert.Equal(0, len(result.Groups))
+	assert.Equal(0, len(result.Stocks(10)))
+}
+
+func TestSearchSynonyms(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindBySynonymsStocks: map[string][]domain.Stock{
+			"coca":      []domain.Stock{domain.Stock{Symbol: "COKE", Name: "Coca-Cola Consolidated"}},
+			"coca cola": []domain.Stock{domain.Stock{Symbol: "KO", Name: "The Coca-Cola Company"}},
+			"google": []domain.Stock{
+				domain.Stock{Symbol: "GOOGL", Name: "Alphabet Inc."},
+				domain.Stock{Symbol: "GOOG", Name: "Alphabet Inc."},
+			},
+		},
+	}
+	svc := NewStockService(stockRepo, nil, repository.NewMemoryRankingLock())
+
+	result, err := svc.Search("Coca-Cola vs Google", "sv", 1, false)
+	assert.NoError(err)
+	assert.Equal(1, stockRepo.FindBySynonymsInvocations)
+	assert.Equal("sv", stockRepo.FindBySynonymsArgLanguage)
+	assert.Equal([]string{"coca", "coca cola", "coca cola google", "cola", "cola google", "google"}, stockRepo.FindBySynonymsArgTerms)
+	assert.Equal(3, len(result.Groups))
+	assert.Equal("coca cola", result.Groups[0].Synonym)
+	assert.Equal("KO", result.Groups[0].Stocks[0].Symbol)
+	assert.Equal("google", result.Groups[1].Synonym)
+	assert.Equal(1, len(result.Groups[1].Stocks))
+	assert.Equal("", result.Groups[2].Synonym)
+	assert.Equal([]string{"Coca-Cola", "Google"}, result.Groups[2].Terms)
+	assert.Nil(result.Suggestions)
+	assert.Equal(0, stockRepo.FindVocabularyInvocations)
+
+	stockRepo.UnsetArgs()
+	result, err = svc.Search("$KO", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(0, stockRepo.FindBySynonymsInvocations)
+
+	stockRepo.FindBySynonymsErr = errors.New("synonym error")
+	_, err = svc.Search("google", "", 10, false)
+	assert.Error(err)
+}
+
+func TestSearchSuggestions(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindVocabularyTerms: testVocabulary,
+	}
+	svc := NewStockService(stockRepo, nil, repository.NewMemoryRankingLock())
+
+	result, err := svc.Search("appke", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(1, stockRepo.FindVocabularyInvocations)
+	assert.Equal(3, stockRepo.FindVocabularyArgMinLength)
+	assert.Equal(7, stockRepo.FindVocabularyArgMaxLength)
+	assert.Equal([]string{"apple", "ample"}, result.Suggestions)
+
+	stockRepo.UnsetArgs()
+	stockRepo.SearchStocks = []domain.Stock{domain.Stock{Symbol: "AAPL"}}
+	result, err = svc.Search("appke", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(0, stockRepo.FindVocabularyInvocations)
+	assert.Nil(result.Suggestions)
+
+	stockRepo.UnsetArgs()
+	stockRepo.SearchStocks = nil
+	stockRepo.FindVocabularyErr = errors.New("vocabulary error")
+	result, err = svc.Search("appke", "", 10, false)
+	assert.NoError(err)
+	assert.Equal(1, stockRepo.FindVocabularyInvocations)
+	assert.Nil(result.Suggestions)
+}
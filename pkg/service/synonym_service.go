@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/textutil"
+)
+
+// MaxSynonymWords the most words a synonym term may consist of.
+const MaxSynonymWords = 4
+
+// SynonymService service for managing the synonym dictionary used when searching.
+type SynonymService interface {
+	Save(term string, symbols []string) (domain.Synonym, error)
+	Delete(term string) error
+	GetAll() ([]domain.Synonym, error)
+	Import(synonyms []domain.Synonym, replace bool) (int, error)
+}
+
+// NewSynonymService creates a SynonymService using the default implementation.
+// The invalidators are notified after each change to the dictionary.
+func NewSynonymService(synonymRepo repository.SynonymRepo, invalidators ...CacheInvalidator) SynonymService {
+	return &synonymSvc{
+		synonymRepo:  synonymRepo,
+		invalidators: invalidators,
+	}
+}
+
+type synonymSvc struct {
+	synonymRepo  repository.SynonymRepo
+	invalidators []CacheInvalidator
+}
+
+// Save maps a term to a list of symbols, replacing any previous mapping of the term.
+func (svc *synonymSvc) Save(term string, symbols []string) (domain.Synonym, error) {
+	synonym, err := newSynonym(term, symbols, time.Now().UTC())
+	if err != nil {
+		return domain.Synonym{}, err
+	}
+
+	err = svc.synonymRepo.Save(synonym)
+	if err != nil {
+		return domain.Synonym{}, err
+	}
+
+	svc.invalidate()
+	return synonym, nil
+}
+
+// Delete removes a term from the synonym dictionary.
+func (svc *synonymSvc) Delete(term string) error {
+	err := svc.synonymRepo.Delete(normalizeSynonymTerm(term))
+	if err == repository.ErrNoSuchSynonym {
+		return apierror.NotFound("No such synonym: " + term)
+	} else if err != nil {
+		return err
+	}
+
+	svc.invalidate()
+	return nil
+}
+
+// GetAll lists the synonym dictionary, which can be imported again as is.
+func (svc *synonymSvc) GetAll() ([]domain.Synonym, error) {
+	return svc.synonymRepo.FindAll()
+}
+
+// Import stores a list of synonyms, returning the number of imported terms. Terms occurring
+// more than once are merged. If replace is true the dictionary is replaced by the imported synonyms.
+// Nothing is imported if any of the synonyms is invalid.
+func (svc *synonymSvc) Import(synonyms []domain.Synonym, replace bool) (int, error) {
+	updatedAt := time.Now().UTC()
+	imported := make([]domain.Synonym, 0, len(synonyms))
+	index := make(map[string]int)
+	for _, s := range synonyms {
+		synonym, err := newSynonym(s.Term, s.Symbols, updatedAt)
+		if err != nil {
+			return 0, err
+		}
+
+		if i, ok := index[synonym.Term]; ok {
+			imported[i].Symbols = distinctSymbols(append(imported[i].Symbols, synonym.Symbols...))
+			continue
+		}
+		index[synonym.Term] = len(imported)
+		imported = append(imported, synonym)
+	}
+
+	err := svc.synonymRepo.Import(imported, replace)
+	if err != nil {
+		return 0, err
+	}
+
+	svc.invalidate()
+	return len(imported), nil
+}
+
+func (svc *synonymSvc) invalidate() {
+	for _, invalidator := range svc.invalidators {
+		invalidator.Invalidate()
+	}
+}
+
+// newSynonym creates a synonym with a normalized term and distinct upper case symbols.
+func newSynonym(term string, symbols []string, updatedAt time.Time) (domain.Synonym, error) {
+	normalized := normalizeSynonymTerm(term)
+	if normalized == "" {
+		return domain.Synonym{}, apierror.InvalidParameter("term", "must contain letters or digits")
+	}
+
+	if len(strings.Fields(normalized)) > MaxSynonymWords {
+		return domain.Synonym{}, apierror.InvalidParameter("term", fmt.Sprintf("must be at most %d words", MaxSynonymWords))
+	}
+
+	symbols = distinctSymbols(symbols)
+	if len(symbols) == 0 {
+		return domain.Synonym{}, apierror.InvalidParameter("symbols", "must contain at least one symbol")
+	}
+
+	return domain.Synonym{
+		Term:      normalized,
+		Symbols:   symbols,
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// normalizeSynonymTerm folds a term into space separated words, the form synonyms are stored and looked up in.
+func normalizeSynonymTerm(term string) string {
+	return strings.Join(textutil.Words(term), " ")
+}
+
+func distinctSymbols(symbols []string) []string {
+	distinct := make([]string, 0, len(symbols))
+	seen := make(map[string]bool)
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		distinct = append(distinct, symbol)
+	}
+
+	return distinct
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveSynonym(t *testing.T) {
+	assert := assert.New(t)
+
+	synonymRepo := &repository.MockSynonymRepo{}
+	svc := NewSynonymService(synonymRepo)
+
+	synonym, err := svc.Save("  Coca-Cola ", []string{"ko", "KO", " coke "})
+	assert.NoError(err)
+	assert.Equal("coca cola", synonym.Term)
+	assert.Equal([]string{"KO", "COKE"}, synonym.Symbols)
+	assert.False(synonym.UpdatedAt.IsZero())
+	assert.Equal(synonym, synonymRepo.SaveArg)
+
+	synonymRepo.UnsetArgs()
+	_, err = svc.Save("!?", []string{"KO"})
+	assert.True(apierror.HasCode(err, apierror.CodeInvalidParameter))
+	_, err = svc.Save("coca cola", []string{" "})
+	assert.True(apierror.HasCode(err, apierror.CodeInvalidParameter))
+	_, err = svc.Save("the coca cola company of atlanta", []string{"KO"})
+	assert.True(apierror.HasCode(err, apierror.CodeInvalidParameter))
+	assert.Equal(0, synonymRepo.SaveInvocations)
+
+	synonymRepo.DeleteErr = repository.ErrNoSuchSynonym
+	err = svc.Delete("Pepsi")
+	assert.True(apierror.HasCode(err, apierror.CodeNotFound))
+	assert.Equal("pepsi", synonymRepo.DeleteArg)
+}
+
+func TestImportSynonyms(t *testing.T) {
+	assert := assert.New(t)
+
+	synonymRepo := &repository.MockSynonymRepo{}
+	svc := NewSynonymService(synonymRepo)
+
+	imported, err := svc.Import([]domain.Synonym{
+		domain.Synonym{Term: "Google", Symbols: []string{"GOOGL"}},
+		domain.Synonym{Term: "Coca Cola", Symbols: []string{"KO"}},
+		domain.Synonym{Term: "google", Symbols: []string{"goog", "GOOGL"}},
+	}, true)
+	assert.NoError(err)
+	assert.Equal(2, imported)
+	assert.True(synonymRepo.ImportArgReplace)
+	assert.Equal(2, len(synonymRepo.ImportArgSynonyms))
+	assert.Equal("google", synonymRepo.ImportArgSynonyms[0].Term)
+	assert.Equal([]string{"GOOGL", "GOOG"}, synonymRepo.ImportArgSynonyms[0].Symbols)
+	assert.Equal("coca cola", synonymRepo.ImportArgSynonyms[1].Term)
+
+	synonymRepo.UnsetArgs()
+	_, err = svc.Import([]domain.Synonym{
+		domain.Synonym{Term: "Google", Symbols: []string{"GOOGL"}},
+		domain.Synonym{Term: "Pepsi"},
+	}, false)
+	assert.True(apierror.HasCode(err, apierror.CodeInvalidParameter))
+	assert.Equal(0, synonymRepo.ImportInvocations)
+}
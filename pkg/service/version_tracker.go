@@ -0,0 +1,96 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+)
+
+// DataVersions the versions of the data search results and responses are computed from.
+type DataVersions struct {
+	LastRanking time.Time
+	Synonyms    domain.DataVersion
+	Anomalies   domain.DataVersion
+}
+
+// LastModified returns the time of the latest change of the data.
+func (v DataVersions) LastModified() time.Time {
+	latest := v.LastRanking
+	for _, t := range []time.Time{v.Synonyms.UpdatedAt, v.Anomalies.UpdatedAt} {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest
+}
+
+// VersionTracker tracks the versions of the data, reading them from the database at most once per
+// interval. Rankings and synonym edits of this replica are noticed at once, as the tracker is notified
+// of them as a ranking listener and cache invalidator, while changes made by other replicas are
+// noticed once the interval has passed.
+type VersionTracker struct {
+	stockRepo   repository.StockRepo
+	versionRepo repository.VersionRepo
+	interval    time.Duration
+
+	mu        sync.Mutex
+	versions  DataVersions
+	checkedAt time.Time
+}
+
+// NewVersionTracker creates a VersionTracker reading the versions when first asked for them.
+func NewVersionTracker(stockRepo repository.StockRepo, versionRepo repository.VersionRepo, interval time.Duration) *VersionTracker {
+	return &VersionTracker{
+		stockRepo:   stockRepo,
+		versionRepo: versionRepo,
+		interval:    interval,
+	}
+}
+
+// Versions returns the current versions of the data, which are read from
+// the database if they were last read more than the interval ago.
+func (t *VersionTracker) Versions() (DataVersions, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.checkedAt.IsZero() && time.Since(t.checkedAt) < t.interval {
+		return t.versions, nil
+	}
+
+	lastRanking, err := t.stockRepo.LastUpdated()
+	if err != nil {
+		return DataVersions{}, err
+	}
+
+	versions, err := t.versionRepo.FindVersions()
+	if err != nil {
+		return DataVersions{}, err
+	}
+
+	t.versions = DataVersions{
+		LastRanking: lastRanking,
+		Synonyms:    versions[repository.SynonymVersion],
+		Anomalies:   versions[repository.AnomalyVersion],
+	}
+	t.checkedAt = time.Now()
+	return t.versions, nil
+}
+
+// BeforeRanking does nothing as the versions only change once the ranking has completed.
+func (t *VersionTracker) BeforeRanking(symbol string) {}
+
+// OnRanking makes the versions be read again as the ranking changed them.
+func (t *VersionTracker) OnRanking(event domain.RankingEvent) {
+	t.Invalidate()
+}
+
+// Invalidate makes the versions be read again when next asked for.
+func (t *VersionTracker) Invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.checkedAt = time.Time{}
+}
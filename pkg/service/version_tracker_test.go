@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionTrackerReadsVersionsOncePerInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	lastRanking := time.Date(2019, 1, 29, 6, 0, 0, 0, time.UTC)
+	stockRepo := &repository.MockStockRepo{LastUpdatedTime: lastRanking}
+	versionRepo := &repository.MockVersionRepo{
+		FindVersionsResult: map[string]domain.DataVersion{
+			repository.SynonymVersion: domain.DataVersion{Version: 2, UpdatedAt: lastRanking.Add(time.Hour)},
+		},
+	}
+	tracker := NewVersionTracker(stockRepo, versionRepo, time.Hour)
+
+	versions, err := tracker.Versions()
+	assert.NoError(err)
+	assert.Equal(lastRanking, versions.LastRanking)
+	assert.Equal(int64(2), versions.Synonyms.Version)
+	assert.Equal(int64(0), versions.Anomalies.Version)
+	assert.Equal(lastRanking.Add(time.Hour), versions.LastModified())
+
+	// Changes of other replicas are not read again within the interval.
+	stockRepo.LastUpdatedTime = lastRanking.Add(2 * time.Hour)
+	versions, err = tracker.Versions()
+	assert.NoError(err)
+	assert.Equal(lastRanking, versions.LastRanking)
+	assert.Equal(1, stockRepo.LastUpdatedInvocations)
+	assert.Equal(1, versionRepo.FindVersionsInvocations)
+
+	tracker.OnRanking(domain.RankingEvent{})
+	versions, err = tracker.Versions()
+	assert.NoError(err)
+	assert.Equal(lastRanking.Add(2*time.Hour), versions.LastRanking)
+	assert.Equal(2, versionRepo.FindVersionsInvocations)
+
+	tracker.Invalidate()
+	versionRepo.FindVersionsErr = errors.New("connection refused")
+	_, err = tracker.Versions()
+	assert.Error(err)
+
+	// Failed reads are retried.
+	versionRepo.FindVersionsErr = nil
+	_, err = tracker.Versions()
+	assert.NoError(err)
+	assert.Equal(4, versionRepo.FindVersionsInvocations)
+}
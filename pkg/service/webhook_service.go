@@ -0,0 +1,351 @@
+package service
+
+import (
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mimir-news/pkg/id"
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/webhook"
+)
+
+// WebhookOptions configuration of webhook delivery. Events are delivered by a fixed
+// number of workers from a bounded queue, and only the latest DeliveryLogSize
+// delivery attempts of each subscription are kept.
+type WebhookOptions struct {
+	TopN            int
+	MaxAttempts     int
+	InitialBackoff  time.Duration
+	Workers         int
+	QueueSize       int
+	DeliveryLogSize int
+}
+
+// DefaultWebhookOptions returns options tracking the top 10 stocks
+// and delivering each event up to five times with doubling backoff.
+func DefaultWebhookOptions() WebhookOptions {
+	return WebhookOptions{
+		TopN:            10,
+		MaxAttempts:     5,
+		InitialBackoff:  time.Second,
+		Workers:         4,
+		QueueSize:       100,
+		DeliveryLogSize: 50,
+	}
+}
+
+// WebhookService service for managing webhook subscriptions and delivering ranking events.
+type WebhookService interface {
+	RankingListener
+	Subscribe(url, secret string, events []string) (domain.Subscription, error)
+	Unsubscribe(id string) error
+	GetSubscriptions() ([]domain.Subscription, error)
+	GetDeliveries(subscriptionID string, limit int) ([]domain.Delivery, error)
+	Wait(timeout time.Duration) bool
+}
+
+// NewWebhookService creates a WebhookService using the default implementation.
+func NewWebhookService(webhookRepo repository.WebhookRepo, stockRepo repository.StockRepo,
+	sender webhook.Sender, opts WebhookOptions) WebhookService {
+	svc := &webhookSvc{
+		webhookRepo: webhookRepo,
+		stockRepo:   stockRepo,
+		sender:      sender,
+		opts:        opts,
+		queue:       make(chan delivery, opts.QueueSize),
+		stopping:    make(chan struct{}),
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		go svc.work()
+	}
+
+	return svc
+}
+
+type webhookSvc struct {
+	webhookRepo repository.WebhookRepo
+	stockRepo   repository.StockRepo
+	sender      webhook.Sender
+	opts        WebhookOptions
+
+	mu          sync.Mutex
+	previousTop []domain.Stock
+	queue       chan delivery
+	deliveries  sync.WaitGroup
+	stopping    chan struct{}
+	stopOnce    sync.Once
+}
+
+// delivery an event queued for delivery to a subscription.
+type delivery struct {
+	subscription domain.Subscription
+	event        domain.WebhookEvent
+}
+
+// Subscribe registers a webhook subscription. A secret is generated if none is provided
+// and the subscription is subscribed to all events if none are specified.
+func (svc *webhookSvc) Subscribe(rawURL, secret string, events []string) (domain.Subscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return domain.Subscription{}, apierror.InvalidParameter("url", "must be an absolute http or https url")
+	}
+
+	if len(events) == 0 {
+		events = domain.WebhookEvents
+	}
+	for _, event := range events {
+		if !isWebhookEvent(event) {
+			return domain.Subscription{}, apierror.InvalidParameter("events", "unknown event "+event)
+		}
+	}
+
+	if secret == "" {
+		secret = id.New()
+	}
+
+	subscription := domain.Subscription{
+		ID:        id.New(),
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = svc.webhookRepo.SaveSubscription(subscription)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	return subscription, nil
+}
+
+// Unsubscribe deletes a webhook subscription.
+func (svc *webhookSvc) Unsubscribe(id string) error {
+	err := svc.webhookRepo.DeleteSubscription(id)
+	if err == repository.ErrNoSuchSubscription {
+		return apierror.NotFound("No such subscription: " + id)
+	}
+
+	return err
+}
+
+// GetSubscriptions lists all webhook subscriptions without their secrets.
+func (svc *webhookSvc) GetSubscriptions() ([]domain.Subscription, error) {
+	subscriptions, err := svc.webhookRepo.FindSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+// GetDeliveries gets the most recent delivery attempts of a subscription.
+func (svc *webhookSvc) GetDeliveries(subscriptionID string, limit int) ([]domain.Delivery, error) {
+	return svc.webhookRepo.FindDeliveries(subscriptionID, limit)
+}
+
+// Wait stops retrying failed deliveries and waits for queued and ongoing deliveries,
+// returning false if the timeout expires first.
+func (svc *webhookSvc) Wait(timeout time.Duration) bool {
+	svc.stopOnce.Do(func() {
+		close(svc.stopping)
+	})
+
+	return waitTimeout(&svc.deliveries, timeout)
+}
+
+// BeforeRanking records the top stocks so changes in the top list can be detected.
+func (svc *webhookSvc) BeforeRanking(symbol string) {
+	top, err := svc.findTop()
+	if err != nil {
+		log.Println("Failed to get top stocks before ranking:", err)
+	}
+
+	svc.mu.Lock()
+	svc.previousTop = top
+	svc.mu.Unlock()
+}
+
+// OnRanking sends ranking events to all subscriptions.
+func (svc *webhookSvc) OnRanking(event domain.RankingEvent) {
+	report := event.Report
+	events := []domain.WebhookEvent{
+		svc.newEvent(domain.EventRankingCompleted, event.Symbol, 0, &report),
+	}
+
+	svc.mu.Lock()
+	previousTop := svc.previousTop
+	svc.previousTop = nil
+	svc.mu.Unlock()
+
+	currentTop, err := svc.findTop()
+	if err != nil {
+		log.Println("Failed to get top stocks after ranking:", err)
+	} else if previousTop != nil {
+		events = append(events, svc.topChangeEvents(previousTop, currentTop)...)
+	}
+
+	svc.dispatch(events)
+}
+
+func (svc *webhookSvc) topChangeEvents(previous, current []domain.Stock) []domain.WebhookEvent {
+	previousRanks := rankBySymbol(previous)
+	currentRanks := rankBySymbol(current)
+
+	events := make([]domain.WebhookEvent, 0)
+	for _, s := range current {
+		if _, ok := previousRanks[s.Symbol]; !ok {
+			events = append(events, svc.newEvent(domain.EventEnteredTop, s.Symbol, currentRanks[s.Symbol], nil))
+		}
+	}
+
+	for _, s := range previous {
+		if _, ok := currentRanks[s.Symbol]; !ok {
+			events = append(events, svc.newEvent(domain.EventLeftTop, s.Symbol, 0, nil))
+		}
+	}
+
+	return events
+}
+
+func (svc *webhookSvc) newEvent(name, symbol string, rank int, report *domain.RankingReport) domain.WebhookEvent {
+	return domain.WebhookEvent{
+		ID:        id.New(),
+		Event:     name,
+		Symbol:    symbol,
+		Rank:      rank,
+		TopN:      svc.opts.TopN,
+		Report:    report,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func (svc *webhookSvc) dispatch(events []domain.WebhookEvent) {
+	subscriptions, err := svc.webhookRepo.FindSubscriptions()
+	if err != nil {
+		log.Println("Failed to get webhook subscriptions:", err)
+		return
+	}
+
+	for _, event := range events {
+		for _, subscription := range subscriptions {
+			if !subscription.Subscribes(event.Event) {
+				continue
+			}
+
+			svc.enqueue(delivery{subscription: subscription, event: event})
+		}
+	}
+}
+
+// enqueue queues an event for delivery, dropping it if the queue is full.
+func (svc *webhookSvc) enqueue(d delivery) {
+	svc.deliveries.Add(1)
+	select {
+	case svc.queue <- d:
+	default:
+		svc.deliveries.Done()
+		log.Printf("Webhook queue full, dropping event %s to subscription %s\n", d.event.ID, d.subscription.ID)
+	}
+}
+
+// work delivers queued events one at a time.
+func (svc *webhookSvc) work() {
+	for d := range svc.queue {
+		svc.deliver(d.subscription, d.event)
+	}
+}
+
+// deliver sends an event to a subscription, retrying with exponential backoff
+// until delivered, out of attempts or the service is stopping.
+func (svc *webhookSvc) deliver(subscription domain.Subscription, event domain.WebhookEvent) {
+	defer svc.deliveries.Done()
+	defer svc.pruneDeliveries(subscription.ID)
+
+	backoff := svc.opts.InitialBackoff
+	for attempt := 1; attempt <= svc.opts.MaxAttempts; attempt++ {
+		status, err := svc.sender.SendSigned(subscription.URL, subscription.Secret, event)
+		svc.logDelivery(subscription, event, attempt, status, err)
+		if err == nil {
+			return
+		}
+
+		if attempt < svc.opts.MaxAttempts && !svc.sleep(backoff) {
+			break
+		}
+		backoff *= 2
+	}
+
+	log.Printf("Giving up delivery of event %s to subscription %s\n", event.ID, subscription.ID)
+}
+
+// sleep waits before retrying a delivery, returning false if the service is stopping.
+func (svc *webhookSvc) sleep(d time.Duration) bool {
+	select {
+	case <-svc.stopping:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (svc *webhookSvc) logDelivery(subscription domain.Subscription, event domain.WebhookEvent, attempt, status int, err error) {
+	delivery := domain.Delivery{
+		ID:             id.New(),
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		Event:          event.Event,
+		Attempt:        attempt,
+		StatusCode:     status,
+		Success:        err == nil,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	saveErr := svc.webhookRepo.SaveDelivery(delivery)
+	if saveErr != nil {
+		log.Println("Failed to save webhook delivery:", saveErr)
+	}
+}
+
+// pruneDeliveries removes all but the latest delivery attempts of a subscription.
+func (svc *webhookSvc) pruneDeliveries(subscriptionID string) {
+	err := svc.webhookRepo.PruneDeliveries(subscriptionID, svc.opts.DeliveryLogSize)
+	if err != nil {
+		log.Println("Failed to prune webhook deliveries:", err)
+	}
+}
+
+func (svc *webhookSvc) findTop() ([]domain.Stock, error) {
+	return svc.stockRepo.FindMostCommon([]string{}, "", svc.opts.TopN)
+}
+
+func rankBySymbol(stocks []domain.Stock) map[string]int {
+	ranks := make(map[string]int, len(stocks))
+	for i, s := range stocks {
+		ranks[s.Symbol] = i + 1
+	}
+
+	return ranks
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range domain.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
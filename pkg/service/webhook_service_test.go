@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/mimir-news/stock-search/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookServiceTopChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL"},
+			domain.Stock{Symbol: "AMD"},
+		},
+	}
+	webhookRepo := &repository.MockWebhookRepo{
+		FindSubscriptionsResult: []domain.Subscription{
+			domain.Subscription{ID: "all", URL: "http://all", Secret: "s1", Events: domain.WebhookEvents},
+			domain.Subscription{ID: "top", URL: "http://top", Secret: "s2", Events: []string{domain.EventLeftTop}},
+		},
+	}
+	sender := &webhook.MockSender{}
+
+	opts := DefaultWebhookOptions()
+	opts.TopN = 2
+	svc := NewWebhookService(webhookRepo, stockRepo, sender, opts)
+
+	svc.BeforeRanking("")
+	assert.Equal(2, stockRepo.FindMostCommonArgLimit)
+	stockRepo.FindMostCommonStocks = []domain.Stock{
+		domain.Stock{Symbol: "AMD"},
+		domain.Stock{Symbol: "TSLA"},
+	}
+	svc.OnRanking(domain.RankingEvent{Report: domain.RankingReport{RankedStocks: 3}})
+	svc.(*webhookSvc).deliveries.Wait()
+
+	events := make(map[string][]domain.WebhookEvent)
+	for i, payload := range sender.SendArgPayloads {
+		events[sender.SendArgURLs[i]] = append(events[sender.SendArgURLs[i]], payload.(domain.WebhookEvent))
+	}
+
+	assert.Equal(3, len(events["http://all"]))
+	assert.Equal(1, len(events["http://top"]))
+	assert.Equal(domain.EventLeftTop, events["http://top"][0].Event)
+	assert.Equal("AAPL", events["http://top"][0].Symbol)
+
+	for _, e := range events["http://all"] {
+		switch e.Event {
+		case domain.EventRankingCompleted:
+			assert.Equal(3, e.Report.RankedStocks)
+		case domain.EventEnteredTop:
+			assert.Equal("TSLA", e.Symbol)
+			assert.Equal(2, e.Rank)
+		case domain.EventLeftTop:
+			assert.Equal("AAPL", e.Symbol)
+		}
+	}
+
+	assert.Equal(4, webhookRepo.SaveDeliveryInvocations)
+	assert.Equal(4, webhookRepo.PruneDeliveriesInvocations)
+	assert.Equal(opts.DeliveryLogSize, webhookRepo.PruneDeliveriesArgKeep)
+	for _, d := range webhookRepo.SaveDeliveryArgs {
+		assert.True(d.Success)
+		assert.Equal(1, d.Attempt)
+	}
+}
+
+func TestWebhookServiceRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{
+		FindSubscriptionsResult: []domain.Subscription{
+			domain.Subscription{ID: "sub", URL: "http://sub", Secret: "s", Events: domain.WebhookEvents},
+		},
+	}
+	sender := &webhook.MockSender{
+		SendStatus: 503,
+		SendErr:    errors.New("unavailable"),
+	}
+
+	opts := DefaultWebhookOptions()
+	opts.MaxAttempts = 3
+	opts.InitialBackoff = 0
+	svc := NewWebhookService(webhookRepo, &repository.MockStockRepo{}, sender, opts)
+
+	svc.OnRanking(domain.RankingEvent{Symbol: "AAPL"})
+	svc.(*webhookSvc).deliveries.Wait()
+
+	assert.Equal(3, len(sender.SendArgURLs))
+	assert.Equal(3, webhookRepo.SaveDeliveryInvocations)
+	last := webhookRepo.SaveDeliveryArgs[2]
+	assert.Equal(3, last.Attempt)
+	assert.Equal(503, last.StatusCode)
+	assert.False(last.Success)
+	assert.Equal("unavailable", last.Error)
+	assert.Equal(1, webhookRepo.PruneDeliveriesInvocations)
+	assert.Equal("sub", webhookRepo.PruneDeliveriesArgID)
+}
+
+func TestWebhookServiceStopsRetryingOnWait(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{
+		FindSubscriptionsResult: []domain.Subscription{
+			domain.Subscription{ID: "sub", URL: "http://sub", Secret: "s", Events: domain.WebhookEvents},
+		},
+	}
+	sender := &webhook.MockSender{
+		SendStatus: 503,
+		SendErr:    errors.New("unavailable"),
+	}
+
+	opts := DefaultWebhookOptions()
+	opts.InitialBackoff = time.Hour
+	svc := NewWebhookService(webhookRepo, &repository.MockStockRepo{}, sender, opts)
+
+	svc.OnRanking(domain.RankingEvent{Symbol: "AAPL"})
+	assert.True(svc.Wait(time.Second))
+	assert.Equal(1, len(sender.SendArgURLs))
+	assert.Equal(1, webhookRepo.PruneDeliveriesInvocations)
+}
+
+func TestWebhookServiceDropsEventsWhenQueueIsFull(t *testing.T) {
+	assert := assert.New(t)
+
+	webhookRepo := &repository.MockWebhookRepo{
+		FindSubscriptionsResult: []domain.Subscription{
+			domain.Subscription{ID: "sub", URL: "http://sub", Secret: "s", Events: domain.WebhookEvents},
+		},
+	}
+	sender := &webhook.MockSender{}
+
+	opts := DefaultWebhookOptions()
+	opts.Workers = 0
+	opts.QueueSize = 1
+	svc := NewWebhookService(webhookRepo, &repository.MockStockRepo{}, sender, opts)
+
+	svc.OnRanking(domain.RankingEvent{Symbol: "AAPL"})
+	svc.OnRanking(domain.RankingEvent{Symbol: "AMD"})
+	assert.Equal(1, len(svc.(*webhookSvc).queue))
+}
@@ -0,0 +1,304 @@
+package stream
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+)
+
+// Event types.
+const (
+	RankingEvent = "ranking"
+	StockEvent   = "stock"
+)
+
+// Event a ranking update sent to stream clients.
+type Event struct {
+	ID     int64         `json:"id"`
+	Type   string        `json:"type"`
+	Stocks []RankedStock `json:"stocks"`
+}
+
+// RankedStock a stock and its position in the ranking.
+type RankedStock struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	Count  int64  `json:"count"`
+	Rank   int    `json:"rank"`
+}
+
+// Options configuration of a Broker.
+type Options struct {
+	LeaderboardSize int
+	BacklogSize     int
+	BufferSize      int
+}
+
+// DefaultOptions returns options tracking the top 100 stocks and
+// keeping the last 100 events for clients that resume.
+func DefaultOptions() Options {
+	return Options{
+		LeaderboardSize: 100,
+		BacklogSize:     100,
+		BufferSize:      16,
+	}
+}
+
+// Subscription a stream client receiving events.
+// The events channel is closed if the client falls too far behind.
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+}
+
+// Broker keeps track of the current ranking and publishes ranking updates to subscribed
+// stream clients. Without a relay only rankings run by the same replica are published.
+type Broker struct {
+	stockRepo repository.StockRepo
+	opts      Options
+
+	mu            sync.Mutex
+	relay         Relay
+	lastID        int64
+	leaderboard   []RankedStock
+	backlog       []Event
+	subscriptions map[*Subscription]bool
+}
+
+// NewBroker creates a new broker with the leaderboard of the latest ranking.
+func NewBroker(stockRepo repository.StockRepo, opts Options) *Broker {
+	leaderboard, err := findLeaderboard(stockRepo, opts.LeaderboardSize)
+	if err != nil {
+		log.Println("Failed to get ranking for stream:", err)
+	}
+
+	return &Broker{
+		stockRepo:     stockRepo,
+		opts:          opts,
+		leaderboard:   leaderboard,
+		backlog:       make([]Event, 0, opts.BacklogSize),
+		subscriptions: make(map[*Subscription]bool),
+	}
+}
+
+// Listen sends rankings through a relay and publishes the rankings received from it,
+// so clients of every replica receive the rankings run by any replica.
+func (b *Broker) Listen(relay Relay) {
+	b.mu.Lock()
+	b.relay = relay
+	b.mu.Unlock()
+
+	go func() {
+		for event := range relay.Events() {
+			b.publishEvent(event)
+		}
+	}()
+}
+
+// Subscribe registers a new client. Events published after the last event id are returned
+// for the client to replay. If the client does not resume or the last event id is no longer
+// in the backlog, a ranking event with the current leaderboard is returned instead.
+func (b *Broker) Subscribe(lastEventID int64) (*Subscription, []Event) {
+	events := make(chan Event, b.opts.BufferSize)
+	sub := &Subscription{
+		Events: events,
+		events: events,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions[sub] = true
+	if !b.canResume(lastEventID) {
+		return sub, []Event{b.snapshot()}
+	}
+
+	missed := make([]Event, 0)
+	for _, e := range b.backlog {
+		if e.ID > lastEventID {
+			missed = append(missed, e)
+		}
+	}
+
+	return sub, missed
+}
+
+// canResume checks if all events after the last event id are in the backlog.
+// Must be called while holding the lock.
+func (b *Broker) canResume(lastEventID int64) bool {
+	if lastEventID <= 0 {
+		return false
+	}
+
+	for _, e := range b.backlog {
+		if e.ID == lastEventID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// snapshot returns the current leaderboard as a ranking event with the id of the latest event.
+// Must be called while holding the lock.
+func (b *Broker) snapshot() Event {
+	return Event{
+		ID:     b.lastID,
+		Type:   RankingEvent,
+		Stocks: copyStocks(b.leaderboard),
+	}
+}
+
+// Unsubscribe removes a client.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscriptions[sub] {
+		delete(b.subscriptions, sub)
+		close(sub.events)
+	}
+}
+
+// BeforeRanking does nothing as events are published after ranking.
+func (b *Broker) BeforeRanking(symbol string) {}
+
+// OnRanking publishes the new ranking to all clients, through the relay if listening to one.
+func (b *Broker) OnRanking(event domain.RankingEvent) {
+	b.mu.Lock()
+	relay := b.relay
+	b.mu.Unlock()
+
+	if relay != nil {
+		err := relay.Send(event)
+		if err == nil {
+			return
+		}
+		log.Println("Failed to relay ranking, publishing to local clients only:", err)
+	}
+
+	b.publishEvent(event)
+}
+
+func (b *Broker) publishEvent(event domain.RankingEvent) {
+	if event.Symbol == "" {
+		b.publishRanking()
+		return
+	}
+
+	for _, s := range event.Stocks {
+		b.publishStock(s)
+	}
+}
+
+func (b *Broker) publishRanking() {
+	leaderboard, err := findLeaderboard(b.stockRepo, b.opts.LeaderboardSize)
+	if err != nil {
+		log.Println("Failed to get ranking for stream:", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.leaderboard = leaderboard
+	b.publish(RankingEvent, copyStocks(leaderboard))
+}
+
+func (b *Broker) publishStock(s domain.Stock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	updated := RankedStock{Symbol: s.Symbol, Name: s.Name, Count: s.Count}
+	found := false
+	for i := range b.leaderboard {
+		if b.leaderboard[i].Symbol == s.Symbol {
+			updated.Name = b.leaderboard[i].Name
+			b.leaderboard[i] = updated
+			found = true
+		}
+	}
+	if !found {
+		b.leaderboard = append(b.leaderboard, updated)
+	}
+
+	sort.SliceStable(b.leaderboard, func(i, j int) bool {
+		return b.leaderboard[i].Count > b.leaderboard[j].Count
+	})
+	for i := range b.leaderboard {
+		b.leaderboard[i].Rank = i + 1
+		if b.leaderboard[i].Symbol == s.Symbol {
+			updated = b.leaderboard[i]
+		}
+	}
+
+	if len(b.leaderboard) > b.opts.LeaderboardSize {
+		b.leaderboard = b.leaderboard[:b.opts.LeaderboardSize]
+	}
+
+	b.publish(StockEvent, []RankedStock{updated})
+}
+
+// publish sends an event to all subscriptions. Must be called while holding the lock.
+func (b *Broker) publish(eventType string, stocks []RankedStock) {
+	b.lastID = nextEventID(b.lastID)
+	event := Event{
+		ID:     b.lastID,
+		Type:   eventType,
+		Stocks: stocks,
+	}
+
+	if len(b.backlog) == b.opts.BacklogSize && len(b.backlog) > 0 {
+		b.backlog = b.backlog[1:]
+	}
+	b.backlog = append(b.backlog, event)
+
+	for sub := range b.subscriptions {
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscriptions, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// nextEventID returns a millisecond timestamp based id, which keeps ids
+// increasing across restarts as long as less than one event is published per millisecond.
+func nextEventID(lastID int64) int64 {
+	id := time.Now().UnixNano() / int64(time.Millisecond)
+	if id <= lastID {
+		return lastID + 1
+	}
+
+	return id
+}
+
+func findLeaderboard(stockRepo repository.StockRepo, size int) ([]RankedStock, error) {
+	stocks, err := stockRepo.FindMostCommon([]string{}, "", size)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := make([]RankedStock, 0, len(stocks))
+	for i, s := range stocks {
+		leaderboard = append(leaderboard, RankedStock{
+			Symbol: s.Symbol,
+			Name:   s.Name,
+			Count:  s.Count,
+			Rank:   i + 1,
+		})
+	}
+
+	return leaderboard, nil
+}
+
+func copyStocks(stocks []RankedStock) []RankedStock {
+	c := make([]RankedStock, len(stocks))
+	copy(c, stocks)
+	return c
+}
@@ -0,0 +1,172 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mimir-news/stock-search/pkg/domain"
+	"github.com/mimir-news/stock-search/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Name: "Apple Inc.", Count: 30},
+			domain.Stock{Symbol: "AMD", Name: "AMD Inc.", Count: 20},
+			domain.Stock{Symbol: "TSLA", Name: "Tesla Inc.", Count: 10},
+		},
+	}
+
+	broker := NewBroker(stockRepo, DefaultOptions())
+	assert.Equal(1, stockRepo.FindMostCommonInvocations)
+	sub, missed := broker.Subscribe(0)
+	assert.Equal(1, len(missed))
+	assert.Equal(RankingEvent, missed[0].Type)
+	assert.Equal(int64(0), missed[0].ID)
+	assert.Equal(3, len(missed[0].Stocks))
+
+	broker.OnRanking(domain.RankingEvent{})
+	ranking := <-sub.Events
+	assert.Equal(RankingEvent, ranking.Type)
+	assert.Equal(3, len(ranking.Stocks))
+	assert.Equal("AAPL", ranking.Stocks[0].Symbol)
+	assert.Equal(1, ranking.Stocks[0].Rank)
+
+	broker.OnRanking(domain.RankingEvent{
+		Symbol: "TSLA",
+		Stocks: []domain.Stock{domain.Stock{Symbol: "TSLA", Count: 25}},
+	})
+	update := <-sub.Events
+	assert.Equal(StockEvent, update.Type)
+	assert.True(update.ID > ranking.ID)
+	assert.Equal(1, len(update.Stocks))
+	assert.Equal("TSLA", update.Stocks[0].Symbol)
+	assert.Equal("Tesla Inc.", update.Stocks[0].Name)
+	assert.Equal(int64(25), update.Stocks[0].Count)
+	assert.Equal(2, update.Stocks[0].Rank)
+
+	broker.Unsubscribe(sub)
+	_, ok := <-sub.Events
+	assert.False(ok)
+
+	resumed, missed := broker.Subscribe(ranking.ID)
+	assert.Equal(1, len(missed))
+	assert.Equal(update.ID, missed[0].ID)
+	broker.Unsubscribe(resumed)
+
+	restarted, missed := broker.Subscribe(ranking.ID - 1)
+	assert.Equal(1, len(missed))
+	assert.Equal(RankingEvent, missed[0].Type)
+	assert.Equal(update.ID, missed[0].ID)
+	assert.Equal("AAPL", missed[0].Stocks[0].Symbol)
+	assert.Equal("TSLA", missed[0].Stocks[1].Symbol)
+	assert.Equal(int64(25), missed[0].Stocks[1].Count)
+	broker.Unsubscribe(restarted)
+}
+
+func TestBrokerPublishesRelayedRankings(t *testing.T) {
+	assert := assert.New(t)
+
+	stockRepo := &repository.MockStockRepo{
+		FindMostCommonStocks: []domain.Stock{
+			domain.Stock{Symbol: "AAPL", Name: "Apple Inc.", Count: 30},
+		},
+	}
+	relay := &testRelay{events: make(chan domain.RankingEvent, 1)}
+
+	broker := NewBroker(stockRepo, DefaultOptions())
+	broker.Listen(relay)
+	sub, _ := broker.Subscribe(0)
+
+	ranked := domain.RankingEvent{
+		Symbol: "AAPL",
+		Stocks: []domain.Stock{domain.Stock{Symbol: "AAPL", Count: 35}},
+	}
+	broker.OnRanking(ranked)
+	assert.Equal([]domain.RankingEvent{ranked}, relay.sent)
+	assert.Equal(0, len(sub.Events))
+
+	relay.events <- ranked
+	update := <-sub.Events
+	assert.Equal(StockEvent, update.Type)
+	assert.Equal(int64(35), update.Stocks[0].Count)
+
+	relay.err = errors.New("connection refused")
+	broker.OnRanking(domain.RankingEvent{})
+	ranking := <-sub.Events
+	assert.Equal(RankingEvent, ranking.Type)
+
+	close(relay.events)
+	broker.Unsubscribe(sub)
+}
+
+func TestBrokerDropsSlowClients(t *testing.T) {
+	assert := assert.New(t)
+
+	opts := DefaultOptions()
+	opts.BufferSize = 1
+	opts.BacklogSize = 2
+	broker := NewBroker(&repository.MockStockRepo{}, opts)
+	sub, _ := broker.Subscribe(0)
+
+	for i := 0; i < 3; i++ {
+		broker.OnRanking(domain.RankingEvent{})
+	}
+
+	<-sub.Events
+	_, ok := <-sub.Events
+	assert.False(ok)
+	assert.Equal(2, len(broker.backlog))
+}
+
+func TestFilterApply(t *testing.T) {
+	assert := assert.New(t)
+
+	event := Event{
+		Type: RankingEvent,
+		Stocks: []RankedStock{
+			RankedStock{Symbol: "AAPL", Rank: 1},
+			RankedStock{Symbol: "AMD", Rank: 2},
+			RankedStock{Symbol: "TSLA", Rank: 3},
+		},
+	}
+
+	filtered, ok := NewFilter(nil, 0).Apply(event)
+	assert.True(ok)
+	assert.Equal(3, len(filtered.Stocks))
+
+	filtered, ok = NewFilter(nil, 2).Apply(event)
+	assert.True(ok)
+	assert.Equal(2, len(filtered.Stocks))
+
+	filtered, ok = NewFilter([]string{"TSLA", "GOOG"}, 0).Apply(event)
+	assert.True(ok)
+	assert.Equal(1, len(filtered.Stocks))
+	assert.Equal("TSLA", filtered.Stocks[0].Symbol)
+
+	_, ok = NewFilter([]string{"TSLA"}, 2).Apply(event)
+	assert.False(ok)
+}
+
+type testRelay struct {
+	sent   []domain.RankingEvent
+	err    error
+	events chan domain.RankingEvent
+}
+
+func (r *testRelay) Send(event domain.RankingEvent) error {
+	r.sent = append(r.sent, event)
+	return r.err
+}
+
+func (r *testRelay) Events() <-chan domain.RankingEvent {
+	return r.events
+}
+
+func (r *testRelay) Close() error {
+	close(r.events)
+	return nil
+}
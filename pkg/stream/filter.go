@@ -0,0 +1,46 @@
+package stream
+
+// Filter selects which stocks a stream client receives.
+// A zero Top and empty Symbols disables the respective filter.
+type Filter struct {
+	Symbols map[string]bool
+	Top     int
+}
+
+// NewFilter creates a filter for the given symbols and top list size.
+func NewFilter(symbols []string, top int) Filter {
+	symbolSet := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		symbolSet[symbol] = true
+	}
+
+	return Filter{
+		Symbols: symbolSet,
+		Top:     top,
+	}
+}
+
+// Apply filters the stocks of an event, returning false if none of them match.
+func (f Filter) Apply(e Event) (Event, bool) {
+	stocks := make([]RankedStock, 0, len(e.Stocks))
+	for _, s := range e.Stocks {
+		if f.matches(s) {
+			stocks = append(stocks, s)
+		}
+	}
+
+	if len(stocks) == 0 {
+		return e, false
+	}
+
+	e.Stocks = stocks
+	return e, true
+}
+
+func (f Filter) matches(s RankedStock) bool {
+	if len(f.Symbols) > 0 && !f.Symbols[s.Symbol] {
+		return false
+	}
+
+	return f.Top <= 0 || (s.Rank > 0 && s.Rank <= f.Top)
+}
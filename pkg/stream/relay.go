@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mimir-news/stock-search/pkg/domain"
+)
+
+// RelayChannel name of the postgres notification channel ranking events are relayed on.
+const RelayChannel = "stock_ranking"
+
+// Relay forwards ranking events to the brokers of all replicas of the service,
+// including the replica sending them.
+type Relay interface {
+	Send(event domain.RankingEvent) error
+	Events() <-chan domain.RankingEvent
+	Close() error
+}
+
+// relayedEvent the payload of a relayed ranking event. Only the stocks of single stock
+// rankings are relayed, as complete rankings are read by each broker when received.
+type relayedEvent struct {
+	Symbol string         `json:"symbol"`
+	Stocks []relayedStock `json:"stocks"`
+}
+
+type relayedStock struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	Count  int64  `json:"count"`
+}
+
+// NewPgRelay creates a Relay sending ranking events with NOTIFY over db and receiving
+// them with LISTEN over a dedicated connection to the given data source. As LISTEN does not
+// work through transaction pooling the data source must connect directly to postgres.
+func NewPgRelay(db *sql.DB, listenDSN string) (Relay, error) {
+	listener := pq.NewListener(listenDSN, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Ranking relay connection failed:", err)
+		}
+	})
+
+	err := listener.Listen(RelayChannel)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	r := &pgRelay{
+		db:       db,
+		listener: listener,
+		events:   make(chan domain.RankingEvent),
+	}
+	go r.receive()
+
+	return r, nil
+}
+
+type pgRelay struct {
+	db       *sql.DB
+	listener *pq.Listener
+	events   chan domain.RankingEvent
+}
+
+const notifyQuery = `SELECT pg_notify($1, $2)`
+
+// Send notifies all listening replicas of a ranking event.
+func (r *pgRelay) Send(event domain.RankingEvent) error {
+	relayed := relayedEvent{Symbol: event.Symbol, Stocks: make([]relayedStock, 0)}
+	if event.Symbol != "" {
+		for _, s := range event.Stocks {
+			relayed.Stocks = append(relayed.Stocks, relayedStock{Symbol: s.Symbol, Name: s.Name, Count: s.Count})
+		}
+	}
+
+	payload, err := json.Marshal(relayed)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(notifyQuery, RelayChannel, string(payload))
+	return err
+}
+
+// Events returns the channel of received ranking events, which is closed when the relay is closed.
+func (r *pgRelay) Events() <-chan domain.RankingEvent {
+	return r.events
+}
+
+// Close stops listening for ranking events.
+func (r *pgRelay) Close() error {
+	return r.listener.Close()
+}
+
+// receive decodes notifications into ranking events. Notifications may have been missed
+// while the connection was reestablished, so a complete ranking is received after reconnecting.
+func (r *pgRelay) receive() {
+	defer close(r.events)
+
+	for n := range r.listener.Notify {
+		if n == nil {
+			r.events <- domain.RankingEvent{}
+			continue
+		}
+
+		var relayed relayedEvent
+		err := json.Unmarshal([]byte(n.Extra), &relayed)
+		if err != nil {
+			log.Println("Failed to decode relayed ranking event:", err)
+			continue
+		}
+
+		event := domain.RankingEvent{Symbol: relayed.Symbol, Stocks: make([]domain.Stock, 0, len(relayed.Stocks))}
+		for _, s := range relayed.Stocks {
+			event.Stocks = append(event.Stocks, domain.Stock{Symbol: s.Symbol, Name: s.Name, Count: s.Count})
+		}
+		r.events <- event
+	}
+}
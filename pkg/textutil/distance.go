@@ -0,0 +1,105 @@
+package textutil
+
+// keyboardRows rows of a Nordic QWERTY keyboard used to find adjacent keys.
+var keyboardRows = []string{
+	"1234567890",
+	"qwertyuiopå",
+	"asdfghjklöä",
+	"zxcvbnm",
+}
+
+type keyPosition struct {
+	row, col int
+}
+
+var keyPositions = newKeyPositions()
+
+func newKeyPositions() map[rune]keyPosition {
+	positions := make(map[rune]keyPosition)
+	for row, keys := range keyboardRows {
+		for col, key := range []rune(keys) {
+			positions[key] = keyPosition{row: row, col: col}
+		}
+	}
+
+	return positions
+}
+
+// Edit costs of TypoDistance.
+const (
+	editCost         = 1.0
+	adjacentKeysCost = 0.5
+)
+
+// AdjacentKeys checks if two keys are next to each other on the keyboard.
+func AdjacentKeys(a, b rune) bool {
+	pa, okA := keyPositions[a]
+	pb, okB := keyPositions[b]
+	if !okA || !okB || a == b {
+		return false
+	}
+
+	return abs(pa.row-pb.row) <= 1 && abs(pa.col-pb.col) <= 1
+}
+
+// TypoDistance is the edit distance between two words where insertions, deletions,
+// substitutions and transpositions of adjacent characters cost 1, except for
+// substitutions of keys next to each other on the keyboard which cost 0.5.
+func TypoDistance(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]float64, len(ra)+1)
+	for i := range d {
+		d[i] = make([]float64, len(rb)+1)
+		d[i][0] = float64(i) * editCost
+	}
+	for j := range d[0] {
+		d[0][j] = float64(j) * editCost
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			substitution := 0.0
+			if ra[i-1] != rb[j-1] {
+				substitution = editCost
+				if AdjacentKeys(ra[i-1], rb[j-1]) {
+					substitution = adjacentKeysCost
+				}
+			}
+
+			d[i][j] = min(d[i-1][j]+editCost, d[i][j-1]+editCost, d[i-1][j-1]+substitution)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+editCost)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// MaxTypos the largest TypoDistance to a word which is still considered a misspelling of it.
+func MaxTypos(word string) float64 {
+	if len([]rune(word)) <= 4 {
+		return 1
+	}
+
+	return 2
+}
+
+func min(values ...float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
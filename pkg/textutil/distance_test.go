@@ -0,0 +1,51 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypoDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		a        string
+		b        string
+		expected float64
+	}{
+		{a: "apple", b: "apple", expected: 0},
+		{a: "aple", b: "apple", expected: 1},
+		{a: "appke", b: "apple", expected: 0.5},
+		{a: "appme", b: "apple", expected: 1},
+		{a: "aplpe", b: "apple", expected: 1},
+		{a: "tesal", b: "tesla", expected: 1},
+		{a: "volvp", b: "volvo", expected: 0.5},
+		{a: "", b: "abc", expected: 3},
+		{a: "erocsson", b: "ericsson", expected: 0.5},
+		{a: "ssab", b: "abb", expected: 2.5},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.expected, TypoDistance(test.a, test.b), "%s -> %s", test.a, test.b)
+		assert.Equal(test.expected, TypoDistance(test.b, test.a), "%s -> %s", test.b, test.a)
+	}
+}
+
+func TestAdjacentKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(AdjacentKeys('a', 's'))
+	assert.True(AdjacentKeys('q', 'a'))
+	assert.True(AdjacentKeys('ö', 'ä'))
+	assert.False(AdjacentKeys('a', 'a'))
+	assert.False(AdjacentKeys('a', 'l'))
+	assert.False(AdjacentKeys('a', '$'))
+}
+
+func TestMaxTypos(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(1.0, MaxTypos("aapl"))
+	assert.Equal(2.0, MaxTypos("ericsson"))
+}
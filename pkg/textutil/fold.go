@@ -0,0 +1,67 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// letterFolds letters which do not decompose into a base letter and diacritics.
+var letterFolds = map[rune]string{
+	'ø': "o",
+	'æ': "ae",
+	'œ': "oe",
+	'ß': "ss",
+	'ł': "l",
+	'đ': "d",
+	'ð': "d",
+	'þ': "th",
+	'ı': "i",
+}
+
+// Fold normalizes text for accent and case insensitive matching.
+// The text is lower cased, compatibility decomposed and stripped of diacritics,
+// so "Nestlé" and "Ørsted" fold to "nestle" and "orsted".
+func Fold(text string) string {
+	lower := strings.ToLower(text)
+	stripped, _, err := transform.String(newFolder(), lower)
+	if err != nil {
+		stripped = lower
+	}
+
+	var b strings.Builder
+	b.Grow(len(stripped))
+	for _, r := range stripped {
+		if fold, ok := letterFolds[r]; ok {
+			b.WriteString(fold)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// FoldOffsets folds text like Fold, also returning the offset in characters
+// of the original character each folded character originates from.
+func FoldOffsets(text string) ([]rune, []int) {
+	folded := make([]rune, 0, len(text))
+	offsets := make([]int, 0, len(text))
+	for i, r := range []rune(text) {
+		for _, f := range Fold(string(r)) {
+			folded = append(folded, f)
+			offsets = append(offsets, i)
+		}
+	}
+
+	return folded, offsets
+}
+
+// newFolder creates a transformer removing diacritics. Transformers are stateful
+// and not safe for concurrent use, so a new one is created for each call.
+func newFolder() transform.Transformer {
+	return transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+}
@@ -0,0 +1,45 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFold(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "Nestlé S.A.", expected: "nestle s.a."},
+		{text: "Ørsted A/S", expected: "orsted a/s"},
+		{text: "Skandinaviska Enskilda Banken", expected: "skandinaviska enskilda banken"},
+		{text: "Hennes & Mauritz", expected: "hennes & mauritz"},
+		{text: "Göteborgs Energi", expected: "goteborgs energi"},
+		{text: "Åland Banken", expected: "aland banken"},
+		{text: "Mærsk", expected: "maersk"},
+		{text: "Straße", expected: "strasse"},
+		{text: "Łódź", expected: "lodz"},
+		{text: "Ｔｏｙｏｔａ", expected: "toyota"},
+		{text: "ERIC-B", expected: "eric-b"},
+		{text: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.expected, Fold(test.text), test.text)
+	}
+}
+
+func TestFoldOffsets(t *testing.T) {
+	assert := assert.New(t)
+
+	folded, offsets := FoldOffsets("Mærsk Ørsted")
+	assert.Equal("maersk orsted", string(folded))
+	assert.Equal([]int{0, 1, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, offsets)
+
+	folded, offsets = FoldOffsets("Nestlé")
+	assert.Equal("nestle", string(folded))
+	assert.Equal([]int{0, 1, 2, 3, 4, 5}, offsets)
+}
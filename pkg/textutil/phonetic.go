@@ -0,0 +1,159 @@
+package textutil
+
+import (
+	"strings"
+)
+
+// MinPhoneticLength shortest word which is matched phonetically.
+const MinPhoneticLength = 4
+
+// Phonetic encodes a word by how it sounds using a simplified Metaphone,
+// so "Nokeeya" and "Nokia" both encode to "NK" and "Ericson" and "Ericsson" to "ERKSN".
+// Vowels are only kept as the first letter and characters other than letters are ignored.
+func Phonetic(word string) string {
+	letters := make([]byte, 0, len(word))
+	for _, r := range Fold(word) {
+		if r >= 'a' && r <= 'z' {
+			if len(letters) > 0 && letters[len(letters)-1] == byte(r) && r != 'c' {
+				continue
+			}
+			letters = append(letters, byte(r))
+		}
+	}
+
+	w := strings.ToUpper(string(letters))
+	switch {
+	case hasPrefix(w, "KN", "GN", "PN", "AE", "WR"):
+		w = w[1:]
+	case hasPrefix(w, "X"):
+		w = "S" + w[1:]
+	case hasPrefix(w, "WH"):
+		w = "W" + w[2:]
+	}
+
+	var code strings.Builder
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		prev, next := at(w, i-1), at(w, i+1)
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				code.WriteByte(c)
+			}
+		case 'B':
+			if !(prev == 'M' && i == len(w)-1) {
+				code.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case next == 'I' && at(w, i+2) == 'A', next == 'H':
+				code.WriteByte('X')
+			case next == 'I', next == 'E', next == 'Y':
+				if prev != 'S' {
+					code.WriteByte('S')
+				}
+			default:
+				code.WriteByte('K')
+			}
+		case 'D':
+			if next == 'G' && isFrontVowel(at(w, i+2)) {
+				code.WriteByte('J')
+			} else {
+				code.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case next == 'H' && !isVowel(at(w, i+2)), next == 'N':
+			case isFrontVowel(next):
+				code.WriteByte('J')
+			default:
+				code.WriteByte('K')
+			}
+		case 'H':
+			if !(isVowel(prev) && !isVowel(next)) && !strings.ContainsRune("CGPST", rune(prev)) {
+				code.WriteByte('H')
+			}
+		case 'K':
+			if prev != 'C' {
+				code.WriteByte('K')
+			}
+		case 'P':
+			if next == 'H' {
+				code.WriteByte('F')
+			} else {
+				code.WriteByte('P')
+			}
+		case 'Q':
+			code.WriteByte('K')
+		case 'S':
+			if next == 'H' || (next == 'I' && (at(w, i+2) == 'O' || at(w, i+2) == 'A')) {
+				code.WriteByte('X')
+			} else {
+				code.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case next == 'I' && (at(w, i+2) == 'O' || at(w, i+2) == 'A'):
+				code.WriteByte('X')
+			case next == 'H':
+				code.WriteByte('0')
+			case next != 'C' || at(w, i+2) != 'H':
+				code.WriteByte('T')
+			}
+		case 'V':
+			code.WriteByte('F')
+		case 'W':
+			if isVowel(next) {
+				code.WriteByte('W')
+			}
+		case 'X':
+			code.WriteString("KS")
+		case 'Y':
+			if isVowel(next) && !isVowel(prev) {
+				code.WriteByte('Y')
+			}
+		case 'Z':
+			code.WriteByte('S')
+		default:
+			code.WriteByte(c)
+		}
+	}
+
+	return code.String()
+}
+
+// PhoneticCode returns the phonetic code of a word or an empty string
+// if the word is too short to be matched phonetically.
+func PhoneticCode(word string) string {
+	if len([]rune(word)) < MinPhoneticLength {
+		return ""
+	}
+
+	return Phonetic(word)
+}
+
+func at(w string, i int) byte {
+	if i < 0 || i >= len(w) {
+		return 0
+	}
+
+	return w[i]
+}
+
+func isVowel(c byte) bool {
+	return c == 'A' || c == 'E' || c == 'I' || c == 'O' || c == 'U'
+}
+
+func isFrontVowel(c byte) bool {
+	return c == 'E' || c == 'I' || c == 'Y'
+}
+
+func hasPrefix(w string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
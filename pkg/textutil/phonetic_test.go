@@ -0,0 +1,46 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhonetic(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{word: "Nokia", expected: "NK"},
+		{word: "Nokeeya", expected: "NK"},
+		{word: "Ericsson", expected: "ERKSN"},
+		{word: "Ericson", expected: "ERKSN"},
+		{word: "Erikson", expected: "ERKSN"},
+		{word: "Phillips", expected: "FLPS"},
+		{word: "Filips", expected: "FLPS"},
+		{word: "Knight", expected: "NT"},
+		{word: "Nite", expected: "NT"},
+		{word: "Xerox", expected: "SRKS"},
+		{word: "Zerox", expected: "SRKS"},
+		{word: "Thomson", expected: "0MSN"},
+		{word: "Shell", expected: "XL"},
+		{word: "Société", expected: "SST"},
+		{word: "Sosiete", expected: "SST"},
+		{word: "", expected: ""},
+		{word: "123", expected: ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.expected, Phonetic(test.word), test.word)
+	}
+}
+
+func TestPhoneticCode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("NK", PhoneticCode("nokeeya"))
+	assert.Equal("AB", Phonetic("abb"))
+	assert.Equal("", PhoneticCode("abb"))
+}
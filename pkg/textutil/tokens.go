@@ -0,0 +1,53 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MinInnerTokenLength shortest part of a word indexed as an inner token.
+const MinInnerTokenLength = 3
+
+// Token searchable part of a name. Word start tokens are whole words,
+// inner tokens are the trailing parts of words such as "banken" in "handelsbanken".
+// Words of at least MinPhoneticLength characters also carry their phonetic code.
+type Token struct {
+	Text      string
+	WordStart bool
+	Phonetic  string
+}
+
+// Words splits folded text into words of letters and digits.
+func Words(text string) []string {
+	return strings.FieldsFunc(Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Tokens returns the distinct tokens of a name. A query term matches a name if it is a
+// prefix of any of its tokens, which finds words anywhere in the name as well as the
+// parts of compound words common in Scandinavian company names.
+func Tokens(name string) []Token {
+	tokens := make([]Token, 0)
+	index := make(map[string]int)
+	add := func(text string, wordStart bool) int {
+		if i, ok := index[text]; ok {
+			tokens[i].WordStart = tokens[i].WordStart || wordStart
+			return i
+		}
+		index[text] = len(tokens)
+		tokens = append(tokens, Token{Text: text, WordStart: wordStart})
+		return len(tokens) - 1
+	}
+
+	for _, word := range Words(name) {
+		i := add(word, true)
+		tokens[i].Phonetic = PhoneticCode(word)
+		runes := []rune(word)
+		for i := 1; len(runes)-i >= MinInnerTokenLength; i++ {
+			add(string(runes[i:]), false)
+		}
+	}
+
+	return tokens
+}
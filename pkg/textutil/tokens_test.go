@@ -0,0 +1,52 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWords(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"general", "motors", "company"}, Words("General Motors Company"))
+	assert.Equal([]string{"at", "t", "inc"}, Words("AT&T, Inc."))
+	assert.Equal([]string{"orsted", "a", "s"}, Words("Ørsted A/S"))
+	assert.Equal(0, len(Words(" - ")))
+}
+
+func TestTokens(t *testing.T) {
+	assert := assert.New(t)
+
+	tokens := Tokens("Svenska Handelsbanken")
+	texts := make(map[string]bool)
+	for _, token := range tokens {
+		texts[token.Text] = token.WordStart
+	}
+
+	assert.True(texts["svenska"])
+	assert.True(texts["handelsbanken"])
+	wordStart, ok := texts["banken"]
+	assert.True(ok)
+	assert.False(wordStart)
+	_, ok = texts["en"]
+	assert.False(ok)
+	assert.Equal(2+(7-3)+(13-3), len(tokens))
+
+	tokens = Tokens("Bank of Bank")
+	assert.Equal(Token{Text: "bank", WordStart: true, Phonetic: "BNK"}, tokens[0])
+	assert.Equal(Token{Text: "ank", WordStart: false}, tokens[1])
+	assert.Equal(Token{Text: "of", WordStart: true}, tokens[2])
+	assert.Equal(3, len(tokens))
+
+	tokens = Tokens("Nokia Oyj")
+	assert.Equal(Token{Text: "nokia", WordStart: true, Phonetic: "NK"}, tokens[0])
+	assert.Equal("", tokens[len(tokens)-1].Phonetic)
+
+	tokens = Tokens("Skanska Ska")
+	for _, token := range tokens {
+		if token.Text == "ska" {
+			assert.True(token.WordStart)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+)
+
+// Policy decides what happens with values outside of the allowed range.
+type Policy string
+
+// Validation policies.
+const (
+	// Clamp adjusts invalid values to the closest allowed value.
+	Clamp Policy = "clamp"
+	// Reject fails the request.
+	Reject Policy = "reject"
+)
+
+// ParsePolicy parses a policy name.
+func ParsePolicy(name string) (Policy, bool) {
+	switch Policy(strings.ToLower(strings.TrimSpace(name))) {
+	case Clamp:
+		return Clamp, true
+	case Reject:
+		return Reject, true
+	default:
+		return "", false
+	}
+}
+
+// DefaultSymbolPattern matches ticker symbols like AAPL, BRK.B and ERIC-B.
+var DefaultSymbolPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.\-]{0,19}$`)
+
+// Rules validation rules of the search parameters shared by all APIs.
+type Rules struct {
+	SearchLimit     LimitRule
+	SuggestionLimit LimitRule
+	Query           QueryRule
+	Symbols         SymbolRule
+}
+
+// DefaultRules returns the default validation rules.
+// Limits are clamped while malformed queries and symbols are rejected.
+func DefaultRules() Rules {
+	return Rules{
+		SearchLimit:     LimitRule{Default: 10, Min: 1, Max: 50, Policy: Clamp},
+		SuggestionLimit: LimitRule{Default: 5, Min: 1, Max: 50, Policy: Clamp},
+		Query:           QueryRule{MaxLength: 100, Policy: Reject},
+		Symbols:         SymbolRule{Pattern: DefaultSymbolPattern, MaxCount: 50, Policy: Reject},
+	}
+}
+
+// LimitRule allowed range of a limit parameter.
+type LimitRule struct {
+	Default int
+	Min     int
+	Max     int
+	Policy  Policy
+}
+
+// Apply validates a limit, returning the limit to use.
+func (r LimitRule) Apply(field string, limit int) (int, error) {
+	if limit >= r.Min && limit <= r.Max {
+		return limit, nil
+	}
+
+	if r.Policy == Reject {
+		return 0, apierror.InvalidLimit(field, fmt.Sprintf("must be between %d and %d", r.Min, r.Max))
+	}
+
+	if limit < r.Min {
+		return r.Min, nil
+	}
+
+	return r.Max, nil
+}
+
+// QueryRule restricts free text search queries.
+// Queries longer than MaxLength characters are truncated when clamped.
+// Queries containing control characters are always rejected.
+type QueryRule struct {
+	MaxLength int
+	Policy    Policy
+}
+
+// Apply validates a query, returning the trimmed query to use.
+func (r QueryRule) Apply(field, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", apierror.MissingQuery(field)
+	}
+
+	if strings.IndexFunc(query, unicode.IsControl) != -1 {
+		return "", apierror.InvalidParameter(field, "must not contain control characters")
+	}
+
+	if utf8.RuneCountInString(query) <= r.MaxLength {
+		return query, nil
+	}
+
+	if r.Policy == Reject {
+		return "", apierror.InvalidParameter(field, fmt.Sprintf("must be at most %d characters", r.MaxLength))
+	}
+
+	return strings.TrimSpace(string([]rune(query)[:r.MaxLength])), nil
+}
+
+// SymbolRule restricts stock symbols and lists of symbols.
+// When clamped invalid symbols are dropped from lists and lists are truncated to MaxCount.
+// A single symbol is always rejected if invalid.
+type SymbolRule struct {
+	Pattern  *regexp.Regexp
+	MaxCount int
+	Policy   Policy
+}
+
+// Check validates a single symbol.
+func (r SymbolRule) Check(field, symbol string) error {
+	if !r.Pattern.MatchString(symbol) {
+		return apierror.InvalidParameter(field, "must be a valid symbol")
+	}
+
+	return nil
+}
+
+// Apply validates a list of symbols, returning the symbols to use.
+func (r SymbolRule) Apply(field string, symbols []string) ([]string, error) {
+	valid := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if r.Pattern.MatchString(symbol) {
+			valid = append(valid, symbol)
+		} else if r.Policy == Reject {
+			return nil, apierror.InvalidParameter(field, "invalid symbol "+symbol)
+		}
+	}
+
+	if len(valid) <= r.MaxCount {
+		return valid, nil
+	}
+
+	if r.Policy == Reject {
+		return nil, apierror.InvalidParameter(field, fmt.Sprintf("must contain at most %d symbols", r.MaxCount))
+	}
+
+	return valid[:r.MaxCount], nil
+}
@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mimir-news/stock-search/pkg/apierror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitRule(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		policy   Policy
+		limit    int
+		expected int
+		code     apierror.Code
+	}{
+		{policy: Clamp, limit: 5, expected: 5},
+		{policy: Clamp, limit: -1, expected: 1},
+		{policy: Clamp, limit: 1000000, expected: 50},
+		{policy: Reject, limit: 50, expected: 50},
+		{policy: Reject, limit: 0, code: apierror.CodeInvalidLimit},
+		{policy: Reject, limit: 51, code: apierror.CodeInvalidLimit},
+	}
+
+	for _, test := range tests {
+		rule := LimitRule{Default: 10, Min: 1, Max: 50, Policy: test.policy}
+		limit, err := rule.Apply("limit", test.limit)
+		if test.code != "" {
+			assert.True(apierror.HasCode(err, test.code), "%v %d", test.policy, test.limit)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expected, limit, "%v %d", test.policy, test.limit)
+	}
+}
+
+func TestQueryRule(t *testing.T) {
+	assert := assert.New(t)
+
+	rule := QueryRule{MaxLength: 5, Policy: Reject}
+	query, err := rule.Apply("query", "  Nestlé ")
+	assert.Error(err)
+
+	query, err = rule.Apply("query", " Ørst ")
+	assert.NoError(err)
+	assert.Equal("Ørst", query)
+
+	_, err = rule.Apply("query", "   ")
+	assert.True(apierror.HasCode(err, apierror.CodeMissingQuery))
+
+	_, err = rule.Apply("query", "a\x00b")
+	assert.True(apierror.HasCode(err, apierror.CodeInvalidParameter))
+
+	rule.Policy = Clamp
+	query, err = rule.Apply("query", "Nestlé SA")
+	assert.NoError(err)
+	assert.Equal("Nestl", query)
+
+	query, err = rule.Apply("query", strings.Repeat("a", 4)+" bc")
+	assert.NoError(err)
+	assert.Equal("aaaa", query)
+}
+
+func TestSymbolRule(t *testing.T) {
+	assert := assert.New(t)
+
+	rule := SymbolRule{Pattern: DefaultSymbolPattern, MaxCount: 2, Policy: Reject}
+	assert.NoError(rule.Check("symbol", "BRK.B"))
+	assert.NoError(rule.Check("symbol", "ERIC-B"))
+	assert.Error(rule.Check("symbol", "AAPL;DROP"))
+	assert.Error(rule.Check("symbol", ""))
+
+	symbols, err := rule.Apply("exclude", []string{"AAPL", "MSFT"})
+	assert.NoError(err)
+	assert.Equal([]string{"AAPL", "MSFT"}, symbols)
+
+	_, err = rule.Apply("exclude", []string{"AAPL", "$MSFT"})
+	assert.True(apierror.HasCode(err, apierror.CodeInvalidParameter))
+	_, err = rule.Apply("exclude", []string{"AAPL", "MSFT", "AMD"})
+	assert.Error(err)
+
+	rule.Policy = Clamp
+	symbols, err = rule.Apply("exclude", []string{"$AAPL", "MSFT", "AMD", "TSLA"})
+	assert.NoError(err)
+	assert.Equal([]string{"MSFT", "AMD"}, symbols)
+}
+
+func TestParsePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, ok := ParsePolicy(" Clamp ")
+	assert.True(ok)
+	assert.Equal(Clamp, policy)
+
+	policy, ok = ParsePolicy("reject")
+	assert.True(ok)
+	assert.Equal(Reject, policy)
+
+	_, ok = ParsePolicy("ignore")
+	assert.False(ok)
+}
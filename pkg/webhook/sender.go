@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Signature headers set on signed webhook requests.
+const (
+	SignatureHeader = "X-Mimir-Signature"
+	TimestampHeader = "X-Mimir-Timestamp"
+)
+
+// Sender sends webhook payloads to subscribers.
+type Sender interface {
+	Send(url string, payload interface{}) error
+	SendSigned(url, secret string, payload interface{}) (int, error)
+}
+
+// NewSender creates a Sender which posts JSON payloads over HTTP.
+func NewSender(timeout time.Duration) Sender {
+	return &httpSender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type httpSender struct {
+	client *http.Client
+}
+
+// Send posts a payload as JSON to the given url.
+func (s *httpSender) Send(url string, payload interface{}) error {
+	_, err := s.post(url, "", payload)
+	return err
+}
+
+// SendSigned posts a payload as JSON to the given url with
+// a HMAC-SHA256 signature of the timestamp and body, returning the response status.
+func (s *httpSender) SendSigned(url, secret string, payload interface{}) (int, error) {
+	return s.post(url, secret, payload)
+}
+
+func (s *httpSender) post(url, secret string, payload interface{}) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("webhook %s responded with status %d", url, res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Sign creates a hex encoded HMAC-SHA256 signature of a timestamp and body.
+// Receivers verify a request by computing the signature of the
+// timestamp header value and raw body using their shared secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MockSender mock implementation of Sender.
+type MockSender struct {
+	mu sync.Mutex
+
+	SendArgURLs     []string
+	SendArgSecrets  []string
+	SendArgPayloads []interface{}
+	SendStatus      int
+	SendErr         error
+}
+
+// Send mock implementation of sending a webhook.
+func (s *MockSender) Send(url string, payload interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SendArgURLs = append(s.SendArgURLs, url)
+	s.SendArgSecrets = append(s.SendArgSecrets, "")
+	s.SendArgPayloads = append(s.SendArgPayloads, payload)
+	return s.SendErr
+}
+
+// SendSigned mock implementation of sending a signed webhook.
+func (s *MockSender) SendSigned(url, secret string, payload interface{}) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SendArgURLs = append(s.SendArgURLs, url)
+	s.SendArgSecrets = append(s.SendArgSecrets, secret)
+	s.SendArgPayloads = append(s.SendArgPayloads, payload)
+	return s.SendStatus, s.SendErr
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendSigned(t *testing.T) {
+	assert := assert.New(t)
+
+	secret := "shared-secret"
+	payload := map[string]string{"event": "ranking.completed"}
+
+	var signature, timestamp string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(SignatureHeader)
+		timestamp = r.Header.Get(TimestampHeader)
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second)
+	status, err := sender.SendSigned(server.URL, secret, payload)
+	assert.NoError(err)
+	assert.Equal(http.StatusNoContent, status)
+	assert.Equal("sha256="+Sign(secret, timestamp, body), signature)
+
+	var received map[string]string
+	err = json.Unmarshal(body, &received)
+	assert.NoError(err)
+	assert.Equal(payload, received)
+
+	err = sender.Send(server.URL, payload)
+	assert.NoError(err)
+	assert.Equal("", signature)
+}
+
+func TestSendFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second)
+	status, err := sender.SendSigned(server.URL, "secret", "payload")
+	assert.Error(err)
+	assert.Equal(http.StatusServiceUnavailable, status)
+}
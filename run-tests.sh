@@ -2,9 +2,20 @@
 
 TARGET_FOLDERS=(
     "./cmd/"
+    "./pkg/apierror/"
+    "./pkg/cache/"
+    "./pkg/client/"
     "./pkg/domain/"
+    "./pkg/graphqlapi/"
+    "./pkg/grpcapi/"
+    "./pkg/migration/"
+    "./pkg/openapi/"
     "./pkg/repository/"
     "./pkg/service/"
+    "./pkg/stream/"
+    "./pkg/textutil/"
+    "./pkg/validation/"
+    "./pkg/webhook/"
 )
 
 test_failed=false
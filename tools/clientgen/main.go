@@ -0,0 +1,41 @@
+// Command clientgen generates the Go client of the stock-search API from its OpenAPI spec.
+//
+// Usage:
+//
+//	clientgen -spec api/openapi.json -package client -out pkg/client/client_gen.go
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+
+	"github.com/mimir-news/stock-search/pkg/openapi"
+)
+
+func main() {
+	specFile := flag.String("spec", "api/openapi.json", "OpenAPI spec in JSON format")
+	pkgName := flag.String("package", "client", "Name of the generated package")
+	out := flag.String("out", "pkg/client/client_gen.go", "Output file")
+	flag.Parse()
+
+	spec, err := openapi.LoadFile(*specFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = spec.Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := openapi.GenerateClient(spec, *pkgName, "api/openapi.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(*out, code, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}